@@ -8,13 +8,23 @@ import (
 	"os/signal"
 	"path"
 	"runtime"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/setavenger/blindbit-lib/logging"
 	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbdump"
 	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+	"github.com/setavenger/blindbit-oracle/internal/events"
+	"github.com/setavenger/blindbit-oracle/internal/health"
 	"github.com/setavenger/blindbit-oracle/internal/indexer"
 	"github.com/setavenger/blindbit-oracle/internal/server"
 	v2 "github.com/setavenger/blindbit-oracle/internal/server/v2"
+	"github.com/setavenger/blindbit-oracle/internal/tlsconfig"
 	"github.com/spf13/cobra"
 )
 
@@ -25,9 +35,16 @@ var (
 	datadir      string
 	configFile   string
 	skipPrecheck bool
+	deepPrecheck bool
 
 	startHeight uint32
 	endHeight   uint32
+
+	verifySample float64
+	verifyOut    string
+
+	dryRun   bool
+	dumpPath string
 )
 
 func init() {
@@ -50,6 +67,12 @@ func init() {
 		false,
 		"Skip database integrity checks and other pre-checks (default: false)",
 	)
+	rootCmd.PersistentFlags().BoolVar(
+		&deepPrecheck,
+		"deep-precheck",
+		false,
+		"Also check secondary indexes of every block during the integrity check and repair them (default: false)",
+	)
 
 	// sync flags
 	syncCmd.Flags().Uint32Var(
@@ -64,6 +87,58 @@ func init() {
 		0,
 		"End height",
 	)
+	syncCmd.Flags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Run the pull and compute pipeline without writing to the database, requires --dump",
+	)
+	syncCmd.Flags().StringVar(
+		&dumpPath,
+		"dump",
+		"",
+		"File to write the tweak dump to during a dry run",
+	)
+
+	// verify-db flags
+	verifyDBCmd.Flags().Uint32Var(
+		&startHeight,
+		"start-height",
+		0,
+		"Start height (default: first indexed block)",
+	)
+	verifyDBCmd.Flags().Uint32Var(
+		&endHeight,
+		"end-height",
+		0,
+		"End height (default: indexed tip)",
+	)
+
+	// verify flags
+	verifyCmd.Flags().Uint32Var(
+		&startHeight,
+		"start-height",
+		0,
+		"Start height (default: first indexed block)",
+	)
+	verifyCmd.Flags().Uint32Var(
+		&endHeight,
+		"end-height",
+		0,
+		"End height (default: indexed tip)",
+	)
+	verifyCmd.Flags().Float64Var(
+		&verifySample,
+		"sample",
+		100,
+		"Percentage of blocks to verify, picked at random",
+	)
+	verifyCmd.Flags().StringVar(
+		&verifyOut,
+		"out",
+		"",
+		"File to write mismatches to as JSON lines (default: stdout)",
+	)
 }
 
 // performDBIntegrityCheck performs database integrity check unless skipped by flag
@@ -71,7 +146,7 @@ func init() {
 func performDBIntegrityCheck(ctx context.Context, builder *indexer.Builder) error {
 	if !skipPrecheck {
 		logging.L.Info().Msg("Performing database integrity check...")
-		err := builder.DBIntegrityCheck(ctx)
+		err := builder.DBIntegrityCheck(ctx, deepPrecheck)
 		if err != nil {
 			return fmt.Errorf("db integrity check failed: %w", err)
 		}
@@ -125,8 +200,14 @@ var syncCmd = &cobra.Command{
 - Not start continuous scanning or servers
 
 Flags:
---skip-precheck flag to skip database integrity checks`,
+--skip-precheck flag to skip database integrity checks
+--dry-run flag to skip writing to the database (optional, default: false)
+--dump flag file for the tweak dump written during a dry run`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if dryRun {
+			return dryRunSync(cmd.Context())
+		}
+
 		logging.L.Info().Msg("Starting initial blockchain sync...")
 
 		db, err := dbpebble.OpenDB()
@@ -137,7 +218,8 @@ Flags:
 		store := dbpebble.NewStore(db)
 		defer store.Close()
 
-		ctx, cancel := context.WithCancel(cmd.Context())
+		// stops at a block boundary and flushes before the store is closed
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
 		defer cancel()
 
 		builder := indexer.NewBuilder(ctx, store)
@@ -172,6 +254,55 @@ Flags:
 	},
 }
 
+// dryRunSync runs the indexing pipeline for the requested range and
+// writes one line per tx with a tweak to the dump file instead of storing the blocks.
+// No database is opened so this can run next to a live instance.
+func dryRunSync(ctx context.Context) error {
+	if dumpPath == "" {
+		return errors.New("--dry-run requires --dump")
+	}
+
+	start, end := startHeight, endHeight
+	if start == 0 {
+		start = config.SyncStartHeight
+	}
+	if end == 0 {
+		chainInfo, err := indexer.GetChainInfo()
+		if err != nil {
+			return fmt.Errorf("failed to get chain info: %w", err)
+		}
+		end = uint32(chainInfo.Blocks)
+	}
+	if start > end {
+		return fmt.Errorf("start height %d is above end height %d", start, end)
+	}
+
+	f, err := os.Create(dumpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %w", err)
+	}
+	defer f.Close()
+
+	logging.L.Info().
+		Uint32("start_height", start).
+		Uint32("end_height", end).
+		Str("dump", dumpPath).
+		Msg("Starting dry run sync...")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	builder := indexer.NewBuilder(ctx, dbdump.NewStore(f, start))
+	err = builder.SyncBlocks(ctx, int64(start), int64(end))
+	if err != nil {
+		return fmt.Errorf("dry run sync failed: %w", err)
+	}
+
+	logging.L.Info().Msg("Dry run sync completed successfully")
+
+	return nil
+}
+
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run the full BlindBit Oracle service",
@@ -186,8 +317,8 @@ Flags:
 --start-height flag to start height (optional, default: 0)
 --end-height flag to end height (optional, default: 0)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		interrupt := make(chan os.Signal, 1)
-		signal.Notify(interrupt, os.Interrupt)
+		ctx, stopSignals := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stopSignals()
 
 		logging.L.Info().Msg("Starting BlindBit Oracle service...")
 
@@ -196,64 +327,284 @@ Flags:
 			return fmt.Errorf("failed opening db: %w", err)
 		}
 
+		// closed last, after servers and indexer stopped
 		store := dbpebble.NewStore(db)
 		defer store.Close()
+		prometheus.MustRegister(store.Collector())
 
-		// Start servers
-		go server.RunServer(server.NewHandler(store))
-
-		if config.GRPCHost != "" {
-			go v2.RunGRPCServer(store)
+		shutdownServers, err := startServers(store)
+		if err != nil {
+			return err
 		}
 
-		// Setup context and error handling
-		ctx, cancel := context.WithCancel(cmd.Context())
-		defer cancel()
+		// the pipeline is not stopped by the signal directly, servers are drained first
+		pipelineCtx, stopPipeline := context.WithCancel(context.WithoutCancel(cmd.Context()))
+		defer stopPipeline()
 
 		errChan := make(chan error, 1)
+		pipelineDone := make(chan struct{})
 
 		// Start indexer
+		health.SetIndexerRunning()
+		health.SetInitialSync(true)
 		go func() {
-			builder := indexer.NewBuilder(ctx, store)
+			defer close(pipelineDone)
+			builder := indexer.NewBuilder(pipelineCtx, store)
 
 			// Perform database integrity check unless skipped
-			err = performDBIntegrityCheck(ctx, builder)
+			err := performDBIntegrityCheck(pipelineCtx, builder)
 			if err != nil {
+				health.SetBuilderError(err)
 				errChan <- err
 				return
 			}
 
 			// Do initial sync
-			err = builder.InitialSyncToTip(ctx)
+			err = builder.InitialSyncToTip(pipelineCtx)
 			if err != nil {
+				health.SetBuilderError(err)
 				errChan <- fmt.Errorf("failed initial sync: %w", err)
 				return
 			}
+			health.SetInitialSync(false)
 			logging.L.Info().Msg("initial sync done")
 
 			// Start continuous sync
-			err = builder.ContinuousSync(ctx)
+			err = builder.ContinuousSync(pipelineCtx)
 			if err != nil {
+				health.SetBuilderError(err)
 				errChan <- fmt.Errorf("continuous sync failed: %w", err)
 				return
 			}
 		}()
 
 		// Wait for interrupt or error
-		for {
+		var pipelineErr error
+		select {
+		case <-ctx.Done():
+			// a second signal kills the process right away
+			stopSignals()
+			logging.L.Info().Msg("Service interrupted, shutting down")
+		case pipelineErr = <-errChan:
+		}
+
+		// servers first so no handler reads while the store is closed
+		shutdownServers()
+		stopPipeline()
+		<-pipelineDone
+
+		if pipelineErr == nil {
 			select {
-			case <-interrupt:
-				cancel()
-				logging.L.Info().Msg("Service interrupted")
-				return nil
-			case err := <-errChan:
-				cancel()
-				return err
+			case pipelineErr = <-errChan:
+			default:
+			}
+			if errors.Is(pipelineErr, context.Canceled) {
+				pipelineErr = nil
+			}
+		}
+		if pipelineErr != nil {
+			return pipelineErr
+		}
+		logging.L.Info().Msg("shutdown complete")
+		return nil
+	},
+}
+
+// startServers starts the HTTP server (with gRPC-Web) and the gRPC server if grpc_host is set.
+// The returned function shuts them down: event subscriptions end, new requests are refused
+// and running ones get config.ShutdownTimeout to finish before they are cancelled.
+func startServers(store database.DB) (func(), error) {
+	tlsReloader, err := tlsconfig.FromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed loading tls certificate: %w", err)
+	}
+	if tlsReloader != nil {
+		tlsReloader.ReloadOnSIGHUP()
+	}
+
+	grpcServer := v2.NewGRPCServer(store, tlsReloader)
+	var grpcWeb *grpcweb.WrappedGrpcServer
+	if config.GRPCWeb {
+		grpcWeb = v2.NewGRPCWebServer(grpcServer)
+	}
+
+	serveCtx, stopServing := context.WithCancel(context.Background())
+	httpDone := make(chan struct{})
+	go func() {
+		defer close(httpDone)
+		server.RunServer(serveCtx, server.NewHandler(store), grpcWeb, tlsReloader)
+	}()
+
+	if config.GRPCHost != "" {
+		go v2.RunGRPCServer(grpcServer)
+	}
+
+	return func() {
+		// subscriptions never end by themselves
+		events.Blocks.Close()
+		stopServing()
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeout)*time.Second)
+		defer cancel()
+		// also covers gRPC-Web calls on the HTTP listener
+		v2.ShutdownGRPCServer(drainCtx, grpcServer)
+		<-httpDone
+		logging.L.Info().Msg("servers stopped")
+	}, nil
+}
+
+var verifyDBCmd = &cobra.Command{
+	Use:   "verify-db",
+	Short: "Verify stored blocks against their digests",
+	Long: `Recompute the digest over all keys and values of every indexed block
+and compare it with the digest stored when the block was written.
+Heights with a mismatch or a missing block are reported at the end
+and can be re-indexed with sync --start-height and --end-height.
+
+Blocks indexed before digests were introduced cannot be verified and are only counted.
+
+Flags:
+--start-height flag to start height (optional, default: first indexed block)
+--end-height flag to end height (optional, default: indexed tip)`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := dbpebble.OpenDB()
+		if err != nil {
+			return fmt.Errorf("failed opening db: %w", err)
+		}
+
+		store := dbpebble.NewStore(db)
+		defer store.Close()
+
+		start, end := startHeight, endHeight
+		if start == 0 {
+			_, start, err = store.FirstBlock()
+			if err != nil {
+				return fmt.Errorf("failed to read first block: %w", err)
+			}
+		}
+		if end == 0 {
+			_, end, err = store.GetChainTip()
+			if err != nil {
+				return fmt.Errorf("failed to read chain tip: %w", err)
 			}
 		}
+		if start > end {
+			return fmt.Errorf("start height %d is above end height %d", start, end)
+		}
+
+		report, err := store.VerifyBlockDigests(cmd.Context(), start, end, config.MaxCPUCores)
+		if err != nil {
+			return fmt.Errorf("verifying digests failed: %w", err)
+		}
+
+		logging.L.Info().
+			Int("checked", report.Checked).
+			Int("mismatched", len(report.Mismatched)).
+			Int("missing", len(report.Missing)).
+			Int("undigested", len(report.Undigested)).
+			Msg("block digest verification done")
+
+		bad := report.BadHeights()
+		if len(bad) == 0 {
+			fmt.Printf("no bad heights between %d and %d\n", start, end)
+			return nil
+		}
+
+		fmt.Printf("bad heights: %s\n", formatHeightRanges(bad))
+		fmt.Println("re-index with:")
+		for _, r := range indexer.HeightsToRanges(bad) {
+			fmt.Printf("  blindbit-oracle sync --start-height %d --end-height %d\n", r.Start, r.End)
+		}
+
+		return nil
 	},
 }
 
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify stored tweaks by recomputing them from Core",
+	Long: `Pull every block (or a random sample) from Core, recompute the tweaks
+and compare them with the stored tweaks and the compute index.
+Each mismatch is written as one JSON object per line.
+
+Flags:
+--start-height flag to start height (optional, default: first indexed block)
+--end-height flag to end height (optional, default: indexed tip)
+--sample flag percentage of blocks to verify (optional, default: 100)
+--out flag file for the mismatches (optional, default: stdout)`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if verifySample <= 0 || verifySample > 100 {
+			return fmt.Errorf("sample has to be within (0, 100], got %v", verifySample)
+		}
+
+		db, err := dbpebble.OpenDB()
+		if err != nil {
+			return fmt.Errorf("failed opening db: %w", err)
+		}
+
+		store := dbpebble.NewStore(db)
+		defer store.Close()
+
+		start, end := startHeight, endHeight
+		if start == 0 {
+			_, start, err = store.FirstBlock()
+			if err != nil {
+				return fmt.Errorf("failed to read first block: %w", err)
+			}
+		}
+		if end == 0 {
+			_, end, err = store.GetChainTip()
+			if err != nil {
+				return fmt.Errorf("failed to read chain tip: %w", err)
+			}
+		}
+		if start > end {
+			return fmt.Errorf("start height %d is above end height %d", start, end)
+		}
+
+		out := os.Stdout
+		if verifyOut != "" {
+			out, err = os.Create(verifyOut)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer out.Close()
+		}
+
+		builder := indexer.NewBuilder(cmd.Context(), store)
+		summary, err := builder.VerifyRange(cmd.Context(), start, end, verifySample, out)
+		if err != nil {
+			return fmt.Errorf("verifying tweaks failed: %w", err)
+		}
+
+		logging.L.Info().
+			Int("checked", summary.Checked).
+			Int("skipped", summary.Skipped).
+			Int("mismatches", summary.Mismatches).
+			Int("bad_heights", len(summary.BadHeights)).
+			Msg("tweak verification done")
+
+		if len(summary.BadHeights) > 0 {
+			return fmt.Errorf("found %d mismatches at heights %s",
+				summary.Mismatches, formatHeightRanges(summary.BadHeights))
+		}
+
+		return nil
+	},
+}
+
+func formatHeightRanges(heights []uint32) string {
+	parts := make([]string, 0, len(heights))
+	for _, r := range indexer.HeightsToRanges(heights) {
+		if r.Start == r.End {
+			parts = append(parts, fmt.Sprintf("%d", r.Start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
 // server only command no syncing only servers are set up
 var serverCmd = &cobra.Command{
 	Use:   "server-only",
@@ -262,6 +613,9 @@ var serverCmd = &cobra.Command{
 - HTTP API server
 - gRPC server (if configured)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stopSignals := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stopSignals()
+
 		logging.L.Info().Msg("Starting BlindBit Oracle service...")
 
 		db, err := dbpebble.OpenDB()
@@ -271,20 +625,19 @@ var serverCmd = &cobra.Command{
 
 		store := dbpebble.NewStore(db)
 		defer store.Close()
+		prometheus.MustRegister(store.Collector())
 
-		// Start servers
-		go server.RunServer(server.NewHandler(store))
-
-		if config.GRPCHost != "" {
-			go v2.RunGRPCServer(store)
+		shutdownServers, err := startServers(store)
+		if err != nil {
+			return err
 		}
 
-		// Wait for interrupt or error
-		interrupt := make(chan os.Signal, 1)
-		signal.Notify(interrupt, os.Interrupt)
-
-		<-interrupt
-		logging.L.Info().Msg("Service interrupted")
+		// Wait for interrupt
+		<-ctx.Done()
+		// a second signal kills the process right away
+		stopSignals()
+		logging.L.Info().Msg("Service interrupted, shutting down")
+		shutdownServers()
 
 		return nil
 	},
@@ -295,6 +648,8 @@ func main() {
 	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(verifyDBCmd)
+	rootCmd.AddCommand(verifyCmd)
 
 	// Execute the root command
 	if err := rootCmd.Execute(); err != nil {
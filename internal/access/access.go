@@ -0,0 +1,187 @@
+// Package access implements the optional API-key auth, the per-client token bucket rate limit
+// and the per-minute height quota shared by the HTTP and the gRPC server.
+//
+// Clients are identified by their API key if it is a configured one, by IP otherwise.
+// Everything is disabled with the default configuration.
+package access
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+var (
+	// ErrUnauthorized no or an unknown API key while keys are configured
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited the client exceeded its request rate
+	ErrRateLimited = errors.New("rate limited")
+	// ErrQuotaExceeded the client requested more heights in the current minute than allowed
+	ErrQuotaExceeded = errors.New("height quota exceeded")
+)
+
+const (
+	quotaWindow = time.Minute
+	// clients not seen for this long are dropped
+	idleTimeout = 10 * time.Minute
+)
+
+type client struct {
+	bucket      *rate.Limiter
+	windowStart time.Time
+	heights     uint64
+	lastSeen    time.Time
+}
+
+// Limiter keeps the state per client
+type Limiter struct {
+	apiKeys          [][]byte
+	ratePerSecond    float64
+	burst            int
+	heightsPerMinute uint64
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+// Shared returns the limiter built from the config on first use.
+// Both servers use it so limits apply per process and not per protocol.
+var Shared = sync.OnceValue(func() *Limiter {
+	apiKeys := config.APIKeys
+	if len(apiKeys) > 0 {
+		// scan keys have to pass the API key check as well
+		apiKeys = append(slices.Clone(apiKeys), config.ScanAPIKeys...)
+	}
+	return NewLimiter(
+		apiKeys,
+		config.RateLimitPerSecond,
+		config.RateLimitBurst,
+		config.MaxHeightsPerMinute,
+	)
+})
+
+// NewLimiter creates a limiter. Empty apiKeys disables auth,
+// ratePerSecond 0 disables the rate limit and heightsPerMinute 0 the quota.
+func NewLimiter(apiKeys []string, ratePerSecond float64, burst int, heightsPerMinute uint64) *Limiter {
+	l := &Limiter{
+		ratePerSecond:    ratePerSecond,
+		burst:            max(burst, 1),
+		heightsPerMinute: heightsPerMinute,
+		clients:          make(map[string]*client),
+	}
+	for _, key := range apiKeys {
+		if key != "" {
+			l.apiKeys = append(l.apiKeys, []byte(key))
+		}
+	}
+	return l
+}
+
+// Authenticate checks apiKey if keys are configured
+func (l *Limiter) Authenticate(apiKey string) error {
+	if len(l.apiKeys) == 0 {
+		return nil
+	}
+	if apiKey == "" {
+		return fmt.Errorf("missing api key: %w", ErrUnauthorized)
+	}
+	if !l.knownKey(apiKey) {
+		return fmt.Errorf("unknown api key: %w", ErrUnauthorized)
+	}
+	return nil
+}
+
+// ClientID identifies a client by its API key if it is a configured one and falls back to the IP.
+// Unknown keys are ignored, otherwise a new key per request would get a fresh bucket each time.
+func (l *Limiter) ClientID(apiKey, ip string) string {
+	if apiKey != "" && l.knownKey(apiKey) {
+		return "key:" + apiKey
+	}
+	return "ip:" + ip
+}
+
+func (l *Limiter) knownKey(apiKey string) bool {
+	for _, key := range l.apiKeys {
+		if subtle.ConstantTimeCompare(key, []byte(apiKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow takes one token from the client's bucket.
+// The returned duration is how long the client should wait if it was rate limited.
+func (l *Limiter) Allow(clientID string) (time.Duration, error) {
+	if l.ratePerSecond <= 0 {
+		return 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c := l.clientLocked(clientID)
+	reservation := c.bucket.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return delay, ErrRateLimited
+	}
+	return 0, nil
+}
+
+// ConsumeHeights adds n requested heights to the client's quota for the current minute
+func (l *Limiter) ConsumeHeights(clientID string, n uint64) error {
+	if l.heightsPerMinute == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c := l.clientLocked(clientID)
+	if c.lastSeen.Sub(c.windowStart) >= quotaWindow {
+		c.windowStart = c.lastSeen
+		c.heights = 0
+	}
+	if c.heights+n > l.heightsPerMinute {
+		return fmt.Errorf(
+			"%d of %d heights used this minute, %d requested: %w",
+			c.heights, l.heightsPerMinute, n, ErrQuotaExceeded,
+		)
+	}
+	c.heights += n
+	return nil
+}
+
+// clientLocked returns the state for clientID and drops idle clients from time to time.
+// Expects l.mu to be held.
+func (l *Limiter) clientLocked(clientID string) *client {
+	now := time.Now()
+
+	if now.Sub(l.lastSweep) > idleTimeout {
+		for id, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleTimeout {
+				delete(l.clients, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[clientID]
+	if !ok {
+		c = &client{
+			bucket:      rate.NewLimiter(rate.Limit(l.ratePerSecond), l.burst),
+			windowStart: now,
+		}
+		l.clients[clientID] = c
+	}
+	c.lastSeen = now
+	return c
+}
@@ -0,0 +1,94 @@
+package access
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	open := NewLimiter(nil, 0, 0, 0)
+	if err := open.Authenticate(""); err != nil {
+		t.Errorf("no keys configured: got %v", err)
+	}
+
+	l := NewLimiter([]string{"a", "b"}, 0, 0, 0)
+	tests := []struct {
+		key  string
+		want error
+	}{
+		{key: "a", want: nil},
+		{key: "b", want: nil},
+		{key: "", want: ErrUnauthorized},
+		{key: "c", want: ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		if err := l.Authenticate(tt.key); !errors.Is(err, tt.want) {
+			t.Errorf("key %q: got %v, want %v", tt.key, err, tt.want)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	l := NewLimiter(nil, 1, 2, 0)
+
+	for i := range 2 {
+		if _, err := l.Allow("ip:1"); err != nil {
+			t.Fatalf("request %d within burst: %v", i, err)
+		}
+	}
+	wait, err := l.Allow("ip:1")
+	if !errors.Is(err, ErrRateLimited) || wait <= 0 {
+		t.Errorf("request over burst: got %v, wait %v", err, wait)
+	}
+
+	// buckets are per client
+	if _, err = l.Allow("ip:2"); err != nil {
+		t.Errorf("other client: %v", err)
+	}
+}
+
+func TestConsumeHeights(t *testing.T) {
+	l := NewLimiter(nil, 0, 0, 100)
+
+	if err := l.ConsumeHeights("key:x", 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ConsumeHeights("key:x", 41); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("over quota: got %v", err)
+	}
+	// a rejected request does not use up the quota
+	if err := l.ConsumeHeights("key:x", 40); err != nil {
+		t.Errorf("up to quota: %v", err)
+	}
+	if err := l.ConsumeHeights("key:y", 100); err != nil {
+		t.Errorf("other client: %v", err)
+	}
+
+	unlimited := NewLimiter(nil, 0, 0, 0)
+	if err := unlimited.ConsumeHeights("key:x", 1_000_000); err != nil {
+		t.Errorf("quota disabled: %v", err)
+	}
+}
+
+func TestClientID(t *testing.T) {
+	open := NewLimiter(nil, 1, 1, 0)
+	// without configured keys any sent key is ignored
+	if id := open.ClientID("random", "10.0.0.1"); id != "ip:10.0.0.1" {
+		t.Errorf("no keys configured: got %q", id)
+	}
+
+	l := NewLimiter([]string{"a"}, 1, 1, 0)
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "a", want: "key:a"},
+		{key: "b", want: "ip:10.0.0.1"},
+		{key: "", want: "ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		if id := l.ClientID(tt.key, "10.0.0.1"); id != tt.want {
+			t.Errorf("key %q: got %q, want %q", tt.key, id, tt.want)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -24,6 +26,9 @@ func LoadConfigs(pathToConfig string) {
 	viper.SetDefault("max_cpu_cores", MaxCPUCores)
 	viper.SetDefault("http_host", HTTPHost)
 	viper.SetDefault("grpc_host", GRPCHost)
+	viper.SetDefault("grpc_web", GRPCWeb)
+	viper.SetDefault("unix_socket_mode", fmt.Sprintf("%04o", UnixSocketMode))
+	viper.SetDefault("http_h2c", HTTPH2C)
 	viper.SetDefault("chain", "signet")
 
 	viper.SetDefault("core_rpc_endpoint", RpcEndpoint)
@@ -34,11 +39,31 @@ func LoadConfigs(pathToConfig string) {
 	viper.SetDefault("tweaks_full_with_dust_filter", false)
 	viper.SetDefault("tweaks_cut_through_with_dust_filter", false)
 	viper.SetDefault("log_level", "info")
+	viper.SetDefault("max_range_per_request", MaxRangePerRequest)
+	viper.SetDefault("cache_min_confirmations", CacheMinConfirmations)
+	viper.SetDefault("cache_max_age_deep", CacheMaxAgeDeep)
+	viper.SetDefault("cache_max_age_tip", CacheMaxAgeTip)
+	viper.SetDefault("readiness_max_lag", ReadinessMaxLag)
+	viper.SetDefault("shutdown_timeout", ShutdownTimeout)
+	viper.SetDefault("api_keys", APIKeys)
+	viper.SetDefault("rate_limit_per_second", RateLimitPerSecond)
+	viper.SetDefault("rate_limit_burst", RateLimitBurst)
+	viper.SetDefault("max_heights_per_minute", MaxHeightsPerMinute)
+	viper.SetDefault("cors_allowed_origins", CORSAllowedOrigins)
+	viper.SetDefault("trusted_proxies", TrustedProxies)
+	viper.SetDefault("scan_rpc", ScanRPC)
+	viper.SetDefault("scan_api_keys", ScanAPIKeys)
 
 	// Bind viper keys to environment variables (optional, for backup)
 	viper.AutomaticEnv()
 	viper.BindEnv("http_host", "HTTP_HOST")
 	viper.BindEnv("grpc_host", "GRPC_HOST")
+	viper.BindEnv("grpc_web", "GRPC_WEB")
+	viper.BindEnv("unix_socket_mode", "UNIX_SOCKET_MODE")
+	viper.BindEnv("http_h2c", "HTTP_H2C")
+	viper.BindEnv("tls_cert_file", "TLS_CERT_FILE")
+	viper.BindEnv("tls_key_file", "TLS_KEY_FILE")
+	viper.BindEnv("tls_client_ca_file", "TLS_CLIENT_CA_FILE")
 	viper.BindEnv("chain", "CHAIN")
 	viper.BindEnv("core_rpc_endpoint", "CORE_RPC_ENDPOINT")
 	viper.BindEnv("core_rest_endpoint", "CORE_REST_ENDPOINT")
@@ -54,18 +79,67 @@ func LoadConfigs(pathToConfig string) {
 	viper.BindEnv("tweaks_full_with_dust_filter", "TWEAKS_FULL_WITH_DUST_FILTER")
 	viper.BindEnv("tweaks_cut_through_with_dust_filter", "TWEAKS_CUT_THROUGH_WITH_DUST_FILTER")
 	viper.BindEnv("log_level", "LOG_LEVEL")
+	viper.BindEnv("max_range_per_request", "MAX_RANGE_PER_REQUEST")
+	viper.BindEnv("cache_min_confirmations", "CACHE_MIN_CONFIRMATIONS")
+	viper.BindEnv("cache_max_age_deep", "CACHE_MAX_AGE_DEEP")
+	viper.BindEnv("cache_max_age_tip", "CACHE_MAX_AGE_TIP")
+	viper.BindEnv("readiness_max_lag", "READINESS_MAX_LAG")
+	viper.BindEnv("shutdown_timeout", "SHUTDOWN_TIMEOUT")
+	viper.BindEnv("api_keys", "API_KEYS")
+	viper.BindEnv("rate_limit_per_second", "RATE_LIMIT_PER_SECOND")
+	viper.BindEnv("rate_limit_burst", "RATE_LIMIT_BURST")
+	viper.BindEnv("max_heights_per_minute", "MAX_HEIGHTS_PER_MINUTE")
+	viper.BindEnv("cors_allowed_origins", "CORS_ALLOWED_ORIGINS")
+	viper.BindEnv("trusted_proxies", "TRUSTED_PROXIES")
+	viper.BindEnv("scan_rpc", "SCAN_RPC")
+	viper.BindEnv("scan_api_keys", "SCAN_API_KEYS")
 
 	/* read and set config variables */
 	// General
 	SyncStartHeight = viper.GetUint32("sync_start_height")
 	HTTPHost = viper.GetString("http_host")
 	GRPCHost = viper.GetString("grpc_host")
+	GRPCWeb = viper.GetBool("grpc_web")
+	HTTPH2C = viper.GetBool("http_h2c")
+
+	// octal like chmod, e.g. "0660"
+	socketMode, err := strconv.ParseUint(viper.GetString("unix_socket_mode"), 8, 32)
+	if err != nil || socketMode > 0o777 {
+		logging.L.Fatal().Err(err).Msg("unix_socket_mode has to be an octal permission like 0660")
+		return
+	}
+	UnixSocketMode = os.FileMode(socketMode)
+
+	// TLS
+	TLSCertFile = viper.GetString("tls_cert_file")
+	TLSKeyFile = viper.GetString("tls_key_file")
+	TLSClientCAFile = viper.GetString("tls_client_ca_file")
 	LogLevel = viper.GetString("log_level")
 
 	// Performance
 	MaxParallelRequests = viper.GetUint16("max_parallel_requests")
 	MaxParallelTweakComputations = viper.GetInt("max_parallel_tweak_computations")
 	MaxCPUCores = viper.GetInt("max_cpu_cores")
+	MaxRangePerRequest = viper.GetUint32("max_range_per_request")
+
+	// HTTP caching
+	CacheMinConfirmations = viper.GetUint32("cache_min_confirmations")
+	CacheMaxAgeDeep = viper.GetInt("cache_max_age_deep")
+	CacheMaxAgeTip = viper.GetInt("cache_max_age_tip")
+
+	// Health
+	ReadinessMaxLag = viper.GetUint32("readiness_max_lag")
+	ShutdownTimeout = viper.GetInt("shutdown_timeout")
+
+	// Access control
+	APIKeys = viper.GetStringSlice("api_keys")
+	RateLimitPerSecond = viper.GetFloat64("rate_limit_per_second")
+	RateLimitBurst = viper.GetInt("rate_limit_burst")
+	MaxHeightsPerMinute = viper.GetUint64("max_heights_per_minute")
+	CORSAllowedOrigins = viper.GetStringSlice("cors_allowed_origins")
+	TrustedProxies = viper.GetStringSlice("trusted_proxies")
+	ScanRPC = viper.GetBool("scan_rpc")
+	ScanAPIKeys = viper.GetStringSlice("scan_api_keys")
 
 	// RPC
 	RpcEndpoint = viper.GetString("core_rpc_endpoint")
@@ -130,6 +204,16 @@ func LoadConfigs(pathToConfig string) {
 		return
 	}
 
+	// 0 would reject every range request, even for a single block
+	if MaxRangePerRequest == 0 {
+		logging.L.Fatal().Msg("max_range_per_request has to be at least 1")
+		return
+	}
+
+	if ScanRPC && len(ScanAPIKeys) > 0 && TLSCertFile == "" {
+		logging.L.Warn().Msg("scan_rpc is enabled without TLS, scan secret keys and scan API keys go over the wire in plaintext")
+	}
+
 	// Bitcoin Core REST needs no RPC credentials; only enforce auth when RPC is used without REST.
 	if RpcEndpoint != "" && RestEndpoint == "" {
 		if CookiePath != "" {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"os"
 	"runtime"
 
 	"github.com/setavenger/blindbit-lib/logging"
@@ -39,6 +40,18 @@ var (
 
 	HTTPHost = "127.0.0.1:8000"
 	GRPCHost = "" // default value is empty (deactivated)
+	// UnixSocketMode are the permissions of unix:// sockets for http_host and grpc_host
+	UnixSocketMode os.FileMode = 0o660
+	// HTTPH2C serves cleartext HTTP/2 (prior knowledge) next to HTTP/1.1 on the HTTP listener, opt-in
+	HTTPH2C = false
+	// GRPCWeb serves the gRPC service as gRPC-Web on the HTTP listener, opt-in
+	// as it exposes the whole gRPC service wherever the HTTP API is reachable
+	GRPCWeb = false
+
+	// TLSCertFile and TLSKeyFile enable TLS on both listeners, TLSClientCAFile additionally mTLS
+	TLSCertFile     = ""
+	TLSKeyFile      = ""
+	TLSClientCAFile = ""
 )
 
 type chain int
@@ -67,11 +80,47 @@ var (
 	// We default to max num cores - 2
 	MaxCPUCores = max(1, runtime.NumCPU()-2)
 
+	// MaxRangePerRequest is the maximum number of heights a single range request may span
+	MaxRangePerRequest uint32 = 1_000
+
+	// CacheMinConfirmations blocks with more confirmations are served with CacheMaxAgeDeep,
+	// blocks closer to the tip with CacheMaxAgeTip (both in seconds)
+	CacheMinConfirmations uint32 = 6
+	CacheMaxAgeDeep              = 86_400
+	CacheMaxAgeTip               = 10
+
+	// ReadinessMaxLag the oracle reports not ready if it is more blocks behind Core
+	ReadinessMaxLag uint32 = 3
+
+	// ShutdownTimeout seconds in-flight requests and streams get to finish on shutdown
+	ShutdownTimeout = 15
+
 	// PruneFrequency every x blocks the data will be checked and pruned
 	// possible routines: -remove utxos for 100% spent transaction
 	PruneFrequency = 72
 )
 
+// access control, everything is disabled by default
+var (
+	// APIKeys if set, every request (except health checks and metrics) needs one of the keys
+	APIKeys []string
+	// RateLimitPerSecond requests per second per API key or IP, 0 disables the limit
+	RateLimitPerSecond float64
+	// RateLimitBurst size of the token bucket
+	RateLimitBurst = 20
+	// MaxHeightsPerMinute how many heights a client can request per minute, 0 disables the quota
+	MaxHeightsPerMinute uint64
+	// CORSAllowedOrigins credentials are only allowed if the origins are listed explicitly
+	CORSAllowedOrigins = []string{"*"}
+	// TrustedProxies IPs or CIDRs whose X-Forwarded-For header is used as the client IP, none by default
+	TrustedProxies []string
+	// ScanRPC serves ScanRange and the wallet methods, which receive scan secret keys,
+	// only to native gRPC clients on the same host, holders of a ScanAPIKeys key or mTLS clients
+	ScanRPC bool
+	// ScanAPIKeys grant scanning, unlike APIKeys which only grant the public data
+	ScanAPIKeys []string
+)
+
 // one has to call SetDirectories otherwise config.DBPath will be empty
 var (
 	DBPathHeaders              string
@@ -111,6 +160,18 @@ func HeaderMustSyncHeight() uint32 {
 	}
 }
 
+// DustFilterSupported reports whether the dust limit can be applied to the served compute index.
+// It needs the output amounts, which tweaks_only does not store.
+func DustFilterSupported() bool {
+	return !TweaksOnly
+}
+
+// CutThroughSupported reports whether cut-through can be applied to the served compute index.
+// It needs the spent outputs of every block, which tweaks_only does not store.
+func CutThroughSupported() bool {
+	return !TweaksOnly
+}
+
 func ChainToString(c chain) string {
 	switch c {
 	case Mainnet:
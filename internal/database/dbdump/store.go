@@ -0,0 +1,156 @@
+// Package dbdump implements the write side of the database.DB interface
+// by writing a canonical tweak dump instead of storing the blocks.
+//
+// Used for dry runs, the output is meant to be diffed against other indexers.
+package dbdump
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+// Line is one transaction with a tweak in the dump.
+// Txids are hex in display order, outputs are x-only keys in vout order.
+type Line struct {
+	Height  uint32   `json:"height"`
+	Txid    string   `json:"txid"`
+	Tweak   string   `json:"tweak"`
+	Outputs []string `json:"outputs"`
+}
+
+// Store writes every applied block to w as one JSON line per tx with a tweak.
+// Blocks arrive out of order from the pipeline so they are held back
+// until all lower heights were written, which keeps the dump sorted by height.
+//
+// Only the methods used by the sync pipeline do something,
+// the others return ErrUnsupported (see unsupported.go).
+type Store struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	enc     *json.Encoder
+	next    uint32
+	pending map[uint32]*database.DBBlock
+
+	tipHash   []byte
+	tipHeight uint32
+}
+
+var _ database.DB = (*Store)(nil)
+
+// NewStore creates a dump store which expects the first block at startHeight
+func NewStore(w io.Writer, startHeight uint32) *Store {
+	bw := bufio.NewWriter(w)
+	return &Store{
+		w:       bw,
+		enc:     json.NewEncoder(bw),
+		next:    startHeight,
+		pending: make(map[uint32]*database.DBBlock),
+	}
+}
+
+func (s *Store) ApplyBlock(block *database.DBBlock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[block.Height] = block
+	for {
+		block, ok := s.pending[s.next]
+		if !ok {
+			return nil
+		}
+		if err := s.writeBlock(block); err != nil {
+			return err
+		}
+		delete(s.pending, s.next)
+		s.next++
+	}
+}
+
+// FlushBatch writes blocks still held back because of a lower height that never arrived
+// and flushes the writer
+func (s *Store) FlushBatch(sync bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) > 0 {
+		heights := make([]uint32, 0, len(s.pending))
+		for height := range s.pending {
+			heights = append(heights, height)
+		}
+		slices.Sort(heights)
+
+		logging.L.Warn().
+			Uint32("missing_height", s.next).
+			Int("pending", len(heights)).
+			Msg("writing blocks after a missing height")
+
+		for _, height := range heights {
+			if err := s.writeBlock(s.pending[height]); err != nil {
+				return err
+			}
+			delete(s.pending, height)
+		}
+		s.next = heights[len(heights)-1] + 1
+	}
+
+	return s.w.Flush()
+}
+
+// GetChainTip returns the highest block written so far
+func (s *Store) GetChainTip() ([]byte, uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tipHash, s.tipHeight, nil
+}
+
+// BatchSize returns the number of blocks held back
+func (s *Store) BatchSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// writeBlock expects s.mu to be held
+func (s *Store) writeBlock(block *database.DBBlock) error {
+	for _, tx := range block.Txs {
+		if tx == nil || tx.Tweak == nil {
+			continue
+		}
+
+		outs := slices.Clone(tx.Outs)
+		slices.SortFunc(outs, func(a, b *database.Output) int {
+			return cmp.Compare(a.Vout, b.Vout)
+		})
+
+		line := Line{
+			Height:  block.Height,
+			Txid:    hex.EncodeToString(utils.ReverseBytesCopy(tx.Txid)),
+			Tweak:   hex.EncodeToString(tx.Tweak[:]),
+			Outputs: make([]string, len(outs)),
+		}
+		for i := range outs {
+			line.Outputs[i] = hex.EncodeToString(outs[i].Pubkey)
+		}
+
+		if err := s.enc.Encode(line); err != nil {
+			logging.L.Err(err).Uint32("height", block.Height).Msg("failed to write dump line")
+			return err
+		}
+	}
+
+	if block.Height >= s.tipHeight {
+		s.tipHash = block.Hash[:]
+		s.tipHeight = block.Height
+	}
+
+	return nil
+}
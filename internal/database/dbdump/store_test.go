@@ -0,0 +1,97 @@
+package dbdump
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+)
+
+func TestDumpOrder(t *testing.T) {
+	fill := func(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+	tweak := [33]byte{0x02, 0x11}
+	txA := append([]byte{0x01}, fill(0xa1)[1:]...)
+	txB, txC := fill(0xb2), fill(0xc3)
+	block := func(height uint32, txs ...*database.Tx) *database.DBBlock {
+		return &database.DBBlock{Height: height, Hash: &chainhash.Hash{byte(height)}, Txs: txs}
+	}
+
+	var out bytes.Buffer
+	s := NewStore(&out, 10)
+	blocks := []*database.DBBlock{
+		// the pipeline hands blocks over out of order
+		block(11, &database.Tx{Txid: txB, Tweak: &tweak, Outs: []*database.Output{{Txid: txB, Pubkey: fill(0x0b)}}}),
+		block(10,
+			&database.Tx{Txid: txA, Tweak: &tweak, Outs: []*database.Output{
+				{Txid: txA, Vout: 1, Pubkey: fill(0x1a)},
+				{Txid: txA, Vout: 0, Pubkey: fill(0x0a)},
+			}},
+			// no tweak, not in the dump
+			&database.Tx{Txid: fill(0xee), Outs: []*database.Output{{Txid: fill(0xee), Pubkey: fill(0xee)}}},
+			nil,
+		),
+		// height 12 never arrives
+		block(13, &database.Tx{Txid: txC, Tweak: &tweak, Outs: []*database.Output{{Txid: txC, Pubkey: fill(0x0c)}}}),
+	}
+	for _, b := range blocks {
+		if err := s.ApplyBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.BatchSize() != 1 {
+		t.Errorf("%d blocks held back, want 1", s.BatchSize())
+	}
+	if err := s.FlushBatch(true); err != nil {
+		t.Fatal(err)
+	}
+
+	tweakHex := "0211" + strings.Repeat("00", 31)
+	want := []Line{
+		{
+			Height:  10,
+			Txid:    strings.Repeat("a1", 31) + "01",
+			Tweak:   tweakHex,
+			Outputs: []string{strings.Repeat("0a", 32), strings.Repeat("1a", 32)},
+		},
+		{Height: 11, Txid: strings.Repeat("b2", 32), Tweak: tweakHex, Outputs: []string{strings.Repeat("0b", 32)}},
+		{Height: 13, Txid: strings.Repeat("c3", 32), Tweak: tweakHex, Outputs: []string{strings.Repeat("0c", 32)}},
+	}
+	var got []Line
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var line Line
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, line)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		gotJSON, _ := json.Marshal(got[i])
+		wantJSON, _ := json.Marshal(want[i])
+		if !bytes.Equal(gotJSON, wantJSON) {
+			t.Errorf("line %d\ngot:  %s\nwant: %s", i, gotJSON, wantJSON)
+		}
+	}
+
+	hash, height, err := s.GetChainTip()
+	if err != nil || height != 13 || !bytes.Equal(hash, (&chainhash.Hash{13})[:]) {
+		t.Errorf("tip %x at %d: %v", hash, height, err)
+	}
+	// nothing is stored to read back
+	if _, err = s.GetBlockHashByHeight(10); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("read: got %v", err)
+	}
+	// scanning and wallets are optional interfaces the dump store leaves out
+	if _, err = query.New(s).Wallet([32]byte{}); !errors.Is(err, database.ErrFeatureDisabled) {
+		t.Errorf("wallet: got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package dbdump
+
+import (
+	"errors"
+
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// ErrUnsupported nothing is stored, so there is nothing to read back
+var ErrUnsupported = errors.New("not supported by the dump store")
+
+func (s *Store) GetBlockHashByHeight(uint32) ([]byte, error) { return nil, ErrUnsupported }
+
+func (s *Store) BlockHeightByHash([]byte) (uint32, error) { return 0, ErrUnsupported }
+
+func (s *Store) ResolveHeight(uint32) ([]byte, error) { return nil, ErrUnsupported }
+
+func (s *Store) TweaksForBlockAll([]byte) ([]*database.TweakRow, error) { return nil, ErrUnsupported }
+
+func (s *Store) TweaksForBlockCutThrough([]byte, uint32) ([]database.TweakRow, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Store) FetchOutputsAll([]byte, uint32) ([]*database.Output, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Store) FetchSpentOutputsShort([]byte) ([]byte, error) { return nil, ErrUnsupported }
+
+func (s *Store) ChainIterator(bool) (<-chan []byte, error) { return nil, ErrUnsupported }
+
+func (s *Store) ForEachHeight(uint32, uint32, func(uint32) error) error { return ErrUnsupported }
+
+func (s *Store) FetchComputeIndex(uint32) ([]*pb.ComputeIndexTxItem, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Store) FetchComputeIndexFiltered(uint32, uint32, uint64, bool) ([]*pb.ComputeIndexTxItem, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Store) BlockhashInDB([]byte) (bool, error) { return false, ErrUnsupported }
+
+func (s *Store) KeyExistsComputeIndex([]byte) (bool, error) { return false, ErrUnsupported }
+
+func (s *Store) FetchTxidOutpoints([]byte, []byte) ([][36]byte, error) { return nil, ErrUnsupported }
+
+func (s *Store) FetchAllTxidOutpointsForBlock([]byte) (map[[32]byte][][36]byte, error) {
+	return nil, ErrUnsupported
+}
@@ -3,7 +3,6 @@ package dbpebble
 import (
 	"bytes"
 	"context"
-	"crypto/rand"
 	"encoding/binary"
 	"errors"
 	"sync"
@@ -11,7 +10,7 @@ import (
 
 	"github.com/cockroachdb/pebble"
 	"github.com/setavenger/blindbit-lib/logging"
-	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/database"
 	"github.com/setavenger/go-bip352"
 )
 
@@ -25,23 +24,15 @@ import (
 // if the label is nil, add the output to the found outputs
 // return the found outputs
 
-var (
-	scanSecretKey [32]byte
-	spendPubKey   *[33]byte
-
-	ErrNoComputeIndex = errors.New("no compute index found")
-)
-
-func init() {
-	rand.Read(scanSecretKey[:])
-	spendPubKey = bip352.PubKeyFromSecKey(&scanSecretKey)
-}
+var ErrNoComputeIndex = errors.New("no compute index found")
 
+// DBComputeComputeIndex scans the compute index from startHeight to endHeight (both inclusive) for keys
 func (s *Store) DBComputeComputeIndex(
 	ctx context.Context,
+	keys *database.ScanKeys,
 	startHeight, endHeight uint32,
 ) (
-	foundOutputs []*FoundOutputShort, err error,
+	foundOutputs []*database.FoundOutputShort, err error,
 ) {
 	lb, ub := BoundsComputeIndex(startHeight, endHeight)
 	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
@@ -54,7 +45,7 @@ func (s *Store) DBComputeComputeIndex(
 	}
 	defer it.Close()
 	if !it.First() {
-		logging.L.Warn().
+		logging.L.Debug().
 			Uint32("startHeight", startHeight).
 			Uint32("endHeight", endHeight).
 			Msg("no compute index found")
@@ -85,9 +76,12 @@ func (s *Store) DBComputeComputeIndex(
 		for i := range countOutputs {
 			copy(shortOutputs[i][:], outputs[i*8:(i+1)*8])
 		}
-		var foundPerTx []*FoundOutputShort
+		var foundPerTx []*database.FoundOutputShort
+		// the shared secret is computed in place, tweak has to stay intact for the result
+		publicComponent := tweak
+		spendPubKey := keys.SpendPubKey
 		foundPerTx, err = ReceiverScanTransactionShortOutputs(
-			scanSecretKey, spendPubKey, nil, shortOutputs, &tweak, nil,
+			keys.ScanSecretKey, &spendPubKey, keys.Labels, shortOutputs, &publicComponent, nil,
 		)
 		if err != nil {
 			return nil, err
@@ -97,14 +91,14 @@ func (s *Store) DBComputeComputeIndex(
 			keyData = keyData[1:] // remove the prefix
 			height := binary.BigEndian.Uint32(keyData[:SizeHeight])
 			txid := keyData[SizeHeight:]
-			logging.L.Debug().Msgf(
-				"Found %d outputs at height %d and txid %x",
-				len(foundPerTx), height, utils.ReverseBytesCopy(txid[:]),
-			)
+			// no txid or height, the log must not tell which outputs belong to the keys
+			logging.L.Trace().Int("count", len(foundPerTx)).Msg("found outputs")
 
 			// Set the txid for each found output
 			for _, found := range foundPerTx {
 				copy(found.Txid[:], txid)
+				found.Height = height
+				found.Tweak = tweak
 			}
 			foundOutputs = append(foundOutputs, foundPerTx...)
 			// <-time.After(10 * time.Second)
@@ -154,7 +148,7 @@ func (pt *ProgressTracker) UpdateProgress(processed int64) {
 	logInterval := max(total/8, 1)
 	if current%logInterval == 0 {
 		percentage := float64(current) / float64(total) * 100
-		logging.L.Info().
+		logging.L.Debug().
 			Int64("processedRanges", current).
 			Int64("totalRanges", total).
 			Float64("percentage", percentage).
@@ -169,12 +163,16 @@ func (pt *ProgressTracker) GetProgress() (processed int64, total int64) {
 	return pt.totalProcessed, int64(pt.totalRanges)
 }
 
-// NewWorkStealingQueue creates a new work stealing queue with ranges of specified size
+// NewWorkStealingQueue creates a new work stealing queue with ranges of specified size.
+// Ranges are inclusive on both ends and don't overlap, so no height is scanned twice.
 func NewWorkStealingQueue(startHeight, endHeight uint32, rangeSize uint32) *WorkStealingQueue {
 	var ranges []WorkRange
 
-	for current := startHeight; current < endHeight; current += rangeSize {
-		rangeEnd := min(current+rangeSize, endHeight)
+	for current := startHeight; current <= endHeight; current += rangeSize {
+		rangeEnd := endHeight
+		if endHeight-current >= rangeSize {
+			rangeEnd = current + rangeSize - 1
+		}
 
 		lb, ub := BoundsComputeIndex(current, rangeEnd)
 		ranges = append(ranges, WorkRange{
@@ -183,6 +181,9 @@ func NewWorkStealingQueue(startHeight, endHeight uint32, rangeSize uint32) *Work
 			lowerBound:  lb,
 			upperBound:  ub,
 		})
+		if rangeEnd == endHeight {
+			break
+		}
 	}
 
 	return &WorkStealingQueue{
@@ -203,10 +204,11 @@ func (q *WorkStealingQueue) GetNextRange() *WorkRange {
 // DBComputeComputeIndexParallel processes compute indexes using work-stealing parallelization
 func (s *Store) DBComputeComputeIndexParallel(
 	ctx context.Context,
+	keys *database.ScanKeys,
 	startHeight, endHeight uint32,
 	numWorkers int,
 	rangeSize uint32, // Size of each range in block heights (e.g., 144)
-) ([]*FoundOutputShort, error) {
+) ([]*database.FoundOutputShort, error) {
 	if numWorkers <= 0 {
 		numWorkers = 1
 	}
@@ -220,14 +222,14 @@ func (s *Store) DBComputeComputeIndexParallel(
 	// Create progress tracker
 	progressTracker := NewProgressTracker(len(workQueue.ranges))
 
-	logging.L.Info().
+	logging.L.Debug().
 		Uint32("startHeight", startHeight).
 		Uint32("endHeight", endHeight).
 		Int("numWorkers", numWorkers).
 		Msg("Starting parallel processing")
 
 	// Create channels for results and errors
-	results := make(chan []*FoundOutputShort, numWorkers)
+	results := make(chan []*database.FoundOutputShort, numWorkers)
 	errorChan := make(chan error, numWorkers)
 
 	// Start workers
@@ -240,7 +242,7 @@ func (s *Store) DBComputeComputeIndexParallel(
 			workerCtx, cancel := context.WithCancel(ctx)
 			defer cancel()
 
-			var workerResults []*FoundOutputShort
+			var workerResults []*database.FoundOutputShort
 			processedRanges := 0
 
 			for {
@@ -260,7 +262,7 @@ func (s *Store) DBComputeComputeIndexParallel(
 
 				// Call the existing function with the range bounds
 				rangeResults, err := s.DBComputeComputeIndex(
-					workerCtx, workRange.startHeight, workRange.endHeight,
+					workerCtx, keys, workRange.startHeight, workRange.endHeight,
 				)
 				if err != nil {
 					logging.L.Err(err).
@@ -285,11 +287,16 @@ func (s *Store) DBComputeComputeIndexParallel(
 	// Wait for all workers to complete
 	wg.Wait()
 
+	// workers stop silently on cancellation, the results would be incomplete
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Close channels after all workers are done
 	close(results)
 
 	// Collect all results
-	var allFoundOutputs []*FoundOutputShort
+	var allFoundOutputs []*database.FoundOutputShort
 	resultsCollected := 0
 
 	for workerResults := range results {
@@ -305,7 +312,7 @@ func (s *Store) DBComputeComputeIndexParallel(
 	default:
 	}
 
-	logging.L.Info().
+	logging.L.Debug().
 		Int("totalFoundOutputs", len(allFoundOutputs)).
 		Msg("Processing completed")
 
@@ -314,28 +321,6 @@ func (s *Store) DBComputeComputeIndexParallel(
 	return allFoundOutputs, nil
 }
 
-// ------ below belongs to other packages ------
-
-type FoundOutputShort struct {
-	// Only first 8 bytes
-	Output      [8]byte
-	SecKeyTweak [32]byte
-	Label       *bip352.Label
-	Txid        [32]byte
-}
-
-func (f *FoundOutputShort) GetOutput() [8]byte {
-	return f.Output
-}
-
-func (f *FoundOutputShort) GetSecKeyTweak() [32]byte {
-	return f.SecKeyTweak
-}
-
-func (f *FoundOutputShort) GetLabel() *bip352.Label {
-	return f.Label
-}
-
 // ReceiverScanTransactionShortOutputs scans but with 8 byte outputs instead fo full outputs
 // scanKey: scanning secretKey of the receiver
 //
@@ -356,9 +341,9 @@ func ReceiverScanTransactionShortOutputs(
 	txOutputs [][8]byte, // 8 byte short outputs only first bytes
 	publicComponent *[33]byte,
 	inputHash *[32]byte,
-) ([]*FoundOutputShort, error) {
+) ([]*database.FoundOutputShort, error) {
 	// todo should probably check inputs before computation especially the labels
-	var foundOutputs []*FoundOutputShort
+	var foundOutputs []*database.FoundOutputShort
 
 	sharedSecret, err := bip352.CreateSharedSecret(publicComponent, &scanKey, inputHash)
 	if err != nil {
@@ -374,11 +359,21 @@ func ReceiverScanTransactionShortOutputs(
 			return nil, err
 		}
 
+		// the short outputs can't be negated or subtracted from,
+		// so the labelled candidates B_spend + t_k*G + label are built upfront
+		var labelledOutputs [][33]byte
+		if len(labels) > 0 {
+			labelledOutputs, err = labelledOutputPubKeys(receiverSpendPubKey, &tweak, labels)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		var found bool
 		for i := range txOutputs {
 			// only check the first 8 bytes of the txOutput and outputPubKey
 			if bytes.Equal(outputPubKey[:8], txOutputs[i][:]) {
-				foundOutputs = append(foundOutputs, &FoundOutputShort{
+				foundOutputs = append(foundOutputs, &database.FoundOutputShort{
 					Output:      txOutputs[i],
 					SecKeyTweak: tweak,
 					Label:       nil,
@@ -390,72 +385,27 @@ func ReceiverScanTransactionShortOutputs(
 				break // found the matching txOutput for outputPubKey, don't try the rest
 			}
 
-			if labels == nil {
+			foundLabel := MatchLabels(txOutputs[i], labelledOutputs, labels)
+			if foundLabel == nil {
 				continue
 			}
 
-			// now check the labels
-			var foundLabel *bip352.Label
-
-			// todo: benchmark against
-			// var prependedTxOutput [33]byte
-			// prependedTxOutput[0] = 0x02
-			// copy(prependedTxOutput[1:], txOutput[:])
-
-			prependedTxOutput := utils.ConvertToFixedLength33(append([]byte{0x02}, txOutputs[i][:]...))
-			prependedOutputPubKey := utils.ConvertToFixedLength33(append([]byte{0x02}, outputPubKey[:]...))
-
-			// start with normal output
-			foundLabel, err = MatchLabels(prependedTxOutput, prependedOutputPubKey, labels)
-			if err != nil {
-				return nil, err
-			}
-
 			// important: copy the tweak to avoid modifying the original tweak
 			var secKeyTweak [32]byte
 			copy(secKeyTweak[:], tweak[:])
-
-			if foundLabel != nil {
-				err = bip352.AddPrivateKeys(&secKeyTweak, &foundLabel.Tweak) // labels have a modified tweak
-				if err != nil {
-					return nil, err
-				}
-				foundOutputs = append(foundOutputs, &FoundOutputShort{
-					Output:      txOutputs[i],
-					SecKeyTweak: secKeyTweak,
-					Label:       foundLabel,
-				})
-				txOutputs = append(txOutputs[:i], txOutputs[i+1:]...)
-				found = true
-				k++
-				break
-			}
-
-			// try the negated output for the label
-			err = bip352.NegatePublicKey(&prependedTxOutput)
-			if err != nil {
-				return nil, err
-			}
-
-			foundLabel, err = MatchLabels(prependedTxOutput, prependedOutputPubKey, labels)
+			err = bip352.AddPrivateKeys(&secKeyTweak, &foundLabel.Tweak) // labels have a modified tweak
 			if err != nil {
 				return nil, err
 			}
-			if foundLabel != nil {
-				err = bip352.AddPrivateKeys(&secKeyTweak, &foundLabel.Tweak) // labels have a modified tweak
-				if err != nil {
-					return nil, err
-				}
-				foundOutputs = append(foundOutputs, &FoundOutputShort{
-					Output:      [8]byte(prependedTxOutput[1:9]), // 8 bytes
-					SecKeyTweak: secKeyTweak,
-					Label:       foundLabel,
-				})
-				txOutputs = append(txOutputs[:i], txOutputs[i+1:]...)
-				found = true
-				k++
-				break
-			}
+			foundOutputs = append(foundOutputs, &database.FoundOutputShort{
+				Output:      txOutputs[i],
+				SecKeyTweak: secKeyTweak,
+				Label:       foundLabel,
+			})
+			txOutputs = append(txOutputs[:i], txOutputs[i+1:]...)
+			found = true
+			k++
+			break
 		}
 
 		if !found {
@@ -465,27 +415,31 @@ func ReceiverScanTransactionShortOutputs(
 	return foundOutputs, nil
 }
 
-func MatchLabels(txOutput, pk [33]byte, labels []*bip352.Label) (*bip352.Label, error) {
-	var pkNeg [33]byte
-	copy(pkNeg[:], pk[:])
-	// subtraction is adding a negated value
-	err := bip352.NegatePublicKey(&pkNeg)
+// labelledOutputPubKeys returns the output pubkey for every label at the output tweak t_k, in the order of labels
+func labelledOutputPubKeys(spendPubKey *[33]byte, tweak *[32]byte, labels []*bip352.Label) ([][33]byte, error) {
+	outputPubKey, err := bip352.AddPublicKeys(bip352.PubKeyFromSecKey(tweak), spendPubKey)
 	if err != nil {
 		return nil, err
 	}
 
-	// todo: is this the best place to prepend to compressed
-	labelMatch, err := bip352.AddPublicKeys(&txOutput, &pkNeg)
-	if err != nil {
-		return nil, err
+	labelledOutputs := make([][33]byte, len(labels))
+	for i, label := range labels {
+		labelledOutputs[i], err = bip352.AddPublicKeys(&outputPubKey, &label.PubKey)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return labelledOutputs, nil
+}
 
-	for _, label := range labels {
+// MatchLabels returns the label whose output pubkey starts with txOutput, nil if none does.
+// Outputs are x-only, so the parity of the labelled output pubkeys doesn't matter.
+func MatchLabels(txOutput [8]byte, labelledOutputs [][33]byte, labels []*bip352.Label) *bip352.Label {
+	for i := range labelledOutputs {
 		// only check the first 8 bytes of actual pubkey
-		if bytes.Equal(labelMatch[1:8+1], label.PubKey[1:8+1]) {
-			return label, nil
+		if bytes.Equal(labelledOutputs[i][1:8+1], txOutput[:]) {
+			return labels[i]
 		}
 	}
-
-	return nil, nil
+	return nil
 }
@@ -1,13 +1,15 @@
 package dbpebble
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/cockroachdb/pebble"
 	"github.com/setavenger/blindbit-lib/logging"
-	"github.com/setavenger/blindbit-lib/proto/pb"
 	"github.com/setavenger/blindbit-lib/utils"
 	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
 )
 
 // should we encode the count of outputs?
@@ -82,23 +84,113 @@ func (s *Store) FetchComputeIndex(height uint32) ([]*pb.ComputeIndexTxItem, erro
 	return computeIndexes, nil
 }
 
-func (s *Store) BuildComputeIndexByRange(startHeight, endHeight uint32) error {
+// FetchComputeIndexFiltered returns the compute index of height with only the outputs
+// of at least dustLimit sats and, with cutThrough, not yet spent at tipHeight.
+// Txs without any remaining output are dropped.
+func (s *Store) FetchComputeIndexFiltered(
+	height, tipHeight uint32, dustLimit uint64, cutThrough bool,
+) ([]*pb.ComputeIndexTxItem, error) {
+	if dustLimit == 0 && !cutThrough {
+		return s.FetchComputeIndex(height)
+	}
+
+	timeStart := time.Now()
+	defer func() {
+		logging.L.Trace().
+			Dur("duration", time.Since(timeStart)).
+			Uint32("height", height).
+			Uint64("dust_limit", dustLimit).
+			Bool("cut_through", cutThrough).
+			Msg("fetching_compute_index_filtered_timing")
+	}()
+
+	lb, ub := BoundsComputeIndexOneHeight(height)
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var computeIndexes []*pb.ComputeIndexTxItem
+	for ok := it.First(); ok; ok = it.Next() {
+		key := it.Key()
+		txid := make([]byte, SizeTxid)
+		copy(txid, key[1+SizeHeight:])
+
+		outs, err := s.OutputsForTx(txid)
+		if err != nil {
+			return nil, err
+		}
+
+		var outputsShort []byte
+		for _, o := range outs {
+			if o.Amount < dustLimit {
+				continue
+			}
+			if cutThrough {
+				spent, err := s.spentAtHeightTip(txid, o.Vout, tipHeight)
+				if err != nil {
+					return nil, err
+				}
+				if spent {
+					continue
+				}
+			}
+			outputsShort = append(outputsShort, o.Pubkey[:8]...)
+		}
+		if len(outputsShort) == 0 {
+			continue
+		}
+
+		tweak := make([]byte, SizeTweak)
+		copy(tweak, it.Value()[:SizeTweak])
+		computeIndexes = append(computeIndexes, &pb.ComputeIndexTxItem{
+			Txid:         utils.ReverseBytes(txid),
+			Tweak:        tweak,
+			OutputsShort: outputsShort,
+		})
+	}
+	// a failed iteration would otherwise pass for a complete compute index
+	if err = it.Error(); err != nil {
+		return nil, err
+	}
+	return computeIndexes, nil
+}
+
+func (s *Store) BuildComputeIndexByRange(ctx context.Context, startHeight, endHeight uint32) error {
 	logging.L.Info().Msgf("Building static indexes from %d -> %d", startHeight, endHeight)
 
-	heightChan := make(chan uint32, 100)                             // Buffered channel for heights
-	errChan := make(chan error, config.MaxParallelTweakComputations) // buffer two avoid deadlocks
+	// without a worker nothing would be built and the range reported done
+	numWorkers := config.MaxParallelTweakComputations
+	if numWorkers <= 0 {
+		numWorkers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heightChan := make(chan uint32, 100)    // Buffered channel for heights
+	errChan := make(chan error, numWorkers) // buffer two avoid deadlocks
 	var wg sync.WaitGroup
 
 	// Send heights to channel
 	go func() {
 		defer close(heightChan)
 		for height := startHeight; height <= endHeight; height++ {
-			heightChan <- height
+			select {
+			case <-ctx.Done():
+				return
+			case heightChan <- height:
+			}
+			// height++ would wrap at math.MaxUint32
+			if height == endHeight {
+				break
+			}
 		}
 	}()
 
 	// Start worker goroutines
-	for i := 0; i < config.MaxParallelTweakComputations; i++ {
+	for range numWorkers {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -109,6 +201,7 @@ func (s *Store) BuildComputeIndexByRange(startHeight, endHeight uint32) error {
 						Uint32("height", height).
 						Msg("compute indexes failed")
 					errChan <- err
+					cancel()
 					return
 				}
 				err = s.FinishComputeIndex(height, blockComputeIndexes)
@@ -117,6 +210,7 @@ func (s *Store) BuildComputeIndexByRange(startHeight, endHeight uint32) error {
 						Uint32("height", height).
 						Msg("compute indexes failed")
 					errChan <- err
+					cancel()
 					return
 				}
 				if height%100 == 0 {
@@ -136,6 +230,9 @@ func (s *Store) BuildComputeIndexByRange(startHeight, endHeight uint32) error {
 	default:
 		// No errors
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	close(errChan)
 
@@ -0,0 +1,187 @@
+package dbpebble_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/cockroachdb/pebble"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+)
+
+func TestFetchComputeIndexFiltered(t *testing.T) {
+	tweak := [33]byte{0x02, 0x11}
+	out := func(txid []byte, vout uint32, amount uint64, key byte) *database.Output {
+		return &database.Output{Txid: txid, Vout: vout, Amount: amount, Pubkey: bytes.Repeat([]byte{key}, 32)}
+	}
+	spend := func(txid []byte, vout uint32, key byte) *database.In {
+		return &database.In{PrevTxid: txid, PrevVout: vout, Pubkey: bytes.Repeat([]byte{key}, 32)}
+	}
+
+	// a has a dust output and an output spent at height 3,
+	// b only a dust output and c only an output spent at height 2
+	a := bytes.Repeat([]byte{0xa1}, 32)
+	b := bytes.Repeat([]byte{0xb2}, 32)
+	c := bytes.Repeat([]byte{0xc3}, 32)
+	blocks := []*database.DBBlock{
+		{
+			Height: 1,
+			Hash:   &chainhash.Hash{0x01},
+			Txs: []*database.Tx{
+				{Txid: a, Tweak: &tweak, Outs: []*database.Output{
+					out(a, 0, 500, 0x0a), out(a, 1, 10_000, 0x1a), out(a, 2, 20_000, 0x2a),
+				}},
+				{Txid: b, Tweak: &tweak, Outs: []*database.Output{out(b, 0, 300, 0x0b)}},
+				{Txid: c, Tweak: &tweak, Outs: []*database.Output{out(c, 0, 5_000, 0x0c)}},
+			},
+		},
+		{
+			Height: 2,
+			Hash:   &chainhash.Hash{0x02},
+			Txs:    []*database.Tx{{Txid: bytes.Repeat([]byte{0xd4}, 32), Ins: []*database.In{spend(c, 0, 0x0c)}}},
+		},
+		{
+			Height: 3,
+			Hash:   &chainhash.Hash{0x03},
+			Txs:    []*database.Tx{{Txid: bytes.Repeat([]byte{0xe5}, 32), Ins: []*database.In{spend(a, 2, 0x2a)}}},
+		},
+	}
+	store := testhelpers.NewStore(t, blocks...)
+
+	short := func(keys ...byte) []byte {
+		var outputsShort []byte
+		for _, key := range keys {
+			outputsShort = append(outputsShort, bytes.Repeat([]byte{key}, 8)...)
+		}
+		return outputsShort
+	}
+
+	tests := []struct {
+		name       string
+		tipHeight  uint32
+		dustLimit  uint64
+		cutThrough bool
+		// want outputs short by txid, txs which are not listed are dropped
+		want map[byte][]byte
+	}{
+		{
+			name: "unfiltered",
+			want: map[byte][]byte{0xa1: short(0x0a, 0x1a, 0x2a), 0xb2: short(0x0b), 0xc3: short(0x0c)},
+		},
+		{
+			name:      "dust limit",
+			dustLimit: 1_000,
+			want:      map[byte][]byte{0xa1: short(0x1a, 0x2a), 0xc3: short(0x0c)},
+		},
+		{
+			name:      "output at the dust limit",
+			dustLimit: 500,
+			want:      map[byte][]byte{0xa1: short(0x0a, 0x1a, 0x2a), 0xc3: short(0x0c)},
+		},
+		{
+			name:       "spent above the tip",
+			tipHeight:  1,
+			cutThrough: true,
+			want:       map[byte][]byte{0xa1: short(0x0a, 0x1a, 0x2a), 0xb2: short(0x0b), 0xc3: short(0x0c)},
+		},
+		{
+			name:       "spent at the tip",
+			tipHeight:  2,
+			cutThrough: true,
+			want:       map[byte][]byte{0xa1: short(0x0a, 0x1a, 0x2a), 0xb2: short(0x0b)},
+		},
+		{
+			name:       "spent below the tip",
+			tipHeight:  3,
+			cutThrough: true,
+			want:       map[byte][]byte{0xa1: short(0x0a, 0x1a), 0xb2: short(0x0b)},
+		},
+		{
+			name:       "dust limit and cut-through",
+			tipHeight:  3,
+			dustLimit:  1_000,
+			cutThrough: true,
+			want:       map[byte][]byte{0xa1: short(0x1a)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := store.FetchComputeIndexFiltered(1, tt.tipHeight, tt.dustLimit, tt.cutThrough)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != len(tt.want) {
+				t.Fatalf("got %d txs, want %d", len(items), len(tt.want))
+			}
+			for _, item := range items {
+				// txids are returned in display order
+				want, ok := tt.want[item.Txid[len(item.Txid)-1]]
+				if !ok {
+					t.Errorf("tx %x not dropped", item.Txid)
+					continue
+				}
+				if !bytes.Equal(item.OutputsShort, want) {
+					t.Errorf("tx %x: outputs short %x, want %x", item.Txid, item.OutputsShort, want)
+				}
+				if !bytes.Equal(item.Tweak, tweak[:]) {
+					t.Errorf("tx %x: tweak %x", item.Txid, item.Tweak)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildComputeIndexByRange(t *testing.T) {
+	saved := config.MaxParallelTweakComputations
+	t.Cleanup(func() { config.MaxParallelTweakComputations = saved })
+
+	txid := bytes.Repeat([]byte{0xa1}, 32)
+	tweak := [33]byte{0x02, 0x11}
+	store := testhelpers.NewStore(t, &database.DBBlock{
+		Height: 1,
+		Hash:   &chainhash.Hash{0x01},
+		Txs: []*database.Tx{{
+			Txid:  txid,
+			Tweak: &tweak,
+			Outs:  []*database.Output{{Txid: txid, Vout: 0, Amount: 1_000, Pubkey: bytes.Repeat([]byte{0x0a}, 32)}},
+		}},
+	})
+
+	// a worker count of 0 still rebuilds every height
+	config.MaxParallelTweakComputations = 0
+	if err := store.DB.Delete(dbpebble.KeyComputeIndex(1, txid), pebble.Sync); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.BuildComputeIndexByRange(context.Background(), 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.FlushBatch(true); err != nil {
+		t.Fatal(err)
+	}
+	items, err := store.FetchComputeIndex(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || !bytes.Equal(items[0].Tweak, tweak[:]) {
+		t.Fatalf("compute index not rebuilt: %v", items)
+	}
+
+	// the range can end at the highest height without wrapping around
+	if err = store.BuildComputeIndexByRange(context.Background(), math.MaxUint32-1, math.MaxUint32); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = store.BuildComputeIndexByRange(ctx, 1, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v after cancel, want context.Canceled", err)
+	}
+}
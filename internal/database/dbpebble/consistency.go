@@ -0,0 +1,142 @@
+package dbpebble
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+// CheckBlockConsistency checks that the secondary indexes of the block at height
+// agree with each other. It only reads and never modifies the db.
+//
+// A tx whose tweak, outputs and compute index row are all gone leaves only its block → tx entry,
+// which is written for txs without a tweak as well. Such losses are found through the block digest,
+// which is compared if nothing else was found. Blocks indexed before digests existed can't be checked for them.
+func (s *Store) CheckBlockConsistency(height uint32) (*database.BlockConsistency, error) {
+	res := &database.BlockConsistency{Height: height}
+
+	blockhash, err := s.GetBlockHashByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if blockhash == nil {
+		res.MissingBlock = true
+		return res, nil
+	}
+
+	// ci:b has to point back to the same height
+	ciHeight, found, err := s.heightIfOnBestChain(blockhash)
+	if err != nil {
+		return nil, err
+	}
+	if !found || ciHeight != height {
+		res.MissingCIBlock = true
+	}
+
+	// spent outputs short is always written, even for blocks without spends
+	found, err = s.keyExists(KeySpentOutputsShort(blockhash))
+	if err != nil {
+		return nil, err
+	}
+	res.MissingSpentOutputsShort = !found
+
+	txids, err := s.BlockTxids(blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, txid := range txids {
+		_, hasTweak, err := s.LoadTweak(txid)
+		if err != nil {
+			return nil, err
+		}
+
+		hasComputeIndex, err := s.keyExists(KeyComputeIndex(height, txid))
+		if err != nil {
+			return nil, err
+		}
+
+		if hasTweak {
+			if !hasComputeIndex {
+				res.MissingComputeIndex = true
+			}
+			continue
+		}
+
+		// outputs and compute index are only written together with a tweak
+		if hasComputeIndex {
+			res.MissingTweak = true
+			continue
+		}
+		lb, ub := BoundsOut(txid)
+		hasOutputs, err := s.rangeNotEmpty(lb, ub)
+		if err != nil {
+			return nil, err
+		}
+		if hasOutputs {
+			res.MissingTweak = true
+		}
+	}
+
+	if res.OK() {
+		stored, ok, err := s.FetchBlockDigest(blockhash)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			computed, err := s.ComputeBlockDigest(height, blockhash)
+			if err != nil {
+				return nil, err
+			}
+			res.DigestMismatch = !bytes.Equal(stored, computed[:])
+		}
+	}
+
+	return res, nil
+}
+
+// RepairCIBlock rewrites the blockhash -> height entry of the block at height
+// from its height -> blockhash entry, which is the one the chain index is read from.
+func (s *Store) RepairCIBlock(height uint32) error {
+	blockhash, err := s.GetBlockHashByHeight(height)
+	if err != nil {
+		return err
+	}
+	if blockhash == nil {
+		return database.ErrNotFound
+	}
+
+	hb := make([]byte, SizeHeight)
+	be32(height, hb)
+	if err = s.DB.Set(KeyCIBlock(blockhash), hb, pebble.Sync); err != nil {
+		logging.L.Err(err).Uint32("height", height).Msg("failed to repair chain index")
+		return err
+	}
+	return nil
+}
+
+func (s *Store) keyExists(key []byte) (bool, error) {
+	_, closer, err := s.DB.Get(key)
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	closer.Close()
+	return true, nil
+}
+
+func (s *Store) rangeNotEmpty(lb, ub []byte) (bool, error) {
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return false, err
+	}
+	defer it.Close()
+
+	found := it.First()
+	return found, it.Error()
+}
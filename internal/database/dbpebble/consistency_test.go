@@ -0,0 +1,139 @@
+package dbpebble_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/cockroachdb/pebble"
+
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+)
+
+func TestCheckBlockConsistency(t *testing.T) {
+	txid := bytes.Repeat([]byte{0xa1}, 32)
+	tweak := [33]byte{0x02, 0x11}
+	block := &database.DBBlock{
+		Height: 1,
+		Hash:   &chainhash.Hash{0x01},
+		Txs: []*database.Tx{{
+			Txid:  txid,
+			Tweak: &tweak,
+			Outs:  []*database.Output{{Txid: txid, Vout: 0, Amount: 1_000, Pubkey: bytes.Repeat([]byte{0x0a}, 32)}},
+		}},
+	}
+	blockhash := block.Hash[:]
+
+	tests := []struct {
+		name       string
+		height     uint32
+		deleteKeys [][]byte
+		want       database.BlockConsistency
+		needRepull bool
+	}{
+		{name: "consistent", height: 1, want: database.BlockConsistency{Height: 1}},
+		{name: "missing block", height: 2, want: database.BlockConsistency{Height: 2, MissingBlock: true}, needRepull: true},
+		{
+			name:       "missing ci block",
+			height:     1,
+			deleteKeys: [][]byte{dbpebble.KeyCIBlock(blockhash)},
+			want:       database.BlockConsistency{Height: 1, MissingCIBlock: true},
+		},
+		{
+			name:       "missing compute index",
+			height:     1,
+			deleteKeys: [][]byte{dbpebble.KeyComputeIndex(1, txid)},
+			want:       database.BlockConsistency{Height: 1, MissingComputeIndex: true},
+		},
+		{
+			name:       "missing tweak",
+			height:     1,
+			deleteKeys: [][]byte{dbpebble.KeyTx(txid)},
+			want:       database.BlockConsistency{Height: 1, MissingTweak: true},
+			needRepull: true,
+		},
+		{
+			name:       "missing spent outputs short",
+			height:     1,
+			deleteKeys: [][]byte{dbpebble.KeySpentOutputsShort(blockhash)},
+			want:       database.BlockConsistency{Height: 1, MissingSpentOutputsShort: true},
+			needRepull: true,
+		},
+		{
+			name:       "missing tx",
+			height:     1,
+			deleteKeys: [][]byte{dbpebble.KeyTx(txid), dbpebble.KeyOut(txid, 0), dbpebble.KeyComputeIndex(1, txid)},
+			want:       database.BlockConsistency{Height: 1, DigestMismatch: true},
+			needRepull: true,
+		},
+		{
+			// without a digest only the block → tx entry is left to notice, which every tx has
+			name:       "missing tx in an undigested block",
+			height:     1,
+			deleteKeys: [][]byte{dbpebble.KeyBlockDigest(blockhash), dbpebble.KeyTx(txid), dbpebble.KeyOut(txid, 0), dbpebble.KeyComputeIndex(1, txid)},
+			want:       database.BlockConsistency{Height: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := testhelpers.NewStore(t, block)
+			for _, key := range tt.deleteKeys {
+				if err := store.DB.Delete(key, pebble.Sync); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := store.CheckBlockConsistency(tt.height)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Fatalf("got %+v, want %+v", *got, tt.want)
+			}
+			if got.OK() != (tt.want == database.BlockConsistency{Height: tt.height}) || got.NeedsRepull() != tt.needRepull {
+				t.Errorf("ok %v, needs repull %v", got.OK(), got.NeedsRepull())
+			}
+		})
+	}
+}
+
+// a missing hash -> height entry is restored without pulling the block again
+func TestRepairCIBlock(t *testing.T) {
+	block := &database.DBBlock{Height: 7, Hash: &chainhash.Hash{0x07}}
+	store := testhelpers.NewStore(t, block)
+	err := store.DB.Delete(dbpebble.KeyCIBlock(block.Hash[:]), pebble.Sync)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.RepairCIBlock(block.Height); err != nil {
+		t.Fatal(err)
+	}
+	res, err := store.CheckBlockConsistency(block.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK() {
+		t.Fatalf("not repaired: %+v", res)
+	}
+	// the rewritten entry is the one the block was indexed with
+	stored, _, err := store.FetchBlockDigest(block.Hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	computed, err := store.ComputeBlockDigest(block.Height, block.Hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, computed[:]) {
+		t.Errorf("digest %x, recomputed %x", stored, computed)
+	}
+
+	if err = store.RepairCIBlock(8); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("repair of a missing block: %v", err)
+	}
+}
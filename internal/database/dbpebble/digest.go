@@ -0,0 +1,339 @@
+package dbpebble
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/setavenger/blindbit-lib/logging"
+)
+
+// blockDigest collects the key/value pairs written for one block.
+// The sum is order independent: pairs are sorted by key before hashing,
+// so the digest can be recomputed later by reading the keys back from the db.
+type blockDigest struct {
+	entries []digestEntry
+}
+
+type digestEntry struct {
+	key   []byte
+	value []byte
+}
+
+func (d *blockDigest) add(key, value []byte) {
+	d.entries = append(d.entries, digestEntry{key: key, value: value})
+}
+
+// sum hashes [len(key):4][key][len(value):4][value] for every pair in key order.
+// If a key was added twice only the last value counts, same as in pebble.
+func (d *blockDigest) sum() [SizeDigest]byte {
+	sort.SliceStable(d.entries, func(i, j int) bool {
+		return bytes.Compare(d.entries[i].key, d.entries[j].key) < 0
+	})
+
+	h := sha256.New()
+	var lenBuf [4]byte
+	for i, e := range d.entries {
+		if i+1 < len(d.entries) && bytes.Equal(e.key, d.entries[i+1].key) {
+			continue
+		}
+		be32(uint32(len(e.key)), lenBuf[:])
+		h.Write(lenBuf[:])
+		h.Write(e.key)
+		be32(uint32(len(e.value)), lenBuf[:])
+		h.Write(lenBuf[:])
+		h.Write(e.value)
+	}
+
+	var out [SizeDigest]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// addKey reads key from the db and adds it to the digest if it exists
+func (s *Store) addKey(d *blockDigest, key []byte) ([]byte, bool, error) {
+	val, closer, err := s.DB.Get(key)
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer closer.Close()
+
+	value := make([]byte, len(val))
+	copy(value, val)
+	d.add(key, value)
+	return value, true, nil
+}
+
+// addRange adds all pairs within [lb, ub) to the digest
+func (s *Store) addRange(d *blockDigest, lb, ub []byte) error {
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for ok := it.First(); ok; ok = it.Next() {
+		d.add(slices.Clone(it.Key()), slices.Clone(it.Value()))
+	}
+	return it.Error()
+}
+
+// ComputeBlockDigest recomputes the digest for a block from what is currently in the db.
+// It walks the same keys attachBlockToBatch writes for a block.
+func (s *Store) ComputeBlockDigest(height uint32, blockhash []byte) ([SizeDigest]byte, error) {
+	d := new(blockDigest)
+
+	// chain index
+	if _, _, err := s.addKey(d, KeyCIHeight(height)); err != nil {
+		return [SizeDigest]byte{}, err
+	}
+	if _, _, err := s.addKey(d, KeyCIBlock(blockhash)); err != nil {
+		return [SizeDigest]byte{}, err
+	}
+
+	// block → txs + transaction records
+	lb, ub := BoundsBlockTx(blockhash)
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return [SizeDigest]byte{}, err
+	}
+	var txids [][]byte
+	for ok := it.First(); ok; ok = it.Next() {
+		txid := slices.Clone(it.Value())
+		d.add(slices.Clone(it.Key()), txid)
+		txids = append(txids, txid)
+	}
+	if err = it.Error(); err != nil {
+		it.Close()
+		return [SizeDigest]byte{}, err
+	}
+	it.Close()
+
+	for _, txid := range txids {
+		if _, _, err = s.addKey(d, KeyTxOccur(txid, blockhash)); err != nil {
+			return [SizeDigest]byte{}, err
+		}
+
+		// outputs and compute index are only written for txs with a tweak
+		_, hasTweak, err := s.addKey(d, KeyTx(txid))
+		if err != nil {
+			return [SizeDigest]byte{}, err
+		}
+		if !hasTweak {
+			continue
+		}
+		lb, ub := BoundsOut(txid)
+		if err = s.addRange(d, lb, ub); err != nil {
+			return [SizeDigest]byte{}, err
+		}
+		if _, _, err = s.addKey(d, KeyComputeIndex(height, txid)); err != nil {
+			return [SizeDigest]byte{}, err
+		}
+	}
+
+	// spend events are found through the txid → outpoints mapping
+	outpointsMap, err := s.FetchAllTxidOutpointsForBlock(blockhash)
+	if err != nil {
+		return [SizeDigest]byte{}, err
+	}
+	for txid, outpoints := range outpointsMap {
+		val, err := ValTxidOutpoints(outpoints)
+		if err != nil {
+			return [SizeDigest]byte{}, err
+		}
+		d.add(KeyTxidOutpoints(blockhash, txid[:]), val)
+
+		for i := range outpoints {
+			prevTxid := outpoints[i][:SizeTxid]
+			prevVout := binary.BigEndian.Uint32(outpoints[i][SizeTxid:])
+			if _, _, err = s.addKey(d, KeySpend(prevTxid, prevVout, blockhash)); err != nil {
+				return [SizeDigest]byte{}, err
+			}
+		}
+	}
+
+	if _, _, err = s.addKey(d, KeySpentOutputsShort(blockhash)); err != nil {
+		return [SizeDigest]byte{}, err
+	}
+
+	return d.sum(), nil
+}
+
+// FetchBlockDigest returns the digest stored when the block was written.
+// ok is false for blocks which were indexed before digests existed.
+func (s *Store) FetchBlockDigest(blockhash []byte) (digest []byte, ok bool, err error) {
+	val, closer, err := s.DB.Get(KeyBlockDigest(blockhash))
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer closer.Close()
+
+	return slices.Clone(val), true, nil
+}
+
+// DigestReport is the result of VerifyBlockDigests
+type DigestReport struct {
+	Checked int
+	// Mismatched heights where the stored digest differs from the recomputed one
+	Mismatched []uint32
+	// Missing heights without a block in the chain index
+	Missing []uint32
+	// Undigested heights indexed before digests were stored, these can't be verified
+	Undigested []uint32
+}
+
+// BadHeights returns all heights which should be re-indexed in ascending order
+func (r *DigestReport) BadHeights() []uint32 {
+	bad := make([]uint32, 0, len(r.Mismatched)+len(r.Missing))
+	bad = append(bad, r.Mismatched...)
+	bad = append(bad, r.Missing...)
+	slices.Sort(bad)
+	return bad
+}
+
+// VerifyBlockDigests recomputes the digest of every block from startHeight to endHeight (both inclusive)
+// and compares it against the stored digest. Heights are checked by numWorkers in parallel.
+func (s *Store) VerifyBlockDigests(
+	ctx context.Context,
+	startHeight, endHeight uint32,
+	numWorkers int,
+) (*DigestReport, error) {
+	if numWorkers <= 0 {
+		numWorkers = 1
+	}
+
+	logging.L.Info().
+		Uint32("start_height", startHeight).
+		Uint32("end_height", endHeight).
+		Int("num_workers", numWorkers).
+		Msg("verifying block digests")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heightChan := make(chan uint32, 100)
+	errChan := make(chan error, numWorkers)
+
+	report := new(DigestReport)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	go func() {
+		defer close(heightChan)
+		for height := startHeight; height <= endHeight; height++ {
+			select {
+			case <-ctx.Done():
+				return
+			case heightChan <- height:
+			}
+			// height++ would wrap at math.MaxUint32
+			if height == endHeight {
+				break
+			}
+		}
+	}()
+
+	for range numWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heightChan {
+				result, err := s.verifyHeight(height)
+				if err != nil {
+					logging.L.Err(err).Uint32("height", height).Msg("failed to verify block digest")
+					errChan <- err
+					cancel()
+					return
+				}
+
+				mu.Lock()
+				report.Checked++
+				switch result {
+				case digestMismatch:
+					report.Mismatched = append(report.Mismatched, height)
+				case digestMissingBlock:
+					report.Missing = append(report.Missing, height)
+				case digestNotStored:
+					report.Undigested = append(report.Undigested, height)
+				}
+				checked := report.Checked
+				mu.Unlock()
+
+				if checked%10_000 == 0 {
+					logging.L.Info().Int("checked", checked).Msg("verifying block digests")
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(report.Mismatched)
+	slices.Sort(report.Missing)
+	slices.Sort(report.Undigested)
+
+	return report, nil
+}
+
+type digestResult int
+
+const (
+	digestOK digestResult = iota
+	digestMismatch
+	digestMissingBlock
+	digestNotStored
+)
+
+func (s *Store) verifyHeight(height uint32) (digestResult, error) {
+	blockhash, err := s.GetBlockHashByHeight(height)
+	if err != nil {
+		return digestOK, err
+	}
+	if blockhash == nil {
+		return digestMissingBlock, nil
+	}
+
+	stored, ok, err := s.FetchBlockDigest(blockhash)
+	if err != nil {
+		return digestOK, err
+	}
+	if !ok {
+		return digestNotStored, nil
+	}
+
+	computed, err := s.ComputeBlockDigest(height, blockhash)
+	if err != nil {
+		return digestOK, err
+	}
+	if !bytes.Equal(stored, computed[:]) {
+		logging.L.Warn().
+			Uint32("height", height).
+			Hex("stored", stored).
+			Hex("computed", computed[:]).
+			Msg("block digest mismatch")
+		return digestMismatch, nil
+	}
+
+	return digestOK, nil
+}
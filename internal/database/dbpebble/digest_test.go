@@ -0,0 +1,118 @@
+package dbpebble_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/cockroachdb/pebble"
+
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+)
+
+func TestBlockDigests(t *testing.T) {
+	txA := bytes.Repeat([]byte{0xa1}, 32)
+	txB := bytes.Repeat([]byte{0xb2}, 32)
+	tweak := [33]byte{0x02, 0x11}
+	blocks := []*database.DBBlock{
+		{
+			Height: 1,
+			Hash:   &chainhash.Hash{0x01},
+			Txs: []*database.Tx{{
+				Txid:  txA,
+				Tweak: &tweak,
+				Outs: []*database.Output{
+					{Txid: txA, Vout: 0, Amount: 1_000, Pubkey: bytes.Repeat([]byte{0x0a}, 32)},
+					{Txid: txA, Vout: 1, Amount: 2_000, Pubkey: bytes.Repeat([]byte{0x1a}, 32)},
+				},
+			}},
+		},
+		{
+			Height: 2,
+			Hash:   &chainhash.Hash{0x02},
+			Txs: []*database.Tx{{
+				Txid:  txB,
+				Tweak: &tweak,
+				Ins: []*database.In{{
+					SpendTxid: txB, PrevTxid: txA, PrevVout: 0, Pubkey: bytes.Repeat([]byte{0x0a}, 32),
+				}},
+				Outs: []*database.Output{{Txid: txB, Vout: 0, Amount: 900, Pubkey: bytes.Repeat([]byte{0x0b}, 32)}},
+			}},
+		},
+	}
+
+	store := testhelpers.NewStore(t, blocks...)
+	for _, block := range blocks {
+		stored, ok, err := store.FetchBlockDigest(block.Hash[:])
+		if err != nil || !ok {
+			t.Fatalf("height %d: no digest stored: %v", block.Height, err)
+		}
+		computed, err := store.ComputeBlockDigest(block.Height, block.Hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(stored, computed[:]) {
+			t.Errorf("height %d: stored %x, recomputed %x", block.Height, stored, computed)
+		}
+	}
+	report, err := store.VerifyBlockDigests(context.Background(), 1, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 3 || len(report.Mismatched) != 0 || len(report.Undigested) != 0 ||
+		!slices.Equal(report.Missing, []uint32{3}) {
+		t.Fatalf("intact store: %+v", report)
+	}
+	// the range can end at the highest height without wrapping around
+	report, err = store.VerifyBlockDigests(context.Background(), math.MaxUint32-1, math.MaxUint32, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 2 || !slices.Equal(report.Missing, []uint32{math.MaxUint32 - 1, math.MaxUint32}) {
+		t.Fatalf("range up to math.MaxUint32: %+v", report)
+	}
+
+	tests := []struct {
+		name   string
+		key    []byte
+		value  []byte // nil deletes the key
+		height uint32
+	}{
+		{name: "deleted tx", key: dbpebble.KeyTx(txA), height: 1},
+		{name: "corrupted output", key: dbpebble.KeyOut(txA, 1), value: []byte{0xff}, height: 1},
+		{name: "deleted spend", key: dbpebble.KeySpend(txA, 0, blocks[1].Hash[:]), height: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := testhelpers.NewStore(t, blocks...)
+			if _, closer, err := store.DB.Get(tt.key); err != nil {
+				t.Fatalf("key not written: %v", err)
+			} else {
+				closer.Close()
+			}
+
+			var err error
+			if tt.value == nil {
+				err = store.DB.Delete(tt.key, pebble.Sync)
+			} else {
+				err = store.DB.Set(tt.key, tt.value, pebble.Sync)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := store.VerifyBlockDigests(context.Background(), 1, 2, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(report.Mismatched, []uint32{tt.height}) || !slices.Equal(report.BadHeights(), []uint32{tt.height}) {
+				t.Errorf("got %+v, want height %d mismatched", report, tt.height)
+			}
+		})
+	}
+}
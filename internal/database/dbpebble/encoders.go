@@ -117,6 +117,14 @@ func BoundsCIHeight() (lb, ub []byte) {
 	return
 }
 
+// BoundsCIHeightRange creates bounds for chain index heights from startHeight to endHeight (both inclusive)
+func BoundsCIHeightRange(startHeight, endHeight uint32) (lb, ub []byte) {
+	lb = KeyCIHeight(startHeight)
+	// appending a byte gives the smallest key after endHeight without overflowing the height
+	ub = append(KeyCIHeight(endHeight), 0x00)
+	return
+}
+
 func KeyCIBlock(blockHash []byte) []byte {
 	k := make([]byte, 1+SizeHash)
 	k[0] = KCIBlock
@@ -131,6 +139,13 @@ func KeySpentOutputsShort(blockhash []byte) []byte {
 	return k
 }
 
+func KeyBlockDigest(blockhash []byte) []byte {
+	k := make([]byte, 1+SizeHash)
+	k[0] = KBlockDigest
+	copy(k[1:], blockhash)
+	return k
+}
+
 // ---------------- Txid Outpoints Mapping ----------------
 
 func KeyTxidOutpoints(blockhash, txid []byte) []byte {
@@ -191,6 +206,46 @@ func BoundsComputeIndexOneHeight(height uint32) (lb, ub []byte) {
 	return lb, ub
 }
 
+// ---------------- Wallets ----------------
+
+func KeyWallet(id [32]byte) []byte {
+	k := make([]byte, 1+SizeHash)
+	k[0] = KWallet
+	copy(k[1:], id[:])
+	return k
+}
+
+func BoundsWallets() (lb, ub []byte) {
+	return []byte{KWallet}, []byte{KWallet + 1}
+}
+
+func KeyWalletOut(id [32]byte, height uint32, txid []byte, vout uint32) []byte {
+	k := make([]byte, 1+SizeHash+SizeHeight+SizeTxid+SizeVout)
+	k[0] = KWalletOut
+	copy(k[1:1+SizeHash], id[:])
+	be32(height, k[1+SizeHash:1+SizeHash+SizeHeight])
+	copy(k[1+SizeHash+SizeHeight:1+SizeHash+SizeHeight+SizeTxid], txid)
+	be32(vout, k[1+SizeHash+SizeHeight+SizeTxid:])
+	return k
+}
+
+// BoundsWalletOut covers the outputs of a wallet from fromHeight on
+func BoundsWalletOut(id [32]byte, fromHeight uint32) (lb, ub []byte) {
+	lb = make([]byte, 1+SizeHash+SizeHeight)
+	lb[0] = KWalletOut
+	copy(lb[1:1+SizeHash], id[:])
+	be32(fromHeight, lb[1+SizeHash:])
+
+	ub = make([]byte, 1+SizeHash+SizeHeight+SizeTxid+SizeVout)
+	ub[0] = KWalletOut
+	copy(ub[1:1+SizeHash], id[:])
+	// height+txid+vout = 0xFF... (exclusive upper bound; practically safe)
+	for i := 1 + SizeHash; i < len(ub); i++ {
+		ub[i] = 0xFF
+	}
+	return
+}
+
 // ---------------- Values ----------------
 
 func ValTxTweak(tweak []byte) ([]byte, error) {
@@ -0,0 +1,89 @@
+package dbpebble
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// storeCollector exposes pebble's internal metrics and the pending commits of a Store.
+// pebble.DB.Metrics() is only called on scrape.
+type storeCollector struct {
+	store *Store
+
+	pendingCommits  *prometheus.Desc
+	diskUsage       *prometheus.Desc
+	readAmp         *prometheus.Desc
+	compactions     *prometheus.Desc
+	compactionDebt  *prometheus.Desc
+	memtableSize    *prometheus.Desc
+	walSize         *prometheus.Desc
+	levelFiles      *prometheus.Desc
+	blockCacheHits  *prometheus.Desc
+	blockCacheMiss  *prometheus.Desc
+	blockCacheBytes *prometheus.Desc
+}
+
+// Collector returns a prometheus collector for the store, register it once per process
+func (s *Store) Collector() prometheus.Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc("blindbit_oracle_store_"+name, help, labels, nil)
+	}
+	return &storeCollector{
+		store:           s,
+		pendingCommits:  desc("pending_commits", "Batches committed in the background which did not finish yet."),
+		diskUsage:       desc("pebble_disk_usage_bytes", "Total disk space used by pebble."),
+		readAmp:         desc("pebble_read_amplification", "Current read amplification."),
+		compactions:     desc("pebble_compactions_total", "Number of compactions."),
+		compactionDebt:  desc("pebble_compaction_debt_bytes", "Estimated bytes to compact for the LSM to reach a stable state."),
+		memtableSize:    desc("pebble_memtable_size_bytes", "Size of the memtables."),
+		walSize:         desc("pebble_wal_size_bytes", "Size of the live WAL data."),
+		levelFiles:      desc("pebble_level_files", "Number of sstables per level.", "level"),
+		blockCacheHits:  desc("pebble_block_cache_hits_total", "Block cache hits."),
+		blockCacheMiss:  desc("pebble_block_cache_misses_total", "Block cache misses."),
+		blockCacheBytes: desc("pebble_block_cache_size_bytes", "Bytes in the block cache."),
+	}
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pendingCommits
+	ch <- c.diskUsage
+	ch <- c.readAmp
+	ch <- c.compactions
+	ch <- c.compactionDebt
+	ch <- c.memtableSize
+	ch <- c.walSize
+	ch <- c.levelFiles
+	ch <- c.blockCacheHits
+	ch <- c.blockCacheMiss
+	ch <- c.blockCacheBytes
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labels...)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	gauge(c.pendingCommits, float64(atomic.LoadInt64(&c.store.pendingCommits)))
+
+	if atomic.LoadInt32(&c.store.closed) == 1 {
+		return
+	}
+	m := c.store.DB.Metrics()
+	gauge(c.diskUsage, float64(m.DiskSpaceUsage()))
+	gauge(c.readAmp, float64(m.ReadAmp()))
+	counter(c.compactions, float64(m.Compact.Count))
+	gauge(c.compactionDebt, float64(m.Compact.EstimatedDebt))
+	gauge(c.memtableSize, float64(m.MemTable.Size))
+	gauge(c.walSize, float64(m.WAL.Size))
+	for level := range m.Levels {
+		gauge(c.levelFiles, float64(m.Levels[level].NumFiles), strconv.Itoa(level))
+	}
+	counter(c.blockCacheHits, float64(m.BlockCache.Hits))
+	counter(c.blockCacheMiss, float64(m.BlockCache.Misses))
+	gauge(c.blockCacheBytes, float64(m.BlockCache.Size))
+}
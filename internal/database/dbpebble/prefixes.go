@@ -9,6 +9,7 @@ const (
 	SizeTweak  = 33
 	SizeAmt    = 8
 	SizePubKey = 32 // x-only pubkey
+	SizeDigest = 32 // sha256 over a block's key/value pairs
 )
 
 // Prefix Keys "K"
@@ -32,4 +33,14 @@ const (
 
 	// Txid to Outpoints mapping (blockhash+txid -> concatenated outpoints)
 	KTxidOutpoints = 0x0F // blockhash+txid -> outpoints
+
+	/* Integrity */
+
+	// Digest over every key/value pair written for a block
+	KBlockDigest = 0x10 // blockhash -> digest
+
+	/* Watch-only wallets, not part of the block digests */
+
+	KWallet    = 0x11 // wallet_id -> registration and scan progress
+	KWalletOut = 0x12 // wallet_id+height+txid+vout -> found output
 )
@@ -1,6 +1,7 @@
 package dbpebble
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -125,6 +126,25 @@ func (s *Store) ChainIterator(asc bool) (<-chan []byte, error) {
 	return blockhashChan, nil
 }
 
+// ForEachHeight calls fn for every indexed height from startHeight to endHeight (both inclusive)
+// in ascending order with a single scan over the chain index
+func (s *Store) ForEachHeight(startHeight, endHeight uint32, fn func(height uint32) error) error {
+	lb, ub := BoundsCIHeightRange(startHeight, endHeight)
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for ok := it.First(); ok; ok = it.Next() {
+		height := binary.BigEndian.Uint32(it.Key()[1:])
+		if err = fn(height); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
 func (s *Store) BlockTxids(blockHash []byte) ([][]byte, error) {
 	lb, ub := BoundsBlockTx(blockHash)
 	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
@@ -274,6 +294,61 @@ func (s *Store) BlockhashInDB(blockhash []byte) (bool, error) {
 	return true, nil
 }
 
+// BlockHeightByHash resolves a blockhash through the chain index.
+// Returns database.ErrNotFound if the hash was never indexed and
+// database.ErrNotOnBestChain together with its former height if it was replaced by a reorg.
+func (s *Store) BlockHeightByHash(blockhash []byte) (uint32, error) {
+	height, found, err := s.heightIfOnBestChain(blockhash)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("block %x: %w", utils.ReverseBytesCopy(blockhash), database.ErrNotFound)
+	}
+
+	// ci:b entries of replaced blocks stay around, ci:h is authoritative
+	bestHash, err := s.GetBlockHashByHeight(height)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(bestHash, blockhash) {
+		return height, fmt.Errorf(
+			"block %x at height %d: %w",
+			utils.ReverseBytesCopy(blockhash), height, database.ErrNotOnBestChain,
+		)
+	}
+
+	return height, nil
+}
+
+// ResolveHeight returns the blockhash at height like GetBlockHashByHeight
+// but classifies a missing height: database.ErrNotYetSynced above the tip,
+// database.ErrPruned below the first indexed block and database.ErrNotFound for gaps.
+func (s *Store) ResolveHeight(height uint32) ([]byte, error) {
+	blockhash, err := s.GetBlockHashByHeight(height)
+	if err != nil || blockhash != nil {
+		return blockhash, err
+	}
+
+	_, tipHeight, err := s.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
+	if height > tipHeight {
+		return nil, fmt.Errorf("height %d above tip %d: %w", height, tipHeight, database.ErrNotYetSynced)
+	}
+
+	_, firstHeight, err := s.FirstBlock()
+	if err != nil {
+		return nil, err
+	}
+	if height < firstHeight {
+		return nil, fmt.Errorf("height %d below first block %d: %w", height, firstHeight, database.ErrPruned)
+	}
+
+	return nil, fmt.Errorf("height %d: %w", height, database.ErrNotFound)
+}
+
 // --- new internal helpers ---------------------------------------------------
 
 // heightIfOnBestChain returns (height,true) if blockHash is on best chain; otherwise (0,false).
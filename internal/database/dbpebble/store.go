@@ -1,16 +1,24 @@
 package dbpebble
 
 import (
+	"bytes"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/cockroachdb/pebble"
 	"github.com/setavenger/blindbit-lib/logging"
 	"github.com/setavenger/blindbit-lib/utils"
 	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/metrics"
 )
 
-var _ database.DB = (*Store)(nil)
+var (
+	_ database.DB             = (*Store)(nil)
+	_ database.IntegrityStore = (*Store)(nil)
+	_ database.ScanStore      = (*Store)(nil)
+	_ database.WalletStore    = (*Store)(nil)
+)
 
 type Store struct {
 	DB           *pebble.DB
@@ -25,6 +33,8 @@ type Store struct {
 	pendingCommits int64          // atomic counter for pending background commits
 	closed         int32          // atomic flag to indicate if store is closed
 	closeWaitGroup sync.WaitGroup // wait group for pending commits
+
+	walletSync *sync.Mutex
 }
 
 func NewStore(db *pebble.DB) *Store {
@@ -35,6 +45,7 @@ func NewStore(db *pebble.DB) *Store {
 		maxPendingCommits: 10,
 		batchSync:         new(sync.Mutex),
 		batchSize:         200,
+		walletSync:        new(sync.Mutex),
 	}
 }
 
@@ -84,9 +95,32 @@ func (s *Store) collectAndWrite(block *database.DBBlock) error {
 func (s *Store) attachBlockToBatch(block *database.DBBlock) error {
 	s.batchSync.Lock()
 	defer s.batchSync.Unlock()
+
+	if err := s.dropReplacedComputeIndex(block); err != nil {
+		return err
+	}
 	return attachBlockToBatch(s.dbBatch, block)
 }
 
+// dropReplacedComputeIndex deletes the compute index rows at the height of block if a different block is stored there.
+// The rows are keyed by height, the ones of a replaced block would otherwise be served with the new block.
+func (s *Store) dropReplacedComputeIndex(block *database.DBBlock) error {
+	replacedHash, err := s.GetBlockHashByHeight(block.Height)
+	if err != nil {
+		logging.L.Err(err).Uint32("height", block.Height).Msg("failed to read block hash")
+		return err
+	}
+	if replacedHash == nil || bytes.Equal(replacedHash, block.Hash[:]) {
+		return nil
+	}
+	lb, ub := BoundsComputeIndexOneHeight(block.Height)
+	if err = s.dbBatch.DeleteRange(lb, ub, nil); err != nil {
+		logging.L.Err(err).Uint32("height", block.Height).Msg("failed to drop compute index of replaced block")
+		return err
+	}
+	return nil
+}
+
 func (s *Store) FlushBatch(sync bool) error {
 	s.batchSync.Lock()
 	counter := s.batchCounter
@@ -130,7 +164,9 @@ func (s *Store) commitBatch(sync bool) error {
 	closeOldBatch := func() error {
 		defer oldBatch.Close()
 		// this might need a max commit semaphore style lock or something
+		start := time.Now()
 		err := oldBatch.Commit(pebble.NoSync)
+		metrics.BatchCommitDuration.Observe(time.Since(start).Seconds())
 		if err != nil {
 			logging.L.Panic().Err(err).Msg("failed to write Batch")
 			return err
@@ -165,16 +201,21 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 	txs := block.Txs
 	height := block.Height
 
-	b := batch
+	// every pair written for the block also feeds the block digest
+	digest := new(blockDigest)
+	set := func(key, value []byte) error {
+		digest.add(key, value)
+		return batch.Set(key, value, nil)
+	}
 
 	// chain index
-	if err := b.Set(KeyCIHeight(height), blockHash, nil); err != nil {
+	if err := set(KeyCIHeight(height), blockHash); err != nil {
 		logging.L.Err(err).Msg("insert failed")
 		return err
 	}
 	hb := make([]byte, SizeHeight)
 	be32(height, hb)
-	if err := b.Set(KeyCIBlock(blockHash), hb, nil); err != nil {
+	if err := set(KeyCIBlock(blockHash), hb); err != nil {
 		logging.L.Err(err).Msg("insert failed")
 		return err
 	}
@@ -194,12 +235,12 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 			continue
 		}
 		// bt
-		if err := b.Set(KeyBlockTx(blockHash, uint32(i)), t.Txid, nil); err != nil {
+		if err := set(KeyBlockTx(blockHash, uint32(i)), t.Txid); err != nil {
 			logging.L.Err(err).Msg("insert failed")
 			return err
 		}
 		// tb (optional, but handy for reorg/tools)
-		if err := b.Set(KeyTxOccur(t.Txid, blockHash), nil, nil); err != nil {
+		if err := set(KeyTxOccur(t.Txid, blockHash), nil); err != nil {
 			logging.L.Err(err).Msg("insert failed")
 			return err
 		}
@@ -214,7 +255,7 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 				logging.L.Err(err).Msg("insert failed")
 				return err
 			}
-			err = b.Set(KeySpend(in.PrevTxid, in.PrevVout, blockHash), val, nil)
+			err = set(KeySpend(in.PrevTxid, in.PrevVout, blockHash), val)
 			if err != nil {
 				logging.L.Err(err).Msg("insert failed")
 				return err
@@ -238,7 +279,7 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 				logging.L.Err(err).Msg("insert failed")
 				return err
 			}
-			if err := b.Set(KeyTx(t.Txid), val, nil); err != nil {
+			if err := set(KeyTx(t.Txid), val); err != nil {
 				logging.L.Err(err).Msg("insert failed")
 				return err
 			}
@@ -251,7 +292,7 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 					logging.L.Err(err).Any("output", o).Msg("insert failed")
 					return err
 				}
-				if err := b.Set(KeyOut(o.Txid, o.Vout), val, nil); err != nil {
+				if err := set(KeyOut(o.Txid, o.Vout), val); err != nil {
 					logging.L.Err(err).Any("output", o).Msg("insert failed")
 					return err
 				}
@@ -267,7 +308,7 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 				Tweak:        t.Tweak[:],
 				OutputsShort: newOutsShort,
 			}
-			if err := b.Set(computeIndex.SerialiseKey(), computeIndex.SerialiseData(), nil); err != nil {
+			if err := set(computeIndex.SerialiseKey(), computeIndex.SerialiseData()); err != nil {
 				return err
 			}
 		}
@@ -293,7 +334,7 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 		for i, outputShort := range spentOutputsShort {
 			copy(spentOutputsValue[i*8:(i+1)*8], outputShort[:])
 		}
-		if err := b.Set(KeySpentOutputsShort(blockHash), spentOutputsValue, nil); err != nil {
+		if err := set(KeySpentOutputsShort(blockHash), spentOutputsValue); err != nil {
 			logging.L.Err(err).Msg("insert spent outputs short failed")
 			return err
 		}
@@ -303,7 +344,7 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 			Uint32("height", height).
 			Hex("block_hash", utils.ReverseBytesCopy(blockHash)).
 			Msg("no spent outputs for block")
-		if err := b.Set(KeySpentOutputsShort(blockHash), []byte{}, nil); err != nil {
+		if err := set(KeySpentOutputsShort(blockHash), []byte{}); err != nil {
 			logging.L.Err(err).Msg("insert spent outputs short failed")
 			return err
 		}
@@ -316,12 +357,19 @@ func attachBlockToBatch(batch *pebble.Batch, block *database.DBBlock) error {
 			logging.L.Err(err).Hex("txid", txid[:]).Msg("failed to encode txid outpoints")
 			return err
 		}
-		if err := b.Set(KeyTxidOutpoints(blockHash, txid[:]), val, nil); err != nil {
+		if err := set(KeyTxidOutpoints(blockHash, txid[:]), val); err != nil {
 			logging.L.Err(err).Hex("txid", txid[:]).Msg("insert txid outpoints failed")
 			return err
 		}
 	}
 
+	// digest goes last so it covers everything above
+	sum := digest.sum()
+	if err := batch.Set(KeyBlockDigest(blockHash), sum[:], nil); err != nil {
+		logging.L.Err(err).Msg("insert block digest failed")
+		return err
+	}
+
 	return nil
 }
 
@@ -339,19 +387,22 @@ func (s *Store) WaitForPendingCommits() {
 }
 
 // Close safely closes the store by waiting for all pending commits before closing the database
+// The writers have to be stopped before, see Builder.stopAtBlockBoundary.
 func (s *Store) Close() error {
-	// Mark store as closed to prevent new commits
-	atomic.StoreInt32(&s.closed, 1)
-
 	// Wait for all pending background commits to complete
 	s.WaitForPendingCommits()
 
-	// Flush any remaining batch synchronously
+	// Flush any remaining batch synchronously,
+	// has to happen before marking the store closed as commitBatch skips closed stores
 	if err := s.FlushBatch(true); err != nil {
 		logging.L.Err(err).Msg("failed to flush final batch")
 		return err
 	}
 
+	// Mark store as closed to prevent new commits
+	atomic.StoreInt32(&s.closed, 1)
+	s.WaitForPendingCommits()
+
 	// Close the underlying database
 	if err := s.DB.Close(); err != nil {
 		logging.L.Err(err).Msg("failed to close database")
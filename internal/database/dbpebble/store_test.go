@@ -0,0 +1,184 @@
+package dbpebble_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+)
+
+// the last blocks of a sync are only in the batch until Close
+func TestCloseFlushesBatch(t *testing.T) {
+	fs := vfs.NewMem()
+	db, err := pebble.Open("", &pebble.Options{FS: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txid := bytes.Repeat([]byte{0xa1}, 32)
+	tweak := [33]byte{0x02, 0x11}
+	block := &database.DBBlock{
+		Height: 1,
+		Hash:   &chainhash.Hash{0x01},
+		Txs: []*database.Tx{{
+			Txid:  txid,
+			Tweak: &tweak,
+			Outs: []*database.Output{
+				{Txid: txid, Vout: 0, Amount: 1_000, Pubkey: bytes.Repeat([]byte{0x0a}, 32)},
+			},
+		}},
+	}
+
+	store := dbpebble.NewStore(db)
+	if err = store.ApplyBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = pebble.Open("", &pebble.Options{FS: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store = dbpebble.NewStore(db)
+	defer store.Close()
+
+	blockhash, err := store.GetBlockHashByHeight(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(blockhash, block.Hash[:]) {
+		t.Fatalf("block not persisted on close, got hash %x", blockhash)
+	}
+}
+
+// wallets are stored right away and a scan started before a re-registration is dropped
+func TestWalletsSurviveReopen(t *testing.T) {
+	fs := vfs.NewMem()
+	db, err := pebble.Open("", &pebble.Options{FS: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := dbpebble.NewStore(db)
+	block := &database.DBBlock{Height: 1, Hash: &chainhash.Hash{0x01}}
+	testhelpers.ApplyBlocks(t, store, block)
+
+	label := uint32(3)
+	w := &database.Wallet{ID: [32]byte{0x77}, SpendPubKey: [33]byte{0x02}, Labels: []uint32{0, label}, BirthHeight: 1}
+	if err = store.PutWallet(w); err != nil {
+		t.Fatal(err)
+	}
+	scan := &database.WalletScan{
+		ID:            w.ID,
+		Revision:      w.Revision,
+		FromHeight:    0,
+		ScannedHeight: 1,
+		ScannedHash:   block.Hash[:],
+		Outputs: []*database.WalletOutput{
+			{Height: 1, Blockhash: block.Hash[:], Txid: [32]byte{0xa1}, Vout: 2, Amount: 1_000, Label: &label},
+		},
+	}
+	// registered again while the scan ran
+	if err = store.PutWallet(w); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.AdvanceWallet(scan); err != nil || ok {
+		t.Fatalf("stale scan stored: %v %v", ok, err)
+	}
+	scan.Revision = w.Revision
+	if ok, err := store.AdvanceWallet(scan); err != nil || !ok {
+		t.Fatalf("scan not stored: %v %v", ok, err)
+	}
+	if err = store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = pebble.Open("", &pebble.Options{FS: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store = dbpebble.NewStore(db)
+	defer store.Close()
+
+	stored, err := store.FetchWallet(w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ScannedHeight != 1 || !bytes.Equal(stored.ScannedHash, block.Hash[:]) || len(stored.Labels) != 2 {
+		t.Errorf("unexpected wallet %+v", stored)
+	}
+	outputs, err := store.FetchWalletOutputs(w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Vout != 2 || outputs[0].Label == nil || *outputs[0].Label != label {
+		t.Fatalf("unexpected outputs %+v", outputs)
+	}
+
+	// a single wallet is rewound without touching the others
+	other := &database.Wallet{ID: [32]byte{0x78}, SpendPubKey: [33]byte{0x03}, BirthHeight: 1}
+	if err = store.PutWallet(other); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.AdvanceWallet(&database.WalletScan{
+		ID: other.ID, Revision: other.Revision, ScannedHeight: 1, ScannedHash: block.Hash[:],
+	}); err != nil || !ok {
+		t.Fatalf("scan not stored: %v %v", ok, err)
+	}
+	if err = store.RewindWallet(w.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err = store.FetchWallet(w.ID); err != nil || stored.ScannedHeight != 0 {
+		t.Errorf("wallet not rewound: %+v %v", stored, err)
+	}
+	if outputs, err = store.FetchWalletOutputs(w.ID); err != nil || len(outputs) != 0 {
+		t.Errorf("outputs kept: %d %v", len(outputs), err)
+	}
+	if stored, err = store.FetchWallet(other.ID); err != nil || stored.ScannedHeight != 1 {
+		t.Errorf("other wallet rewound: %+v %v", stored, err)
+	}
+}
+
+// a block replacing another at the same height takes over its compute index, the replaced txs are dropped
+func TestApplyBlockReplacesComputeIndex(t *testing.T) {
+	tweak := [33]byte{0x02, 0x11}
+	block := func(hash, txByte byte) *database.DBBlock {
+		txid := bytes.Repeat([]byte{txByte}, 32)
+		return &database.DBBlock{
+			Height: 1,
+			Hash:   &chainhash.Hash{hash},
+			Txs: []*database.Tx{{
+				Txid:  txid,
+				Tweak: &tweak,
+				Outs:  []*database.Output{{Txid: txid, Vout: 0, Amount: 1_000, Pubkey: bytes.Repeat([]byte{txByte}, 32)}},
+			}},
+		}
+	}
+
+	store := testhelpers.NewStore(t, block(0x01, 0xa1))
+	// applying the same block again keeps its rows
+	testhelpers.ApplyBlocks(t, store, block(0x01, 0xa1))
+	items, err := store.FetchComputeIndex(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d txs after reapplying the block, want 1", len(items))
+	}
+
+	testhelpers.ApplyBlocks(t, store, block(0x02, 0xb2))
+	items, err = store.FetchComputeIndex(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || !bytes.Equal(items[0].Txid, bytes.Repeat([]byte{0xb2}, 32)) {
+		t.Fatalf("got %d txs after the replacement, want only the new tx", len(items))
+	}
+}
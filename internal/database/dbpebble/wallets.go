@@ -0,0 +1,341 @@
+package dbpebble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+// Wallet registrations and their outputs are written directly with their own sync batches,
+// independent of the block batch. walletSync serialises the read-modify-write operations.
+
+const (
+	sizeWalletFixed = 3*SizeHeight + 32 + SizeTweak + SizeHash
+	sizeWalletOut   = SizeAmt + SizePubKey + SizeTweak + 32 + SizeHash
+	sizeLabel       = 4
+)
+
+func ValWallet(w *database.Wallet) []byte {
+	v := make([]byte, sizeWalletFixed+sizeLabel*len(w.Labels))
+	be32(w.Revision, v[0:4])
+	be32(w.BirthHeight, v[4:8])
+	be32(w.ScannedHeight, v[8:12])
+	copy(v[12:44], w.ScanSecretKey[:])
+	copy(v[44:77], w.SpendPubKey[:])
+	// all zero until the first scan
+	copy(v[77:77+SizeHash], w.ScannedHash)
+	for i, m := range w.Labels {
+		be32(m, v[sizeWalletFixed+i*sizeLabel:])
+	}
+	return v
+}
+
+func ParseWalletValue(id [32]byte, v []byte) (*database.Wallet, error) {
+	if len(v) < sizeWalletFixed || (len(v)-sizeWalletFixed)%sizeLabel != 0 {
+		return nil, errors.New("bad wallet value length")
+	}
+	w := &database.Wallet{
+		ID:            id,
+		Revision:      binary.BigEndian.Uint32(v[0:4]),
+		BirthHeight:   binary.BigEndian.Uint32(v[4:8]),
+		ScannedHeight: binary.BigEndian.Uint32(v[8:12]),
+		ScanSecretKey: [32]byte(v[12:44]),
+		SpendPubKey:   [33]byte(v[44:77]),
+	}
+	if scannedHash := v[77 : 77+SizeHash]; !bytes.Equal(scannedHash, make([]byte, SizeHash)) {
+		w.ScannedHash = bytes.Clone(scannedHash)
+	}
+	for i := sizeWalletFixed; i < len(v); i += sizeLabel {
+		w.Labels = append(w.Labels, binary.BigEndian.Uint32(v[i:i+sizeLabel]))
+	}
+	return w, nil
+}
+
+func ValWalletOut(o *database.WalletOutput) []byte {
+	size := sizeWalletOut
+	if o.Label != nil {
+		size += sizeLabel
+	}
+	v := make([]byte, size)
+	le64(o.Amount, v[:SizeAmt])
+	off := SizeAmt
+	off += copy(v[off:], o.Pubkey[:])
+	off += copy(v[off:], o.Tweak[:])
+	off += copy(v[off:], o.SecKeyTweak[:])
+	off += copy(v[off:], o.Blockhash)
+	if o.Label != nil {
+		be32(*o.Label, v[off:])
+	}
+	return v
+}
+
+func ParseWalletOut(key, v []byte) (*database.WalletOutput, error) {
+	if len(key) != 1+SizeHash+SizeHeight+SizeTxid+SizeVout {
+		return nil, errors.New("bad wallet output key length")
+	}
+	if len(v) != sizeWalletOut && len(v) != sizeWalletOut+sizeLabel {
+		return nil, errors.New("bad wallet output value length")
+	}
+	key = key[1+SizeHash:]
+	o := &database.WalletOutput{
+		Height: binary.BigEndian.Uint32(key[:SizeHeight]),
+		Txid:   [32]byte(key[SizeHeight : SizeHeight+SizeTxid]),
+		Vout:   binary.BigEndian.Uint32(key[SizeHeight+SizeTxid:]),
+		Amount: binary.LittleEndian.Uint64(v[:SizeAmt]),
+	}
+	off := SizeAmt
+	off += copy(o.Pubkey[:], v[off:])
+	off += copy(o.Tweak[:], v[off:])
+	off += copy(o.SecKeyTweak[:], v[off:])
+	o.Blockhash = bytes.Clone(v[off : off+SizeHash])
+	off += SizeHash
+	if len(v) > off {
+		m := binary.BigEndian.Uint32(v[off:])
+		o.Label = &m
+	}
+	return o, nil
+}
+
+// PutWallet registers w or replaces its registration.
+// Outputs found so far are dropped, the wallet is scanned again from w.BirthHeight.
+func (s *Store) PutWallet(w *database.Wallet) error {
+	s.walletSync.Lock()
+	defer s.walletSync.Unlock()
+
+	old, err := s.fetchWallet(w.ID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return err
+	}
+	w.Revision = 1
+	if old != nil {
+		w.Revision = old.Revision + 1
+	}
+	w.ScannedHeight = w.BirthHeight - 1
+	w.ScannedHash = nil
+
+	batch := s.DB.NewBatch()
+	defer batch.Close()
+	lb, ub := BoundsWalletOut(w.ID, 0)
+	if err = batch.DeleteRange(lb, ub, nil); err != nil {
+		return err
+	}
+	if err = batch.Set(KeyWallet(w.ID), ValWallet(w), nil); err != nil {
+		return err
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// DeleteWallet removes the registration and the outputs found for it
+func (s *Store) DeleteWallet(id [32]byte) error {
+	s.walletSync.Lock()
+	defer s.walletSync.Unlock()
+
+	if _, err := s.fetchWallet(id); err != nil {
+		return err
+	}
+
+	batch := s.DB.NewBatch()
+	defer batch.Close()
+	lb, ub := BoundsWalletOut(id, 0)
+	if err := batch.DeleteRange(lb, ub, nil); err != nil {
+		return err
+	}
+	if err := batch.Delete(KeyWallet(id), nil); err != nil {
+		return err
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// FetchWallet returns database.ErrNotFound for unknown ids
+func (s *Store) FetchWallet(id [32]byte) (*database.Wallet, error) {
+	return s.fetchWallet(id)
+}
+
+func (s *Store) fetchWallet(id [32]byte) (*database.Wallet, error) {
+	val, closer, err := s.DB.Get(KeyWallet(id))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, fmt.Errorf("wallet %x: %w", id, database.ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return ParseWalletValue(id, val)
+}
+
+func (s *Store) FetchWallets() ([]*database.Wallet, error) {
+	lb, ub := BoundsWallets()
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var wallets []*database.Wallet
+	for ok := it.First(); ok; ok = it.Next() {
+		w, err := ParseWalletValue([32]byte(it.Key()[1:]), it.Value())
+		if err != nil {
+			logging.L.Err(err).Msg("bad wallet registration")
+			return nil, err
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, nil
+}
+
+// AdvanceWallet stores the outputs and the progress of a scan.
+// It reports false without storing anything if the wallet was re-registered, removed or scanned by someone else
+// since the scan started, or if scan.ScannedHash is no longer on the best chain.
+func (s *Store) AdvanceWallet(scan *database.WalletScan) (bool, error) {
+	s.walletSync.Lock()
+	defer s.walletSync.Unlock()
+
+	w, err := s.fetchWallet(scan.ID)
+	if errors.Is(err, database.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if w.Revision != scan.Revision || w.ScannedHeight != scan.FromHeight {
+		return false, nil
+	}
+	bestHash, err := s.GetBlockHashByHeight(scan.ScannedHeight)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(bestHash, scan.ScannedHash) {
+		return false, nil
+	}
+
+	batch := s.DB.NewBatch()
+	defer batch.Close()
+	for _, o := range scan.Outputs {
+		if err = batch.Set(KeyWalletOut(scan.ID, o.Height, o.Txid[:], o.Vout), ValWalletOut(o), nil); err != nil {
+			return false, err
+		}
+	}
+	w.ScannedHeight = scan.ScannedHeight
+	w.ScannedHash = scan.ScannedHash
+	if err = batch.Set(KeyWallet(w.ID), ValWallet(w), nil); err != nil {
+		return false, err
+	}
+	if err = batch.Commit(pebble.Sync); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RewindWallets drops the outputs found at height and above and sets the wallets back to height-1,
+// so the block replacing the one at height is scanned again
+func (s *Store) RewindWallets(height uint32) error {
+	s.walletSync.Lock()
+	defer s.walletSync.Unlock()
+
+	wallets, err := s.FetchWallets()
+	if err != nil {
+		return err
+	}
+
+	batch := s.DB.NewBatch()
+	defer batch.Close()
+	for _, w := range wallets {
+		if err = s.rewindWallet(batch, w, height); err != nil {
+			return err
+		}
+	}
+	if batch.Empty() {
+		return nil
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// RewindWallet is RewindWallets for a single wallet
+func (s *Store) RewindWallet(id [32]byte, height uint32) error {
+	s.walletSync.Lock()
+	defer s.walletSync.Unlock()
+
+	w, err := s.fetchWallet(id)
+	if err != nil {
+		return err
+	}
+
+	batch := s.DB.NewBatch()
+	defer batch.Close()
+	if err = s.rewindWallet(batch, w, height); err != nil {
+		return err
+	}
+	if batch.Empty() {
+		return nil
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// rewindWallet adds the rewind of w to batch if it is scanned up to height
+func (s *Store) rewindWallet(batch *pebble.Batch, w *database.Wallet, height uint32) error {
+	if w.ScannedHeight < height {
+		return nil
+	}
+	lb, ub := BoundsWalletOut(w.ID, height)
+	if err := batch.DeleteRange(lb, ub, nil); err != nil {
+		return err
+	}
+
+	w.ScannedHeight = max(height, w.BirthHeight) - 1
+	w.ScannedHash = nil
+	if w.ScannedHeight >= w.BirthHeight {
+		var err error
+		w.ScannedHash, err = s.GetBlockHashByHeight(w.ScannedHeight)
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Set(KeyWallet(w.ID), ValWallet(w), nil)
+}
+
+func (s *Store) FetchWalletOutputs(id [32]byte) ([]*database.WalletOutput, error) {
+	lb, ub := BoundsWalletOut(id, 0)
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var outputs []*database.WalletOutput
+	for ok := it.First(); ok; ok = it.Next() {
+		o, err := ParseWalletOut(it.Key(), it.Value())
+		if err != nil {
+			logging.L.Err(err).Msg("bad wallet output")
+			return nil, err
+		}
+		outputs = append(outputs, o)
+	}
+	return outputs, nil
+}
+
+// SpendingBlock returns the block on the best chain which spends the outpoint, nil if it is unspent
+func (s *Store) SpendingBlock(prevTxid []byte, prevVout uint32) ([]byte, uint32, error) {
+	lb, ub := BoundsSpend(prevTxid, prevVout)
+	it, err := s.DB.NewIter(&pebble.IterOptions{LowerBound: lb, UpperBound: ub})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer it.Close()
+
+	for ok := it.First(); ok; ok = it.Next() {
+		k := it.Key()
+		blockhash := bytes.Clone(k[len(k)-SizeHash:])
+		height, err := s.BlockHeightByHash(blockhash)
+		if errors.Is(err, database.ErrNotOnBestChain) || errors.Is(err, database.ErrNotFound) {
+			// spent in a block replaced by a reorg
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		}
+		return blockhash, height, nil
+	}
+	return nil, 0, nil
+}
@@ -0,0 +1,18 @@
+package database
+
+import "errors"
+
+// Typed errors for data which can't be served.
+// Implementations wrap them with context, check with errors.Is.
+var (
+	// ErrNotFound the requested block or height is not in the index
+	ErrNotFound = errors.New("not found")
+	// ErrNotYetSynced the requested height is above the synced tip
+	ErrNotYetSynced = errors.New("not yet synced")
+	// ErrPruned the requested height is below the first indexed block
+	ErrPruned = errors.New("pruned")
+	// ErrFeatureDisabled the requested data is not served with the current configuration
+	ErrFeatureDisabled = errors.New("feature disabled")
+	// ErrNotOnBestChain the requested block was indexed but replaced by a reorg
+	ErrNotOnBestChain = errors.New("not on best chain")
+)
@@ -0,0 +1,34 @@
+package database
+
+import "github.com/setavenger/go-bip352"
+
+// ScanKeys are the receiver keys for scanning the compute index on the server
+type ScanKeys struct {
+	ScanSecretKey [32]byte
+	SpendPubKey   [33]byte
+	// Labels to check besides the plain spend key, wallets should always include the change label (m = 0)
+	Labels []*bip352.Label
+}
+
+// FoundOutputShort an output of the compute index which belongs to the scanned keys
+type FoundOutputShort struct {
+	// Only first 8 bytes
+	Output      [8]byte
+	SecKeyTweak [32]byte
+	Label       *bip352.Label
+	Txid        [32]byte
+	Height      uint32
+	Tweak       [33]byte
+}
+
+func (f *FoundOutputShort) GetOutput() [8]byte {
+	return f.Output
+}
+
+func (f *FoundOutputShort) GetSecKeyTweak() [32]byte {
+	return f.SecKeyTweak
+}
+
+func (f *FoundOutputShort) GetLabel() *bip352.Label {
+	return f.Label
+}
@@ -2,13 +2,18 @@
 package database
 
 import (
+	"context"
+
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/setavenger/blindbit-lib/proto/pb"
+
+	"github.com/setavenger/blindbit-oracle/proto/pb"
 )
 
 type DB interface {
 	GetChainTip() ([]byte, uint32, error)
 	GetBlockHashByHeight(height uint32) ([]byte, error)
+	BlockHeightByHash(blockhash []byte) (uint32, error)
+	ResolveHeight(height uint32) ([]byte, error)
 	ApplyBlock(*DBBlock) error
 	FlushBatch(sync bool) error
 	TweaksForBlockAll([]byte) ([]*TweakRow, error)
@@ -16,7 +21,9 @@ type DB interface {
 	FetchOutputsAll(blockhash []byte, tipheight uint32) ([]*Output, error)
 	FetchSpentOutputsShort(blockhash []byte) ([]byte, error)
 	ChainIterator(asc bool) (<-chan []byte, error) // todo: add context
+	ForEachHeight(startHeight, endHeight uint32, fn func(height uint32) error) error
 	FetchComputeIndex(height uint32) ([]*pb.ComputeIndexTxItem, error)
+	FetchComputeIndexFiltered(height, tipHeight uint32, dustLimit uint64, cutThrough bool) ([]*pb.ComputeIndexTxItem, error)
 	BlockhashInDB(blockhash []byte) (bool, error)
 	BatchSize() int
 	KeyExistsComputeIndex(blockhash []byte) (bool, error)
@@ -26,6 +33,70 @@ type DB interface {
 	FetchAllTxidOutpointsForBlock(blockhash []byte) (map[[32]byte][][36]byte, error)
 }
 
+// IntegrityStore is implemented by stores which can check and repair their secondary indexes
+type IntegrityStore interface {
+	CheckBlockConsistency(height uint32) (*BlockConsistency, error)
+	RepairCIBlock(height uint32) error
+	BuildComputeIndexByRange(ctx context.Context, startHeight, endHeight uint32) error
+}
+
+// ScanStore is implemented by stores which can scan the compute index for outputs of a wallet
+type ScanStore interface {
+	DBComputeComputeIndexParallel(ctx context.Context, keys *ScanKeys, startHeight, endHeight uint32, numWorkers int, rangeSize uint32) ([]*FoundOutputShort, error)
+	OutputsForTx(txid []byte) ([]*Output, error)
+}
+
+// WalletStore is implemented by stores which keep watch-only wallets and their found outputs
+type WalletStore interface {
+	PutWallet(w *Wallet) error
+	DeleteWallet(id [32]byte) error
+	FetchWallet(id [32]byte) (*Wallet, error)
+	FetchWallets() ([]*Wallet, error)
+	AdvanceWallet(scan *WalletScan) (bool, error)
+	RewindWallets(height uint32) error
+	RewindWallet(id [32]byte, height uint32) error
+	FetchWalletOutputs(id [32]byte) ([]*WalletOutput, error)
+	SpendingBlock(prevTxid []byte, prevVout uint32) ([]byte, uint32, error)
+}
+
+// BlockConsistency lists the secondary index problems found for one height
+type BlockConsistency struct {
+	Height uint32
+	// MissingBlock no chain index entry for the height at all (a gap)
+	MissingBlock bool
+	// MissingCIBlock the height points to a blockhash which has no (or a different) hash -> height entry
+	MissingCIBlock bool
+	// MissingComputeIndex a tweak is stored but the compute index row is missing
+	MissingComputeIndex bool
+	// MissingTweak outputs or compute index rows exist for a txid of the block without a stored tweak
+	MissingTweak bool
+	// MissingSpentOutputsShort the accelerator index for spent outputs is missing
+	MissingSpentOutputsShort bool
+	// DigestMismatch nothing else was found but the block digest differs,
+	// e.g. a tx lost its tweak together with its outputs and compute index row
+	DigestMismatch bool
+}
+
+// OK reports whether no problems were found
+func (c *BlockConsistency) OK() bool {
+	return !c.MissingBlock &&
+		!c.MissingCIBlock &&
+		!c.MissingComputeIndex &&
+		!c.MissingTweak &&
+		!c.MissingSpentOutputsShort &&
+		!c.DigestMismatch
+}
+
+// NeedsRepull reports whether the block has to be pulled again.
+// A missing compute index can be rebuilt from the stored tweaks and outputs
+// and a missing hash -> height entry from the height -> hash entry.
+func (c *BlockConsistency) NeedsRepull() bool {
+	return c.MissingBlock ||
+		c.MissingTweak ||
+		c.MissingSpentOutputsShort ||
+		c.DigestMismatch
+}
+
 type TweakRow struct {
 	Txid  [32]byte
 	Tweak [33]byte
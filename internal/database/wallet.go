@@ -0,0 +1,43 @@
+package database
+
+// Wallet a watch-only wallet registration, the indexer scans every block from BirthHeight on for it
+type Wallet struct {
+	// ID HMAC-SHA256 over the spend public key keyed with the scan secret key, see query.WalletID
+	ID            [32]byte
+	ScanSecretKey [32]byte
+	SpendPubKey   [33]byte
+	Labels        []uint32
+	BirthHeight   uint32
+	// ScannedHeight the last scanned height, BirthHeight-1 before the first scan
+	ScannedHeight uint32
+	// ScannedHash the blockhash at ScannedHeight when it was scanned, nil before the first scan
+	ScannedHash []byte
+	// Revision changes with every registration, scans of an older revision are dropped
+	Revision uint32
+}
+
+// WalletOutput an output found for a wallet, hashes in internal byte order
+type WalletOutput struct {
+	Height      uint32
+	Blockhash   []byte
+	Txid        [32]byte
+	Vout        uint32
+	Amount      uint64
+	Pubkey      [32]byte
+	Tweak       [33]byte
+	SecKeyTweak [32]byte
+	// Label m of the label the output was sent to, nil for the plain spend key
+	Label *uint32
+}
+
+// WalletScan the result of scanning a wallet up to ScannedHeight
+type WalletScan struct {
+	ID [32]byte
+	// Revision and FromHeight the scan started at, it is only stored if the wallet is still there
+	Revision   uint32
+	FromHeight uint32
+
+	ScannedHeight uint32
+	ScannedHash   []byte
+	Outputs       []*WalletOutput
+}
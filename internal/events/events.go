@@ -0,0 +1,112 @@
+// Package events fans out block notifications from the indexer to the API servers.
+package events
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/setavenger/blindbit-lib/utils"
+)
+
+// Block event types
+const (
+	BlockConnected    = "block_connected"
+	BlockDisconnected = "block_disconnected"
+)
+
+// BlockEvent is sent after the block was committed to the store.
+// BlockHash is hex in display order.
+type BlockEvent struct {
+	Type      string `json:"type"`
+	Height    uint32 `json:"height"`
+	BlockHash string `json:"block_hash"`
+}
+
+// NewBlockEvent creates an event, blockhash is in internal byte order like in the store
+func NewBlockEvent(eventType string, height uint32, blockhash []byte) BlockEvent {
+	return BlockEvent{
+		Type:      eventType,
+		Height:    height,
+		BlockHash: hex.EncodeToString(utils.ReverseBytesCopy(blockhash)),
+	}
+}
+
+// Bus delivers published events to all subscribers.
+// Publish never blocks, a subscriber which can't keep up is dropped
+// and its channel closed so the client reconnects instead of missing events.
+type Bus struct {
+	mu     sync.Mutex
+	subs   map[chan BlockEvent]struct{}
+	closed chan struct{}
+}
+
+// Blocks is the bus the indexer publishes connected and disconnected blocks on
+var Blocks = NewBus()
+
+func NewBus() *Bus {
+	return &Bus{
+		subs:   make(map[chan BlockEvent]struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving all events published from now on
+// and a function to unsubscribe, which has to be called once done
+func (b *Bus) Subscribe(buffer int) (<-chan BlockEvent, func()) {
+	ch := make(chan BlockEvent, buffer)
+
+	b.mu.Lock()
+	select {
+	case <-b.closed:
+		close(ch)
+	default:
+		b.subs[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends ev to every subscriber
+func (b *Bus) Publish(ev BlockEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends all subscriptions on shutdown, later subscriptions are closed right away
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case <-b.closed:
+		return
+	default:
+	}
+	close(b.closed)
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// Closed is closed once Close was called, subscribers use it to tell a shutdown from being dropped
+func (b *Bus) Closed() <-chan struct{} {
+	return b.closed
+}
@@ -0,0 +1,51 @@
+package events
+
+import "testing"
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	fast, unsubscribeFast := bus.Subscribe(4)
+	slow, unsubscribeSlow := bus.Subscribe(1)
+	defer unsubscribeFast()
+
+	connected := NewBlockEvent(BlockConnected, 2, []byte{0x02, 0x01})
+	disconnected := NewBlockEvent(BlockDisconnected, 2, []byte{0x02, 0x02})
+	if connected.BlockHash[:4] != "0102" {
+		t.Errorf("block hash %s not in display order", connected.BlockHash)
+	}
+
+	// the slow subscriber's buffer is full after the first event, it is dropped on the second
+	bus.Publish(disconnected)
+	bus.Publish(connected)
+	for _, want := range []BlockEvent{disconnected, connected} {
+		if got := <-fast; got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+	if got := <-slow; got != disconnected {
+		t.Errorf("got %+v, want %+v", got, disconnected)
+	}
+	if _, ok := <-slow; ok {
+		t.Fatal("slow subscriber not dropped")
+	}
+	// unsubscribing after being dropped does not close twice
+	unsubscribeSlow()
+
+	bus.Close()
+	if _, ok := <-fast; ok {
+		t.Fatal("subscription open after close")
+	}
+	select {
+	case <-bus.Closed():
+	default:
+		t.Fatal("closed not signalled")
+	}
+	late, unsubscribeLate := bus.Subscribe(1)
+	defer unsubscribeLate()
+	if _, ok := <-late; ok {
+		t.Fatal("subscription after close is open")
+	}
+	// publishing after close reaches nobody and does not panic
+	bus.Publish(connected)
+	bus.Close()
+}
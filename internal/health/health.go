@@ -0,0 +1,80 @@
+// Package health tracks the sync state the readiness checks of the HTTP and gRPC servers are based on.
+// The indexer reports into it, the servers only read.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+var (
+	mu sync.RWMutex
+
+	// indexerRunning is false for server-only, then there is no lag to check
+	indexerRunning bool
+	initialSync    bool
+	builderErr     error
+
+	indexedHeight uint32
+	coreTip       int64
+)
+
+// SetIndexerRunning marks that this process runs an indexer,
+// readiness then depends on its state
+func SetIndexerRunning() {
+	mu.Lock()
+	defer mu.Unlock()
+	indexerRunning = true
+}
+
+// SetInitialSync is true while the initial sync to the Core tip runs
+func SetInitialSync(running bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	initialSync = running
+}
+
+// SetBuilderError records that the builder stopped with err, nil once it runs again
+func SetBuilderError(err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	builderErr = err
+}
+
+// SetSyncState records the indexed height and the Core tip, coreTip is only set if it is known (> 0)
+func SetSyncState(indexed uint32, tip int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	indexedHeight = indexed
+	if tip > 0 {
+		coreTip = tip
+	}
+}
+
+// Ready returns nil if the oracle serves data close enough to the Core tip.
+// Fails while the initial sync runs, after the builder stopped with an error
+// or if the lag to Core is above config.ReadinessMaxLag.
+func Ready() error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if !indexerRunning {
+		return nil
+	}
+	if builderErr != nil {
+		return fmt.Errorf("indexer stopped: %w", builderErr)
+	}
+	if initialSync {
+		return errors.New("initial sync running")
+	}
+	if coreTip == 0 {
+		return errors.New("core tip not known yet")
+	}
+	if lag := coreTip - int64(indexedHeight); lag > int64(config.ReadinessMaxLag) {
+		return fmt.Errorf("%d blocks behind core (max %d)", lag, config.ReadinessMaxLag)
+	}
+	return nil
+}
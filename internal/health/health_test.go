@@ -0,0 +1,43 @@
+package health
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+func TestReady(t *testing.T) {
+	savedLag := config.ReadinessMaxLag
+	config.ReadinessMaxLag = 3
+	t.Cleanup(func() { config.ReadinessMaxLag = savedLag })
+
+	// the states are applied in order, like the indexer reports them
+	steps := []struct {
+		name  string
+		apply func()
+		ready bool
+	}{
+		{name: "server only", apply: func() {}, ready: true},
+		{name: "initial sync", apply: func() {
+			SetIndexerRunning()
+			SetInitialSync(true)
+			SetSyncState(100, 200)
+		}, ready: false},
+		{name: "initial sync caught up", apply: func() { SetSyncState(200, 200) }, ready: false},
+		{name: "initial sync done", apply: func() { SetInitialSync(false) }, ready: true},
+		{name: "lag at the limit", apply: func() { SetSyncState(200, 203) }, ready: true},
+		{name: "lag above the limit", apply: func() { SetSyncState(200, 204) }, ready: false},
+		{name: "unknown core tip keeps the last one", apply: func() { SetSyncState(200, 0) }, ready: false},
+		{name: "caught up", apply: func() { SetSyncState(204, 0) }, ready: true},
+		{name: "builder error", apply: func() { SetBuilderError(errors.New("core unreachable")) }, ready: false},
+		{name: "builder running again", apply: func() { SetBuilderError(nil) }, ready: true},
+	}
+
+	for _, step := range steps {
+		step.apply()
+		if err := Ready(); (err == nil) != step.ready {
+			t.Errorf("%s: ready %v, got %v", step.name, step.ready, err)
+		}
+	}
+}
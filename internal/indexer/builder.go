@@ -1,6 +1,7 @@
 package indexer
 
 import (
+	"bytes"
 	"context"
 	"sync"
 	"time"
@@ -10,6 +11,10 @@ import (
 	"github.com/setavenger/blindbit-lib/utils"
 	"github.com/setavenger/blindbit-oracle/internal/config"
 	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/events"
+	"github.com/setavenger/blindbit-oracle/internal/health"
+	"github.com/setavenger/blindbit-oracle/internal/metrics"
+	"github.com/setavenger/blindbit-oracle/internal/query"
 	"github.com/setavenger/go-bip352"
 )
 
@@ -25,6 +30,14 @@ type Builder struct {
 	// db connection used for the entire builder in all go routines
 	store database.DB
 
+	// query scans the registered wallets
+	query *query.Service
+	// reorgMu is held by the writer while it rewinds wallets and applies a replacing block,
+	// wallet scans hold it shared so they never mix blocks of both chains
+	reorgMu sync.RWMutex
+	// walletWake tells the wallet scanner about a new block
+	walletWake chan struct{}
+
 	// Note: forceRebuildStaticIndexesDuringSync removed - static indexes should only be rebuilt with explicit user intention
 }
 
@@ -41,7 +54,9 @@ func NewBuilder(ctx context.Context, db database.DB) *Builder {
 			chan *database.DBBlock,
 			config.MaxParallelTweakComputations*20,
 		),
-		store: db,
+		store:      db,
+		query:      query.New(db),
+		walletWake: make(chan struct{}, 1),
 	}
 }
 
@@ -54,6 +69,19 @@ func (b *Builder) ContinuousSync(ctx context.Context) error {
 	b.newBlockChan = make(chan *Block, config.MaxParallelRequests*20)
 	b.writerChan = make(chan *database.DBBlock, config.MaxParallelTweakComputations*20)
 
+	// wallets are scanned next to the writer, so blocks don't wait for them
+	scannerCtx, stopScanner := context.WithCancel(ctx)
+	scannerDone := make(chan struct{})
+	go func() {
+		defer close(scannerDone)
+		b.runWalletScanner(scannerCtx)
+	}()
+	// the store must not be closed under a running scan
+	defer func() {
+		stopScanner()
+		<-scannerDone
+	}()
+
 	// Start writer goroutine to process blocks from writerChan
 	errChan := make(chan error)
 	doneChan := make(chan struct{})
@@ -71,23 +99,22 @@ func (b *Builder) ContinuousSync(ctx context.Context) error {
 					return
 				}
 
-				err := b.store.ApplyBlock(dbBlock)
+				replacedHash, err := b.writeBlock(dbBlock)
 				if err != nil {
-					logging.L.Err(err).
-						Str("blockhash", dbBlock.Hash.String()).
-						Uint32("height", dbBlock.Height).
-						Msg("failed storing block")
 					errChan <- err
 					return
 				}
+				reportSyncState(dbBlock.Height, 0)
 
-				// need to flush as batch sizes won't get big here
-				// only reorgs but even those are small usually
-				err = b.store.FlushBatch(true)
-				if err != nil {
-					logging.L.Err(err).Msg("failed flushing batch")
-					errChan <- err
-					return
+				// only announced after the commit so clients can fetch the data right away
+				if replacedHash != nil {
+					events.Blocks.Publish(events.NewBlockEvent(events.BlockDisconnected, dbBlock.Height, replacedHash))
+				}
+				events.Blocks.Publish(events.NewBlockEvent(events.BlockConnected, dbBlock.Height, dbBlock.Hash[:]))
+
+				select {
+				case b.walletWake <- struct{}{}:
+				default:
 				}
 
 				logging.L.Info().
@@ -100,6 +127,9 @@ func (b *Builder) ContinuousSync(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
+			if err := b.stopAtBlockBoundary(doneChan, errChan); err != nil {
+				return err
+			}
 			return ctx.Err()
 		case err := <-errChan:
 			logging.L.Err(err).Msg("there was an error processing blocks")
@@ -127,6 +157,7 @@ func (b *Builder) ContinuousSync(ctx context.Context) error {
 				logging.L.Err(err).Msg("failed to pull chainInfo")
 				return err
 			}
+			reportSyncState(syncTip, chainInfo.Blocks)
 
 			// todo: change to single block pull
 			// we also check previous blockhash basically going backwards and overwriting if exists
@@ -143,6 +174,53 @@ func (b *Builder) ContinuousSync(ctx context.Context) error {
 	}
 }
 
+// writeBlock applies and commits dbBlock.
+// If it replaces a different block at its height, the wallets are rewound first and that block's hash is returned.
+func (b *Builder) writeBlock(dbBlock *database.DBBlock) (replacedHash []byte, err error) {
+	replacedHash, err = b.store.GetBlockHashByHeight(dbBlock.Height)
+	if err != nil {
+		logging.L.Err(err).
+			Uint32("height", dbBlock.Height).
+			Msg("failed to look up block at height")
+		return nil, err
+	}
+	if bytes.Equal(replacedHash, dbBlock.Hash[:]) {
+		replacedHash = nil
+	}
+
+	if wallets, ok := b.store.(database.WalletStore); ok && replacedHash != nil {
+		b.reorgMu.Lock()
+		defer b.reorgMu.Unlock()
+
+		// before the block is applied, so a crash in between can't leave matches of the replaced block
+		err = wallets.RewindWallets(dbBlock.Height)
+		if err != nil {
+			logging.L.Err(err).
+				Uint32("height", dbBlock.Height).
+				Msg("failed rewinding wallets")
+			return nil, err
+		}
+	}
+
+	err = b.store.ApplyBlock(dbBlock)
+	if err != nil {
+		logging.L.Err(err).
+			Str("blockhash", dbBlock.Hash.String()).
+			Uint32("height", dbBlock.Height).
+			Msg("failed storing block")
+		return nil, err
+	}
+
+	// need to flush as batch sizes won't get big here
+	// only reorgs but even those are small usually
+	err = b.store.FlushBatch(true)
+	if err != nil {
+		logging.L.Err(err).Msg("failed flushing batch")
+		return nil, err
+	}
+	return replacedHash, nil
+}
+
 func (b *Builder) InitialSyncToTip(
 	ctx context.Context,
 ) error {
@@ -196,7 +274,12 @@ func (b *Builder) SyncBlocks(
 			case pullSemaphore <- struct{}{}:
 				wg.Add(1)
 			case <-ctx.Done():
-				errChan <- ctx.Err()
+				// the consumer sees the cancellation itself and may already have
+				// returned from stopAtBlockBoundary, so don't block on reporting it
+				select {
+				case errChan <- ctx.Err():
+				default:
+				}
 				return
 			}
 
@@ -259,6 +342,7 @@ func (b *Builder) SyncBlocks(
 					}
 
 					handleTime := time.Since(handleStartTime)
+					metrics.HandleDuration.Observe(handleTime.Seconds())
 					logging.L.Trace().
 						Int("worker_id", workerID).
 						Str("blockhash", block.Hash.String()).
@@ -303,6 +387,8 @@ func (b *Builder) SyncBlocks(
 					return
 				}
 
+				metrics.WriterBacklog.Set(float64(len(b.writerChan)))
+
 				// Simple completion log
 				logging.L.Info().
 					Uint32("height", dbBlock.Height).
@@ -322,6 +408,7 @@ func (b *Builder) SyncBlocks(
 					logging.L.Warn().Err(err).Msg("failed to get chain info for progress report")
 					chainInfo = &ChainInfo{Blocks: int64(syncTip)} // fallback
 				}
+				reportSyncState(syncTip, chainInfo.Blocks)
 
 				// Enhanced progress reporting
 				logging.L.Info().
@@ -344,6 +431,8 @@ func (b *Builder) SyncBlocks(
 					logging.L.Info().Msg("Indexer is up to date with blockchain tip")
 				}
 			case <-tickerReports:
+				metrics.PullBacklog.Set(float64(len(b.newBlockChan)))
+				metrics.WriterBacklog.Set(float64(len(b.writerChan)))
 				logging.L.Trace().
 					Int("backlog_chan_pull", len(b.newBlockChan)).
 					Int("backlog_chan_db_writer", len(b.writerChan)).
@@ -355,7 +444,9 @@ func (b *Builder) SyncBlocks(
 	for {
 		select {
 		case <-ctx.Done():
-			// check here as well
+			if err := b.stopAtBlockBoundary(doneChan, errChan); err != nil {
+				return err
+			}
 			return ctx.Err()
 		case err := <-errChan:
 			logging.L.Err(err).Msg("there was an error pulling blocks")
@@ -367,6 +458,33 @@ func (b *Builder) SyncBlocks(
 	}
 }
 
+// stopAtBlockBoundary waits for the writer to finish the block it is applying on cancellation
+// and flushes, so the store only ever holds whole blocks.
+// Blocks still queued for the writer are dropped and pulled again on the next start.
+func (b *Builder) stopAtBlockBoundary(writerDone <-chan struct{}, errChan <-chan error) error {
+	for {
+		select {
+		case <-writerDone:
+			err := b.store.FlushBatch(true)
+			if err != nil {
+				logging.L.Err(err).Msg("failed flushing batch on shutdown")
+				return err
+			}
+			logging.L.Info().Msg("indexer stopped")
+			return nil
+		case err := <-errChan:
+			// pullers and the writer return after reporting, don't leave them blocked
+			logging.L.Debug().Err(err).Msg("error while stopping the indexer")
+		}
+	}
+}
+
+// reportSyncState updates metrics and readiness, coreTip 0 keeps the last known tip
+func reportSyncState(indexedHeight uint32, coreTip int64) {
+	metrics.SetSyncState(indexedHeight, coreTip)
+	health.SetSyncState(indexedHeight, coreTip)
+}
+
 func (b *Builder) pullBlockToChan(height int64) error {
 	block, err := b.pullBlock(height)
 	if err != nil {
@@ -378,6 +496,9 @@ func (b *Builder) pullBlockToChan(height int64) error {
 }
 
 func (b *Builder) pullBlock(height int64) (*Block, error) {
+	start := time.Now()
+	defer func() { metrics.PullDuration.Observe(time.Since(start).Seconds()) }()
+
 	blockhash, err := getBlockHashByHeight(height)
 	if err != nil {
 		logging.L.Err(err).Int64("height", height).Msg("failed to pull blockhash")
@@ -460,7 +581,21 @@ func (b *Builder) handleBlock(ctx context.Context, block *Block) error {
 	return nil
 }
 
+// handleTx builds the database.Tx for tx and counts failed tweak computations
 func handleTx(tx *Transaction) *database.Tx {
+	dbTx, err := buildTx(tx)
+	if err != nil {
+		logging.L.Warn().Err(err).
+			Str("txid", tx.txid.String()).
+			Msg("failed to compute tweak")
+		metrics.TweakFailures.Inc()
+	}
+	return dbTx
+}
+
+// buildTx extracts the silent payment data of tx. If the tweak computation fails
+// the tx is still returned without a tweak, next to the error.
+func buildTx(tx *Transaction) (*database.Tx, error) {
 	var dbOuts []*database.Output
 
 	// we only want outputs where we know they can be Silent Payments.
@@ -477,14 +612,11 @@ func handleTx(tx *Transaction) *database.Tx {
 		}
 	}
 
-	var err error
+	var tweakErr error
 	var tweak *[33]byte
 	if len(dbOuts) > 0 {
-		tweak, err = ComputeTweakPerTx(tx)
-		if err != nil {
-			logging.L.Warn().Err(err).
-				Str("txid", tx.txid.String()).
-				Msg("failed to compute tweak")
+		tweak, tweakErr = ComputeTweakPerTx(tx)
+		if tweakErr != nil {
 			tweak = nil
 		}
 	}
@@ -512,8 +644,8 @@ func handleTx(tx *Transaction) *database.Tx {
 			Outs:  dbOuts,
 			Ins:   dbIns,
 		}
-		return &dbTx
+		return &dbTx, tweakErr
 	}
 
-	return nil
+	return nil, tweakErr
 }
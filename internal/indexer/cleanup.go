@@ -3,15 +3,18 @@ package indexer
 import (
 	"context"
 	"errors"
+	"slices"
+	"sync"
 
-	"github.com/cockroachdb/pebble"
 	"github.com/setavenger/blindbit-lib/logging"
 	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
 )
 
 // DBIntegrityCheck iterates from config syncstartheight to tip and
-// checks for missing blocks to pull those and patch missing block data
-func (b *Builder) DBIntegrityCheck(ctx context.Context) error {
+// checks for missing blocks to pull those and patch missing block data.
+// If deep is set the secondary indexes of every block are checked and repaired as well.
+func (b *Builder) DBIntegrityCheck(ctx context.Context, deep bool) error {
 	_, syncTip, err := b.store.GetChainTip()
 	if err != nil {
 		logging.L.Err(err).Msg("failed to get sync tip from db")
@@ -49,35 +52,13 @@ func (b *Builder) DBIntegrityCheck(ctx context.Context) error {
 		}
 	}
 
-	// Check for missing static indexes
-	// logging.L.Info().Msg("Checking static index integrity...")
-	// err = b.checkStaticIndexIntegrity(startHeight, endHeight)
-	// if err != nil {
-	// 	return err
-	// }
-
-	// for height := config.SyncStartHeight; height <= syncTip; height++ {
-	// 	// todo: turn into more efficient sync blocks (concurrent)
-	// 	// if somehow big gaps become apparent
-	// 	blockhash, err := b.store.GetBlockHashByHeight(height)
-	// 	if err != nil && !errors.Is(err, pebble.ErrNotFound) {
-	// 		return err
-	// 	}
-	//
-	// 	if blockhash != nil {
-	// 		// block is already processed
-	// 		continue
-	// 	}
-	//
-	// 	logging.L.Info().
-	// 		Uint32("height", height).
-	// 		Msg("block is missing, trying to process block")
-	// err = b.singleProcessBlock(b.ctx, height)
-	// 	if err != nil {
-	// 		logging.L.Err(err).Uint32("height", height).Msg("failed to process block")
-	// 		return err
-	// 	}
-	// }
+	if deep {
+		logging.L.Info().Msg("Checking static index integrity...")
+		err = b.checkStaticIndexIntegrity(ctx, startHeight, endHeight)
+		if err != nil {
+			return err
+		}
+	}
 
 	err = b.store.FlushBatch(true)
 	if err != nil {
@@ -93,42 +74,230 @@ type HeightRange struct {
 	End   uint32
 }
 
+// identifyGapRanges returns all ranges between startHeight and endHeight (both inclusive)
+// which are not in the chain index. The chain index is read with a single ascending scan.
 func (b *Builder) identifyGapRanges(
 	startHeight, endHeight uint32,
 ) ([]HeightRange, error) {
-	var allRanges []HeightRange
-	var currentRange *HeightRange
-
-	for height := startHeight; height <= endHeight; height++ {
-		// todo: turn into more efficient sync blocks (concurrent)
-		// if somehow big gaps become apparent
-		blockhash, err := b.store.GetBlockHashByHeight(height)
-		if err != nil && !errors.Is(err, pebble.ErrNotFound) {
-			return nil, err
+	gaps := newGapFinder(startHeight, endHeight)
+	err := b.store.ForEachHeight(startHeight, endHeight, func(height uint32) error {
+		gaps.observe(height)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return gaps.finish(), nil
+}
+
+// gapFinder collects missing ranges from a stream of ascending heights
+type gapFinder struct {
+	// next is the lowest height not yet seen
+	next uint32
+	end  uint32
+	// done is set once the range is exhausted, next can't move past math.MaxUint32
+	done   bool
+	ranges []HeightRange
+}
+
+func newGapFinder(startHeight, endHeight uint32) *gapFinder {
+	return &gapFinder{
+		next: startHeight,
+		end:  endHeight,
+		done: endHeight < startHeight,
+	}
+}
+
+// observe marks height as present. Heights have to be passed in ascending order,
+// heights outside the range or already seen are ignored.
+func (g *gapFinder) observe(height uint32) {
+	if g.done || height < g.next || height > g.end {
+		return
+	}
+	if height > g.next {
+		g.ranges = append(g.ranges, HeightRange{Start: g.next, End: height - 1})
+	}
+	if height == g.end {
+		g.done = true
+		return
+	}
+	g.next = height + 1
+}
+
+// finish returns all gaps including a trailing gap up to the end height
+func (g *gapFinder) finish() []HeightRange {
+	if !g.done {
+		g.ranges = append(g.ranges, HeightRange{Start: g.next, End: g.end})
+		g.done = true
+	}
+	return g.ranges
+}
+
+// checkStaticIndexIntegrity checks the secondary indexes of every block in the range.
+// Missing hash -> height entries are rewritten from the height -> hash entries and missing
+// compute index rows rebuilt from the stored tweaks and outputs,
+// everything else requires the block to be pulled again.
+func (b *Builder) checkStaticIndexIntegrity(
+	ctx context.Context,
+	startHeight, endHeight uint32,
+) error {
+	store, ok := b.store.(database.IntegrityStore)
+	if !ok {
+		return errors.New("the store can't check its secondary indexes")
+	}
+
+	repairHeights, rebuildHeights, repullHeights, err := b.findInconsistentHeights(ctx, store, startHeight, endHeight)
+	if err != nil {
+		logging.L.Err(err).Msg("failed to check static indexes")
+		return err
+	}
+
+	if len(repairHeights) == 0 && len(rebuildHeights) == 0 && len(repullHeights) == 0 {
+		logging.L.Info().
+			Uint32("start_height", startHeight).
+			Uint32("end_height", endHeight).
+			Msg("no inconsistent static indexes identified for block range")
+		return nil
+	}
+
+	rebuildRanges := HeightsToRanges(rebuildHeights)
+	repullRanges := HeightsToRanges(repullHeights)
+	logging.L.Info().
+		Any("repair_ci_block_heights", repairHeights).
+		Any("rebuild_ranges", rebuildRanges).
+		Any("repull_ranges", repullRanges).
+		Msg("identified inconsistent static indexes")
+
+	for _, height := range repairHeights {
+		logging.L.Debug().Msgf("repairing chain index %d", height)
+		if err = store.RepairCIBlock(height); err != nil {
+			logging.L.Err(err).Uint32("height", height).Msg("failed to repair chain index")
+			return err
 		}
+	}
 
-		if blockhash != nil {
-			// block is already processed
-			if currentRange != nil {
-				if currentRange.End == 0 {
-					// todo: until we can do point pulls
-					// sync range needs end and it's easier to define here
-					currentRange.End = currentRange.Start
-				}
-				allRanges = append(allRanges, *currentRange)
-				currentRange = nil
-			}
-			continue
+	for i := range rebuildRanges {
+		start, end := rebuildRanges[i].Start, rebuildRanges[i].End
+		logging.L.Debug().Msgf("rebuilding compute index %d -> %d", start, end)
+		err = store.BuildComputeIndexByRange(ctx, start, end)
+		if err != nil {
+			logging.L.Err(err).Msg("failed to rebuild compute index")
+			return err
 		}
+	}
 
-		if currentRange == nil {
-			currentRange = new(HeightRange)
-			currentRange.Start = height
-			continue
+	for i := range repullRanges {
+		start, end := repullRanges[i].Start, repullRanges[i].End
+		logging.L.Debug().Msgf("re-pulling blocks %d -> %d", start, end)
+		err = b.SyncBlocks(ctx, int64(start), int64(end))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findInconsistentHeights returns the heights which need their hash -> height entry rewritten,
+// the heights which need their compute index rebuilt and the heights which need to be pulled again,
+// all in ascending order. A height which is pulled again is in no other list.
+func (b *Builder) findInconsistentHeights(
+	ctx context.Context,
+	store database.IntegrityStore,
+	startHeight, endHeight uint32,
+) (repairHeights, rebuildHeights, repullHeights []uint32, err error) {
+	// without a worker nothing would be checked and the range reported clean
+	numWorkers := config.MaxParallelTweakComputations
+	if numWorkers <= 0 {
+		numWorkers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heightChan := make(chan uint32, 100)
+	errChan := make(chan error, numWorkers)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	go func() {
+		defer close(heightChan)
+		for height := startHeight; height <= endHeight; height++ {
+			select {
+			case <-ctx.Done():
+				return
+			case heightChan <- height:
+			}
+			// height++ would wrap at math.MaxUint32
+			if height == endHeight {
+				break
+			}
 		}
+	}()
+
+	for range numWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heightChan {
+				res, err := store.CheckBlockConsistency(height)
+				if err != nil {
+					logging.L.Err(err).Uint32("height", height).Msg("failed to check block consistency")
+					errChan <- err
+					cancel()
+					return
+				}
+				if res.OK() {
+					continue
+				}
+
+				logging.L.Warn().Any("consistency", res).Msg("inconsistent static indexes")
 
-		currentRange.End = height
+				mu.Lock()
+				if res.NeedsRepull() {
+					repullHeights = append(repullHeights, height)
+				} else {
+					if res.MissingCIBlock {
+						repairHeights = append(repairHeights, height)
+					}
+					if res.MissingComputeIndex {
+						rebuildHeights = append(rebuildHeights, height)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
-	return allRanges, nil
+	wg.Wait()
+
+	select {
+	case err = <-errChan:
+		return nil, nil, nil, err
+	default:
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	slices.Sort(repairHeights)
+	slices.Sort(rebuildHeights)
+	slices.Sort(repullHeights)
+
+	return repairHeights, rebuildHeights, repullHeights, nil
+}
+
+// HeightsToRanges merges ascending heights into continuous inclusive ranges
+func HeightsToRanges(heights []uint32) []HeightRange {
+	var ranges []HeightRange
+	for _, height := range heights {
+		if n := len(ranges); n > 0 && ranges[n-1].End+1 == height {
+			ranges[n-1].End = height
+			continue
+		}
+		ranges = append(ranges, HeightRange{Start: height, End: height})
+	}
+	return ranges
 }
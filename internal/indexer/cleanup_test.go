@@ -0,0 +1,230 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/cockroachdb/pebble"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+)
+
+func TestGapFinder(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end uint32
+		heights    []uint32
+		want       []HeightRange
+	}{
+		{
+			name:    "no gaps",
+			start:   10,
+			end:     14,
+			heights: []uint32{10, 11, 12, 13, 14},
+			want:    nil,
+		},
+		{
+			name:    "empty index",
+			start:   10,
+			end:     14,
+			heights: nil,
+			want:    []HeightRange{{Start: 10, End: 14}},
+		},
+		{
+			name:    "leading gap",
+			start:   10,
+			end:     14,
+			heights: []uint32{12, 13, 14},
+			want:    []HeightRange{{Start: 10, End: 11}},
+		},
+		{
+			name:    "trailing gap",
+			start:   10,
+			end:     14,
+			heights: []uint32{10, 11, 12},
+			want:    []HeightRange{{Start: 13, End: 14}},
+		},
+		{
+			name:    "single height trailing gap",
+			start:   10,
+			end:     14,
+			heights: []uint32{10, 11, 12, 13},
+			want:    []HeightRange{{Start: 14, End: 14}},
+		},
+		{
+			name:    "gaps in the middle",
+			start:   10,
+			end:     20,
+			heights: []uint32{10, 12, 13, 17, 20},
+			want: []HeightRange{
+				{Start: 11, End: 11},
+				{Start: 14, End: 16},
+				{Start: 18, End: 19},
+			},
+		},
+		{
+			name:    "leading and trailing gap",
+			start:   10,
+			end:     20,
+			heights: []uint32{15},
+			want: []HeightRange{
+				{Start: 10, End: 14},
+				{Start: 16, End: 20},
+			},
+		},
+		{
+			name:    "heights outside the range are ignored",
+			start:   10,
+			end:     14,
+			heights: []uint32{5, 9, 10, 11, 14, 15},
+			want:    []HeightRange{{Start: 12, End: 13}},
+		},
+		{
+			name:    "duplicate heights are ignored",
+			start:   10,
+			end:     14,
+			heights: []uint32{10, 10, 11, 14, 14},
+			want:    []HeightRange{{Start: 12, End: 13}},
+		},
+		{
+			name:    "single height range present",
+			start:   10,
+			end:     10,
+			heights: []uint32{10},
+			want:    nil,
+		},
+		{
+			name:    "single height range missing",
+			start:   10,
+			end:     10,
+			heights: nil,
+			want:    []HeightRange{{Start: 10, End: 10}},
+		},
+		{
+			name:    "end before start",
+			start:   14,
+			end:     10,
+			heights: []uint32{10, 11},
+			want:    nil,
+		},
+		{
+			name:    "range ends at max height",
+			start:   math.MaxUint32 - 2,
+			end:     math.MaxUint32,
+			heights: []uint32{math.MaxUint32 - 2, math.MaxUint32},
+			want:    []HeightRange{{Start: math.MaxUint32 - 1, End: math.MaxUint32 - 1}},
+		},
+		{
+			name:    "range starts at zero",
+			start:   0,
+			end:     3,
+			heights: []uint32{2},
+			want: []HeightRange{
+				{Start: 0, End: 1},
+				{Start: 3, End: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gaps := newGapFinder(tt.start, tt.end)
+			for _, height := range tt.heights {
+				gaps.observe(height)
+			}
+			got := gaps.finish()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeightsToRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		heights []uint32
+		want    []HeightRange
+	}{
+		{name: "empty", heights: nil, want: nil},
+		{name: "single", heights: []uint32{7}, want: []HeightRange{{Start: 7, End: 7}}},
+		{
+			name:    "merged and split",
+			heights: []uint32{1, 2, 3, 5, 7, 8},
+			want: []HeightRange{
+				{Start: 1, End: 3},
+				{Start: 5, End: 5},
+				{Start: 7, End: 8},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HeightsToRanges(tt.heights)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindInconsistentHeights(t *testing.T) {
+	saved := config.MaxParallelTweakComputations
+	t.Cleanup(func() { config.MaxParallelTweakComputations = saved })
+
+	txid := bytes.Repeat([]byte{0xa1}, 32)
+	tweak := [33]byte{0x02, 0x11}
+	var blocks []*database.DBBlock
+	for height := uint32(1); height <= 4; height++ {
+		blocks = append(blocks, &database.DBBlock{
+			Height: height,
+			Hash:   &chainhash.Hash{byte(height)},
+			Txs: []*database.Tx{{
+				Txid:  txid,
+				Tweak: &tweak,
+				Outs:  []*database.Output{{Txid: txid, Vout: 0, Amount: 1_000, Pubkey: bytes.Repeat([]byte{0x0a}, 32)}},
+			}},
+		})
+	}
+	store := testhelpers.NewStore(t, blocks...)
+	b := NewBuilder(context.Background(), store)
+	for _, key := range [][]byte{
+		dbpebble.KeyCIBlock((&chainhash.Hash{0x02})[:]),
+		dbpebble.KeyComputeIndex(3, txid),
+		dbpebble.KeySpentOutputsShort((&chainhash.Hash{0x04})[:]),
+	} {
+		if err := store.DB.Delete(key, pebble.Sync); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a worker count of 0 still checks every height
+	for _, workers := range []int{0, 1, 4} {
+		config.MaxParallelTweakComputations = workers
+		repair, rebuild, repull, err := b.findInconsistentHeights(context.Background(), store, 1, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(repair, []uint32{2}) || !slices.Equal(rebuild, []uint32{3}) || !slices.Equal(repull, []uint32{4, 5}) {
+			t.Errorf("%d workers: repair %v, rebuild %v, repull %v", workers, repair, rebuild, repull)
+		}
+	}
+
+	// the range can end at the highest height without wrapping around
+	_, _, repull, err := b.findInconsistentHeights(context.Background(), store, math.MaxUint32-1, math.MaxUint32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(repull, []uint32{math.MaxUint32 - 1, math.MaxUint32}) {
+		t.Errorf("range up to math.MaxUint32: repull %v", repull)
+	}
+}
@@ -0,0 +1,271 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+// Mismatch kinds reported by VerifyBlock
+const (
+	MismatchBlockMissing           = "block_missing"
+	MismatchBlockhash              = "blockhash_mismatch"
+	MismatchMissingTweak           = "missing_tweak"
+	MismatchUnexpectedTweak        = "unexpected_tweak"
+	MismatchTweak                  = "tweak_mismatch"
+	MismatchMissingComputeIndex    = "missing_compute_index"
+	MismatchUnexpectedComputeIndex = "unexpected_compute_index"
+	MismatchOutputs                = "outputs_mismatch"
+)
+
+// Mismatch is a difference between the stored index and the data recomputed from the block.
+// Hashes and txids are hex in display order, Stored and Computed hold the differing values.
+type Mismatch struct {
+	Height    uint32 `json:"height"`
+	Blockhash string `json:"blockhash"`
+	Txid      string `json:"txid,omitempty"`
+	Kind      string `json:"kind"`
+	Stored    string `json:"stored,omitempty"`
+	Computed  string `json:"computed,omitempty"`
+}
+
+// VerifySummary is the result of VerifyRange
+type VerifySummary struct {
+	Checked    int
+	Skipped    int
+	Mismatches int
+	// BadHeights heights with at least one mismatch
+	BadHeights []uint32
+}
+
+// VerifyRange verifies the blocks from startHeight to endHeight (both inclusive).
+// sample is the share of blocks which get checked in percent, 100 checks every block.
+// Every mismatch is written to w as one JSON object per line.
+func (b *Builder) VerifyRange(
+	ctx context.Context,
+	startHeight, endHeight uint32,
+	sample float64,
+	w io.Writer,
+) (*VerifySummary, error) {
+	logging.L.Info().
+		Uint32("start_height", startHeight).
+		Uint32("end_height", endHeight).
+		Float64("sample", sample).
+		Msg("verifying tweaks")
+
+	// without a worker nothing would be checked and the range reported clean
+	numWorkers := int(config.MaxParallelRequests)
+	if numWorkers <= 0 {
+		numWorkers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heightChan := make(chan uint32, 100)
+	errChan := make(chan error, numWorkers)
+
+	summary := new(VerifySummary)
+	enc := json.NewEncoder(w)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	go func() {
+		defer close(heightChan)
+		for height := startHeight; height <= endHeight; height++ {
+			if sample < 100 && rand.Float64()*100 >= sample {
+				mu.Lock()
+				summary.Skipped++
+				mu.Unlock()
+			} else {
+				select {
+				case <-ctx.Done():
+					return
+				case heightChan <- height:
+				}
+			}
+			// height++ would wrap at math.MaxUint32
+			if height == endHeight {
+				break
+			}
+		}
+	}()
+
+	for range numWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heightChan {
+				mismatches, err := b.VerifyBlock(height)
+				if err != nil {
+					logging.L.Err(err).Uint32("height", height).Msg("failed to verify block")
+					errChan <- err
+					cancel()
+					return
+				}
+
+				mu.Lock()
+				summary.Checked++
+				if len(mismatches) > 0 {
+					summary.Mismatches += len(mismatches)
+					summary.BadHeights = append(summary.BadHeights, height)
+				}
+				for i := range mismatches {
+					if err = enc.Encode(mismatches[i]); err != nil {
+						break
+					}
+				}
+				checked := summary.Checked
+				mu.Unlock()
+
+				if err != nil {
+					logging.L.Err(err).Msg("failed to write mismatch")
+					errChan <- err
+					cancel()
+					return
+				}
+
+				if checked%1_000 == 0 {
+					logging.L.Info().Int("checked", checked).Msg("verifying tweaks")
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(summary.BadHeights)
+
+	return summary, nil
+}
+
+// VerifyBlock pulls the block at height from Core, recomputes the tweaks
+// and compares them with the stored tweaks and compute index
+func (b *Builder) VerifyBlock(height uint32) ([]Mismatch, error) {
+	storedHash, err := b.store.GetBlockHashByHeight(height)
+	if err != nil {
+		logging.L.Err(err).Uint32("height", height).Msg("failed to get blockhash from db")
+		return nil, err
+	}
+	if storedHash == nil {
+		return []Mismatch{{Height: height, Kind: MismatchBlockMissing}}, nil
+	}
+
+	block, err := b.pullBlock(int64(height))
+	if err != nil {
+		return nil, err
+	}
+
+	blockhash := block.Hash.String()
+	if !bytes.Equal(storedHash, block.Hash[:]) {
+		return []Mismatch{{
+			Height:    height,
+			Blockhash: blockhash,
+			Kind:      MismatchBlockhash,
+			Stored:    hex.EncodeToString(utils.ReverseBytesCopy(storedHash)),
+			Computed:  blockhash,
+		}}, nil
+	}
+
+	return b.compareBlock(height, block)
+}
+
+// compareBlock recomputes the tweaks of block and compares them with the stored ones
+func (b *Builder) compareBlock(height uint32, block *Block) ([]Mismatch, error) {
+	blockhash := block.Hash.String()
+	storedTweaks, err := b.store.TweaksForBlockAll(block.Hash[:])
+	if err != nil {
+		logging.L.Err(err).Uint32("height", height).Msg("failed to fetch tweaks")
+		return nil, err
+	}
+	tweaks := make(map[[32]byte][33]byte, len(storedTweaks))
+	for _, row := range storedTweaks {
+		tweaks[row.Txid] = row.Tweak
+	}
+
+	computeIndex, err := b.store.FetchComputeIndex(height)
+	if err != nil {
+		logging.L.Err(err).Uint32("height", height).Msg("failed to fetch compute index")
+		return nil, err
+	}
+	// compute index txids come in display order
+	outputsShort := make(map[[32]byte][]byte, len(computeIndex))
+	for _, item := range computeIndex {
+		outputsShort[[32]byte(utils.ReverseBytesCopy(item.Txid))] = item.OutputsShort
+	}
+
+	var mismatches []Mismatch
+	add := func(txid [32]byte, kind string, stored, computed []byte) {
+		mismatches = append(mismatches, Mismatch{
+			Height:    height,
+			Blockhash: blockhash,
+			Txid:      hex.EncodeToString(utils.ReverseBytesCopy(txid[:])),
+			Kind:      kind,
+			Stored:    hex.EncodeToString(stored),
+			Computed:  hex.EncodeToString(computed),
+		})
+	}
+
+	for _, tx := range block.txs {
+		// a failed tweak is stored as no tweak, verifying does not count it as a failure again
+		dbTx, err := buildTx(tx)
+		if err != nil {
+			logging.L.Debug().Err(err).Str("txid", tx.txid.String()).Msg("failed to compute tweak")
+		}
+		txid := *tx.txid
+
+		if dbTx == nil || dbTx.Tweak == nil {
+			continue
+		}
+
+		storedTweak, ok := tweaks[txid]
+		switch {
+		case !ok:
+			add(txid, MismatchMissingTweak, nil, dbTx.Tweak[:])
+		case storedTweak != *dbTx.Tweak:
+			add(txid, MismatchTweak, storedTweak[:], dbTx.Tweak[:])
+		}
+		delete(tweaks, txid)
+
+		computedShort := make([]byte, 0, len(dbTx.Outs)*8)
+		for _, out := range dbTx.Outs {
+			computedShort = append(computedShort, out.Pubkey[:8]...)
+		}
+		storedShort, ok := outputsShort[txid]
+		switch {
+		case !ok:
+			add(txid, MismatchMissingComputeIndex, nil, computedShort)
+		case !bytes.Equal(storedShort, computedShort):
+			add(txid, MismatchOutputs, storedShort, computedShort)
+		}
+		delete(outputsShort, txid)
+	}
+
+	// everything left is stored but has no tweak when recomputed
+	for txid, tweak := range tweaks {
+		add(txid, MismatchUnexpectedTweak, tweak[:], nil)
+	}
+	for txid, short := range outputsShort {
+		add(txid, MismatchUnexpectedComputeIndex, short, nil)
+	}
+
+	return mismatches, nil
+}
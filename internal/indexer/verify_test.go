@@ -0,0 +1,140 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/setavenger/go-bip352"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/metrics"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+)
+
+func TestVerifyBlock(t *testing.T) {
+	store := testhelpers.NewStore(t)
+	b := NewBuilder(context.Background(), store)
+
+	p2tr := func(key byte) *wire.TxOut {
+		return wire.NewTxOut(1_000, append([]byte{0x51, 0x20}, bytes.Repeat([]byte{key}, 32)...))
+	}
+	in := func(prevTxid byte, witness [][]byte, prevOut *wire.TxOut) *Vin {
+		txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{prevTxid}, 0), nil, witness)
+		return &Vin{txIn: txIn, prevOut: prevOut}
+	}
+	sig := bytes.Repeat([]byte{0x30}, 64)
+	inputKey := bip352.PubKeyFromSecKey(&[32]byte{0x1e, 0x01})
+
+	// the input key of the second tx is not a valid point, its tweak computation fails
+	badKey := append([]byte{0x02}, bytes.Repeat([]byte{0xff}, 32)...)
+	p2wpkh := &wire.TxOut{Value: 2_000, PkScript: append([]byte{0x00, 0x14}, make([]byte, 20)...)}
+
+	// txids are unique per block, the index keys tweaks by txid
+	block := func(height uint32, hash byte) (*Block, *database.DBBlock) {
+		payment := &Transaction{
+			txid: &chainhash.Hash{hash, 0xa1},
+			ins: []*Vin{in(0x01, [][]byte{sig}, &wire.TxOut{
+				Value: 2_000, PkScript: append([]byte{0x51, 0x20}, inputKey[1:]...),
+			})},
+			outs: []*wire.TxOut{p2tr(0x0a), p2tr(0x1a)},
+		}
+		failing := &Transaction{
+			txid: &chainhash.Hash{hash, 0xb2},
+			ins:  []*Vin{in(0x02, [][]byte{sig, badKey}, p2wpkh)},
+			outs: []*wire.TxOut{p2tr(0x0b)},
+		}
+		block := &Block{Height: int64(height), Hash: &chainhash.Hash{hash}, txs: []*Transaction{payment, failing}}
+		dbBlock := &database.DBBlock{Height: height, Hash: block.Hash}
+		for _, tx := range block.txs {
+			dbBlock.Txs = append(dbBlock.Txs, handleTx(tx))
+		}
+		return block, dbBlock
+	}
+
+	failures := testutil.ToFloat64(metrics.TweakFailures)
+	good, dbGood := block(1, 0x01)
+	if dbGood.Txs[0].Tweak == nil || dbGood.Txs[1].Tweak != nil {
+		t.Fatalf("fixture: tweaks %v, %v", dbGood.Txs[0].Tweak, dbGood.Txs[1].Tweak)
+	}
+	if testutil.ToFloat64(metrics.TweakFailures) != failures+1 {
+		t.Fatal("fixture: indexing did not count the failed tweak")
+	}
+	if _, err := b.writeBlock(dbGood); err != nil {
+		t.Fatal(err)
+	}
+
+	// the stored tweak and first output of the payment differ from the block
+	bad, dbBad := block(2, 0x02)
+	tweak := *dbBad.Txs[0].Tweak
+	tweak[1] ^= 0xff
+	dbBad.Txs[0] = &database.Tx{
+		Txid:  dbBad.Txs[0].Txid,
+		Tweak: &tweak,
+		Outs:  []*database.Output{{Txid: dbBad.Txs[0].Txid, Vout: 1, Pubkey: bytes.Repeat([]byte{0x1a}, 32)}},
+	}
+	if _, err := b.writeBlock(dbBad); err != nil {
+		t.Fatal(err)
+	}
+
+	failures = testutil.ToFloat64(metrics.TweakFailures)
+
+	mismatches, err := b.compareBlock(1, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("matching block: %+v", mismatches)
+	}
+
+	mismatches, err = b.compareBlock(2, bad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]Mismatch)
+	for _, m := range mismatches {
+		kinds[m.Kind] = m
+	}
+	if len(mismatches) != 2 || kinds[MismatchTweak].Height != 2 || kinds[MismatchOutputs].Height != 2 {
+		t.Fatalf("mismatching block: %+v", mismatches)
+	}
+	if m := kinds[MismatchTweak]; m.Txid != bad.txs[0].txid.String() || m.Blockhash != bad.Hash.String() {
+		t.Errorf("tweak mismatch reported as %+v", m)
+	}
+
+	if got := testutil.ToFloat64(metrics.TweakFailures); got != failures {
+		t.Errorf("verifying counted %v tweak failures", got-failures)
+	}
+}
+
+// the range can end at the highest height without wrapping around, sampled out heights included,
+// and is checked even without configured workers
+func TestVerifyRangeLastHeight(t *testing.T) {
+	saved := config.MaxParallelRequests
+	t.Cleanup(func() { config.MaxParallelRequests = saved })
+	// a worker count of 0 still checks every height
+	config.MaxParallelRequests = 0
+
+	store := testhelpers.NewStore(t)
+	b := NewBuilder(context.Background(), store)
+
+	for _, sample := range []float64{0, 100} {
+		var out bytes.Buffer
+		summary, err := b.VerifyRange(context.Background(), math.MaxUint32-1, math.MaxUint32, sample, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Checked+summary.Skipped != 2 {
+			t.Errorf("sample %v: %+v", sample, summary)
+		}
+		if sample == 100 && !slices.Equal(summary.BadHeights, []uint32{math.MaxUint32 - 1, math.MaxUint32}) {
+			t.Errorf("missing blocks not reported: %+v", summary)
+		}
+	}
+}
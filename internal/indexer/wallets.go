@@ -0,0 +1,169 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+)
+
+const (
+	// walletScanInterval wallets behind the tip, e.g. right after registering, are scanned further this often
+	walletScanInterval = 3 * time.Second
+	// walletScanChunk heights scanned per wallet at once, so neither a reorg nor other wallets wait for a long backfill
+	walletScanChunk = 144
+	// walletReorgWindow how far a wallet is set back if its last scanned block was replaced without a rewind
+	walletReorgWindow = 144
+)
+
+// runWalletScanner scans the wallets after every new block and every walletScanInterval until ctx is done.
+// It runs next to the writer of the continuous sync, only a block replacing another waits for the chunk in progress.
+func (b *Builder) runWalletScanner(ctx context.Context) {
+	if !config.ScanRPC {
+		return
+	}
+	ticker := time.NewTicker(walletScanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.walletWake:
+		}
+		b.scanWallets(ctx)
+	}
+}
+
+// scanWallets scans the registered wallets up to the indexed tip, at most walletScanChunk heights each.
+// Failures are logged and retried on the next call, they don't stop the indexer.
+func (b *Builder) scanWallets(ctx context.Context) {
+	if !config.ScanRPC {
+		return
+	}
+	store, ok := b.store.(database.WalletStore)
+	if !ok {
+		return
+	}
+
+	wallets, err := store.FetchWallets()
+	if err != nil {
+		logging.L.Err(err).Msg("failed to load wallets")
+		return
+	}
+
+	for _, w := range wallets {
+		if err = b.scanWallet(ctx, store, w.ID); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logging.L.Err(err).Hex("wallet_id", w.ID[:]).Msg("failed scanning wallet")
+		}
+	}
+}
+
+// scanWallet scans the next chunk of the wallet.
+// The wallet and the tip are read under reorgMu, a reorg waits until the chunk is stored and rewinds it then.
+func (b *Builder) scanWallet(ctx context.Context, store database.WalletStore, id [32]byte) error {
+	b.reorgMu.RLock()
+	defer b.reorgMu.RUnlock()
+
+	w, err := store.FetchWallet(id)
+	if errors.Is(err, database.ErrNotFound) {
+		// unregistered in the meantime
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_, tip, err := b.store.GetChainTip()
+	if err != nil {
+		return err
+	}
+	if w.ScannedHeight >= tip {
+		return nil
+	}
+
+	if w.ScannedHash != nil {
+		hash, err := b.store.GetBlockHashByHeight(w.ScannedHeight)
+		if err != nil {
+			return err
+		}
+		// replaced outside of the continuous sync, e.g. by syncing the range again
+		if !bytes.Equal(hash, w.ScannedHash) {
+			from := uint32(1)
+			if w.ScannedHeight > walletReorgWindow {
+				from = w.ScannedHeight - walletReorgWindow + 1
+			}
+			logging.L.Warn().
+				Hex("wallet_id", w.ID[:]).
+				Uint32("height", w.ScannedHeight).
+				Uint32("rescan_from", from).
+				Msg("last scanned block of wallet was replaced")
+			return store.RewindWallet(w.ID, from)
+		}
+	}
+
+	keys, err := query.NewScanKeys(w.ScanSecretKey[:], w.SpendPubKey[:], w.Labels)
+	if err != nil {
+		return err
+	}
+
+	start := w.ScannedHeight + 1
+	end := min(tip, w.ScannedHeight+walletScanChunk)
+	endHash, err := b.store.GetBlockHashByHeight(end)
+	if err != nil {
+		return err
+	}
+
+	scan := &database.WalletScan{
+		ID:            w.ID,
+		Revision:      w.Revision,
+		FromHeight:    w.ScannedHeight,
+		ScannedHeight: end,
+		ScannedHash:   endHash,
+	}
+	err = b.query.ScanRange(ctx, keys, start, end, func(matches []query.ScanMatch, _ uint32) error {
+		for i := range matches {
+			scan.Outputs = append(scan.Outputs, walletOutput(&matches[i]))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	stored, err := store.AdvanceWallet(scan)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		logging.L.Debug().Hex("wallet_id", w.ID[:]).Msg("wallet changed during the scan, scanning again")
+		return nil
+	}
+	logging.L.Debug().
+		Hex("wallet_id", w.ID[:]).
+		Uint32("scanned_height", end).
+		Msg("wallet scanned")
+	return nil
+}
+
+// walletOutput converts back to internal byte order
+func walletOutput(m *query.ScanMatch) *database.WalletOutput {
+	return &database.WalletOutput{
+		Height:      m.Block.Height,
+		Blockhash:   utils.ReverseBytesCopy(m.Block.Hash),
+		Txid:        [32]byte(utils.ReverseBytesCopy(m.UTXO.Txid[:])),
+		Vout:        m.UTXO.Vout,
+		Amount:      m.UTXO.Amount,
+		Pubkey:      m.UTXO.Pubkey,
+		Tweak:       m.Tweak,
+		SecKeyTweak: m.SecKeyTweak,
+		Label:       m.Label,
+	}
+}
@@ -0,0 +1,153 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/setavenger/go-bip352"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+)
+
+func TestScanWallets(t *testing.T) {
+	config.ScanRPC = true
+	t.Cleanup(func() { config.ScanRPC = false })
+
+	store := testhelpers.NewStore(t)
+	b := NewBuilder(context.Background(), store)
+	ctx := context.Background()
+
+	scanSecretKey := [32]byte{0x5c, 0x01}
+	spendPubKey := *bip352.PubKeyFromSecKey(&[32]byte{0x5e, 0x02})
+	tweak := *bip352.PubKeyFromSecKey(&[32]byte{0x7a, 0x03})
+	publicComponent := tweak
+	sharedSecret, err := bip352.CreateSharedSecret(&publicComponent, &scanSecretKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _, err := bip352.CreateOutputPubKeyTweak(sharedSecret, &spendPubKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txid := bytes.Repeat([]byte{0xd4}, 32)
+	payment := &database.Tx{
+		Txid:  txid,
+		Tweak: &tweak,
+		Outs:  []*database.Output{{Txid: txid, Vout: 0, Amount: 1_000, Pubkey: output[:]}},
+	}
+	otherTxid := bytes.Repeat([]byte{0xe5}, 32)
+	other := &database.Tx{
+		Txid:  otherTxid,
+		Tweak: &[33]byte{0x02, 0x11},
+		Outs:  []*database.Output{{Txid: otherTxid, Vout: 0, Amount: 2_000, Pubkey: bytes.Repeat([]byte{0x0f}, 32)}},
+	}
+
+	// like the continuous sync writer, rewinding wallets on a replacement
+	applyBlock := func(block *database.DBBlock) {
+		t.Helper()
+		if _, err := b.writeBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := query.New(store)
+	wallet, err := q.RegisterWallet(scanSecretKey[:], spendPubKey[:], []uint32{0}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := func(stage string, scannedHeight uint32, includeSpent bool, heights ...uint32) *query.WalletOutputs {
+		t.Helper()
+		b.scanWallets(ctx)
+		outputs, err := q.WalletOutputs(wallet.ID, includeSpent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outputs.Wallet.ScannedHeight != scannedHeight {
+			t.Errorf("%s: scanned height %d, want %d", stage, outputs.Wallet.ScannedHeight, scannedHeight)
+		}
+		if len(outputs.Outputs) != len(heights) {
+			t.Fatalf("%s: %d outputs, want %d", stage, len(outputs.Outputs), len(heights))
+		}
+		for i, height := range heights {
+			if got := outputs.Outputs[i].Block.Height; got != height {
+				t.Errorf("%s: output %d at height %d, want %d", stage, i, got, height)
+			}
+		}
+		return outputs
+	}
+
+	// below the birth height the payment is not picked up
+	applyBlock(&database.DBBlock{Height: 1, Hash: &chainhash.Hash{0x01}, Txs: []*database.Tx{payment}})
+	expect("before birth", 1, false)
+
+	applyBlock(&database.DBBlock{Height: 2, Hash: &chainhash.Hash{0x02}, Txs: []*database.Tx{payment}})
+	found := expect("new block", 2, false, 2)
+	if found.Outputs[0].UTXO.Amount != 1_000 || found.Outputs[0].Spent != nil {
+		t.Errorf("unexpected output %+v", found.Outputs[0])
+	}
+
+	// a reorg replaces block 2 with one without the payment
+	applyBlock(&database.DBBlock{Height: 2, Hash: &chainhash.Hash{0x22}, Txs: []*database.Tx{other}})
+	expect("reorg", 2, false)
+
+	// the payment confirms again and is spent in the next block
+	applyBlock(&database.DBBlock{Height: 3, Hash: &chainhash.Hash{0x03}, Txs: []*database.Tx{payment}})
+	spend := &database.Tx{
+		Txid: bytes.Repeat([]byte{0xf6}, 32),
+		Ins: []*database.In{{
+			SpendTxid: bytes.Repeat([]byte{0xf6}, 32), PrevTxid: txid, PrevVout: 0, Pubkey: output[:],
+		}},
+	}
+	applyBlock(&database.DBBlock{Height: 4, Hash: &chainhash.Hash{0x04}, Txs: []*database.Tx{spend}})
+	expect("spent", 4, false)
+	spent := expect("spent included", 4, true, 3)
+	if s := spent.Outputs[0].Spent; s == nil || s.Height != 4 {
+		t.Errorf("spend not reported: %+v", s)
+	}
+
+	// registrations survive a restart of the builder
+	b = NewBuilder(context.Background(), store)
+	applyBlock(&database.DBBlock{Height: 5, Hash: &chainhash.Hash{0x05}, Txs: []*database.Tx{other}})
+	expect("restart", 5, true, 3)
+}
+
+// a wallet scan in progress holds up a block replacing another, but not a new block
+func TestWriteBlockWaitsForWalletScan(t *testing.T) {
+	store := testhelpers.NewStore(t)
+	b := NewBuilder(context.Background(), store)
+
+	if _, err := b.writeBlock(&database.DBBlock{Height: 1, Hash: &chainhash.Hash{0x01}}); err != nil {
+		t.Fatal(err)
+	}
+
+	b.reorgMu.RLock()
+	if _, err := b.writeBlock(&database.DBBlock{Height: 2, Hash: &chainhash.Hash{0x02}}); err != nil {
+		t.Fatal(err)
+	}
+
+	replaced := make(chan []byte)
+	go func() {
+		replacedHash, err := b.writeBlock(&database.DBBlock{Height: 2, Hash: &chainhash.Hash{0x22}})
+		if err != nil {
+			t.Error(err)
+		}
+		replaced <- replacedHash
+	}()
+	select {
+	case <-replaced:
+		t.Fatal("replacing block applied during a wallet scan")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.reorgMu.RUnlock()
+	if replacedHash := <-replaced; !bytes.Equal(replacedHash, (&chainhash.Hash{0x02})[:]) {
+		t.Errorf("replaced hash %x", replacedHash)
+	}
+}
@@ -0,0 +1,96 @@
+// Package listener opens the listeners for http_host and grpc_host,
+// which take either a TCP address or unix:///path/to.sock.
+package listener
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+const unixPrefix = "unix://"
+
+// Listen opens a unix socket for unix:// addresses, otherwise a TCP listener.
+// The socket file gets config.UnixSocketMode and is removed when the listener is closed.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no socket path in %q", addr)
+	}
+
+	// a socket left behind by an unclean shutdown would make listen fail
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	// the socket is created with the umask's permissions, it is only moved to path
+	// once it has its mode, until then it sits in a directory only we can enter
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".sock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpPath := filepath.Join(dir, "s")
+	lis, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	lis.SetUnlinkOnClose(false)
+	if err = os.Chmod(tmpPath, config.UnixSocketMode); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("failed to move socket into place: %w", err)
+	}
+	return &unixListener{UnixListener: lis, path: path}, nil
+}
+
+// unixListener reports and removes the socket under its final path
+type unixListener struct {
+	*net.UnixListener
+	path   string
+	unlink sync.Once
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+func (l *unixListener) Close() error {
+	// only once, a later socket under the same path might belong to someone else
+	l.unlink.Do(func() { os.Remove(l.path) })
+	return l.UnixListener.Close()
+}
+
+// removeStaleSocket refuses to remove anything but a socket
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	// another process still serving on it
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use", path)
+	}
+	return os.Remove(path)
+}
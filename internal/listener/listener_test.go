@@ -0,0 +1,91 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "oracle.sock")
+	config.UnixSocketMode = 0o600
+
+	lis, err := Listen("unix://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode %o, want 600", info.Mode().Perm())
+	}
+	if addr := lis.Addr().String(); addr != path {
+		t.Errorf("listening on %s, want %s", addr, path)
+	}
+	// the socket is created elsewhere and moved into place, nothing else is left behind
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d entries next to the socket", len(entries))
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// a second server must not take over a live socket
+	if _, err = Listen("unix://" + path); err == nil {
+		t.Error("listened on a socket in use")
+	}
+	lis.Close()
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Error("socket file left behind after close")
+	}
+}
+
+func TestListenUnixStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oracle.sock")
+
+	// leave the file behind like a crashed process would
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	lis, err := Listen("unix://" + path)
+	if err != nil {
+		t.Fatalf("stale socket not replaced: %v", err)
+	}
+	lis.Close()
+}
+
+func TestListenUnixRefusesOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oracle.sock")
+	if err := os.WriteFile(path, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen("unix://" + path); err == nil {
+		t.Fatal("listened over a regular file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep me" {
+		t.Error("regular file was touched")
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	lis, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	if lis.Addr().Network() != "tcp" {
+		t.Errorf("network %s", lis.Addr().Network())
+	}
+}
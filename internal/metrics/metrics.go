@@ -0,0 +1,114 @@
+// Package metrics holds the prometheus metrics of the indexer, the store and the APIs.
+// All metrics are registered with the default registry which is served on /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "blindbit_oracle"
+
+// Indexer
+var (
+	IndexedHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "indexed_height",
+		Help:      "Height of the last block written to the store.",
+	})
+	CoreTipHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "core_tip_height",
+		Help:      "Block count reported by Bitcoin Core.",
+	})
+	BlocksBehind = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "blocks_behind",
+		Help:      "Difference between the Core tip and the indexed height.",
+	})
+	PullBacklog = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "pull_backlog",
+		Help:      "Pulled blocks waiting to be handled (newBlockChan).",
+	})
+	WriterBacklog = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "writer_backlog",
+		Help:      "Handled blocks waiting to be written (writerChan).",
+	})
+	PullDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "block_pull_duration_seconds",
+		Help:      "Time to pull a block and its prevouts from Core.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+	HandleDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "block_handle_duration_seconds",
+		Help:      "Time to compute the tweaks of a block.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+	TweakFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "indexer",
+		Name:      "tweak_failures_total",
+		Help:      "Transactions with taproot outputs for which the tweak computation failed.",
+	})
+)
+
+// Store
+var (
+	BatchCommitDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "batch_commit_duration_seconds",
+		Help:      "Time to commit a batch to pebble.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+)
+
+// APIs
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC call latency by method, streams are measured until they end.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// SetSyncState updates the height gauges, coreTip is only set if it is known (> 0)
+func SetSyncState(indexedHeight uint32, coreTip int64) {
+	IndexedHeight.Set(float64(indexedHeight))
+	if coreTip > 0 {
+		CoreTipHeight.Set(float64(coreTip))
+		BlocksBehind.Set(float64(coreTip - int64(indexedHeight)))
+	}
+}
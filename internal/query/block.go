@@ -0,0 +1,59 @@
+package query
+
+import (
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+// Info describes the chain state and which data sets the oracle serves
+type Info struct {
+	Network                        string
+	Height                         uint32
+	TweaksOnly                     bool
+	TweaksFullBasic                bool
+	TweaksFullWithDustFilter       bool
+	TweaksCutThroughWithDustFilter bool
+	// DustFilter and CutThrough the filters the gRPC range streams can apply
+	DustFilter bool
+	CutThrough bool
+}
+
+func (s *Service) Info() (*Info, error) {
+	height, err := s.TipHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Info{
+		Network:                        config.ChainToString(config.Chain),
+		Height:                         height,
+		TweaksOnly:                     config.TweaksOnly,
+		TweaksFullBasic:                config.TweakIndexFullNoDust,
+		TweaksFullWithDustFilter:       config.TweakIndexFullIncludingDust,
+		TweaksCutThroughWithDustFilter: config.TweaksCutThroughWithDust,
+		DustFilter:                     config.DustFilterSupported(),
+		CutThrough:                     config.CutThroughSupported(),
+	}, nil
+}
+
+// TipHeight returns the height of the last indexed block
+func (s *Service) TipHeight() (uint32, error) {
+	_, height, err := s.db.GetChainTip()
+	return height, err
+}
+
+// BlockByHeight returns the blockhash (internal byte order) of the block at height on the best chain
+func (s *Service) BlockByHeight(height uint32) ([]byte, error) {
+	return s.db.ResolveHeight(height)
+}
+
+// BlockByHash returns height and blockhash (internal byte order) for a 32 byte hash in display order.
+// The block has to be on the best chain.
+func (s *Service) BlockByHash(displayHash []byte) (uint32, []byte, error) {
+	blockhash := utils.ReverseBytesCopy(displayHash)
+	height, err := s.db.BlockHeightByHash(blockhash)
+	if err != nil {
+		return 0, nil, err
+	}
+	return height, blockhash, nil
+}
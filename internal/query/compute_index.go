@@ -0,0 +1,106 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+// ComputeIndexTx lets a wallet probe a tx for its outputs before fetching them
+type ComputeIndexTx struct {
+	Txid  [32]byte
+	Tweak [33]byte
+	// OutputsShort the first 8 bytes of every taproot output pubkey, concatenated
+	OutputsShort []byte
+}
+
+type ComputeIndex struct {
+	Block BlockID
+	Txs   []ComputeIndexTx
+}
+
+// BlockScanData is everything a light wallet needs to scan a block
+type BlockScanData struct {
+	Block        BlockID
+	ComputeIndex []ComputeIndexTx
+	// SpentOutputs the shortened spent outputs, concatenated
+	SpentOutputs []byte
+}
+
+// ComputeIndexFilter drops outputs from the compute index.
+// The zero value returns the full compute index.
+type ComputeIndexFilter struct {
+	DustLimit  uint64
+	CutThrough bool
+	// tipHeight spends up to this height are applied for cut-through
+	tipHeight uint32
+}
+
+// NewComputeIndexFilter checks that the filters are supported, cut-through is applied as of the current tip
+func (s *Service) NewComputeIndexFilter(dustLimit uint64, cutThrough bool) (ComputeIndexFilter, error) {
+	filter := ComputeIndexFilter{DustLimit: dustLimit, CutThrough: cutThrough}
+	if dustLimit == 0 && !cutThrough {
+		return filter, nil
+	}
+	if dustLimit > 0 && !config.DustFilterSupported() {
+		return filter, fmt.Errorf("dust filter is not served in tweaks only mode: %w", database.ErrFeatureDisabled)
+	}
+	if cutThrough && !config.CutThroughSupported() {
+		return filter, fmt.Errorf("cut-through is not served in tweaks only mode: %w", database.ErrFeatureDisabled)
+	}
+
+	tipHeight, err := s.TipHeight()
+	if err != nil {
+		return filter, err
+	}
+	filter.tipHeight = tipHeight
+	return filter, nil
+}
+
+// ComputeIndex returns the compute index of a block, blockhash is in internal byte order
+func (s *Service) ComputeIndex(height uint32, blockhash []byte, filter ComputeIndexFilter) (*ComputeIndex, error) {
+	txs, err := s.computeIndexTxs(height, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &ComputeIndex{Block: newBlockID(height, blockhash), Txs: txs}, nil
+}
+
+// BlockScanData returns the compute index and the spent outputs of a block
+func (s *Service) BlockScanData(height uint32, blockhash []byte, filter ComputeIndexFilter) (*BlockScanData, error) {
+	txs, err := s.computeIndexTxs(height, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	spentOutputs, err := s.db.FetchSpentOutputsShort(blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlockScanData{
+		Block:        newBlockID(height, blockhash),
+		ComputeIndex: txs,
+		SpentOutputs: spentOutputs,
+	}, nil
+}
+
+func (s *Service) computeIndexTxs(height uint32, filter ComputeIndexFilter) ([]ComputeIndexTx, error) {
+	items, err := s.db.FetchComputeIndexFiltered(height, filter.tipHeight, filter.DustLimit, filter.CutThrough)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]ComputeIndexTx, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		tx := ComputeIndexTx{OutputsShort: item.OutputsShort}
+		copy(tx.Txid[:], item.Txid)
+		copy(tx.Tweak[:], item.Tweak)
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,101 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+type FullTxOutput struct {
+	Vout   uint32
+	Amount uint64
+	Pubkey [32]byte
+}
+
+// FullTx is a tx with taproot outputs, its tweak (zero if none was stored)
+// and the outpoints it spends (txid in display order + 4 byte vout)
+type FullTx struct {
+	Txid    [32]byte
+	Tweak   [33]byte
+	Inputs  [][36]byte
+	Outputs []FullTxOutput
+}
+
+type FullBlock struct {
+	Block BlockID
+	Txs   []FullTx
+}
+
+// FullBlock returns every tx of a block with taproot outputs, ordered by txid (internal byte order).
+// Not available in tweaks only mode.
+func (s *Service) FullBlock(height uint32, blockhash []byte) (*FullBlock, error) {
+	if config.TweaksOnly {
+		return nil, fmt.Errorf("full blocks are not served in tweaks only mode: %w", database.ErrFeatureDisabled)
+	}
+
+	_, syncTip, err := s.db.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := s.db.FetchOutputsAll(blockhash, syncTip)
+	if err != nil {
+		return nil, err
+	}
+
+	tweakRows, err := s.db.TweaksForBlockAll(blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	txidOutpoints, err := s.db.FetchAllTxidOutpointsForBlock(blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	tweaks := make(map[[32]byte][33]byte, len(tweakRows))
+	for _, tweakRow := range tweakRows {
+		if tweakRow != nil {
+			tweaks[tweakRow.Txid] = tweakRow.Tweak
+		}
+	}
+
+	// group the outputs by txid
+	txOutputs := make(map[[32]byte][]FullTxOutput)
+	for _, output := range outputs {
+		if output == nil {
+			continue
+		}
+		out := FullTxOutput{Vout: output.Vout, Amount: output.Amount}
+		copy(out.Pubkey[:], output.Pubkey)
+		txid := [32]byte(output.Txid)
+		txOutputs[txid] = append(txOutputs[txid], out)
+	}
+
+	txids := make([][32]byte, 0, len(txOutputs))
+	for txid := range txOutputs {
+		txids = append(txids, txid)
+	}
+	slices.SortFunc(txids, func(a, b [32]byte) int { return bytes.Compare(a[:], b[:]) })
+
+	txs := make([]FullTx, 0, len(txids))
+	for _, txid := range txids {
+		outpoints := txidOutpoints[txid]
+		for i := range outpoints {
+			utils.ReverseBytes(outpoints[i][:32])
+		}
+
+		txs = append(txs, FullTx{
+			Txid:    [32]byte(utils.ReverseBytesCopy(txid[:])),
+			Tweak:   tweaks[txid],
+			Inputs:  outpoints,
+			Outputs: txOutputs[txid],
+		})
+	}
+
+	return &FullBlock{Block: newBlockID(height, blockhash), Txs: txs}, nil
+}
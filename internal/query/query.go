@@ -0,0 +1,134 @@
+// Package query assembles the served data sets from the database.
+// The results are transport independent, the HTTP and the gRPC server only encode them.
+// Hashes and txids in results are in display order.
+package query
+
+import (
+	"fmt"
+
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+type Service struct {
+	db database.DB
+}
+
+func New(db database.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) scanStore() (database.ScanStore, error) {
+	store, ok := s.db.(database.ScanStore)
+	if !ok {
+		return nil, fmt.Errorf("the store can't scan: %w", database.ErrFeatureDisabled)
+	}
+	return store, nil
+}
+
+func (s *Service) walletStore() (database.WalletStore, error) {
+	store, ok := s.db.(database.WalletStore)
+	if !ok {
+		return nil, fmt.Errorf("the store can't keep wallets: %w", database.ErrFeatureDisabled)
+	}
+	return store, nil
+}
+
+// BlockID identifies the block a result belongs to, Hash is in display order
+type BlockID struct {
+	Height uint32
+	Hash   []byte
+}
+
+func newBlockID(height uint32, blockhash []byte) BlockID {
+	return BlockID{Height: height, Hash: utils.ReverseBytesCopy(blockhash)}
+}
+
+type Tweaks struct {
+	Block  BlockID
+	Tweaks [][33]byte
+}
+
+type UTXO struct {
+	Txid   [32]byte
+	Vout   uint32
+	Amount uint64
+	Pubkey [32]byte
+}
+
+type UTXOs struct {
+	Block BlockID
+	UTXOs []UTXO
+}
+
+// SpentOutputs are the shortened (8 byte) pubkeys of the taproot outputs spent in a block
+type SpentOutputs struct {
+	Block BlockID
+	Spent [][8]byte
+}
+
+// Tweaks returns the tweaks of a block, blockhash is in internal byte order
+func (s *Service) Tweaks(height uint32, blockhash []byte) (*Tweaks, error) {
+	tweakRows, err := s.db.TweaksForBlockAll(blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	tweaks := make([][33]byte, 0, len(tweakRows))
+	for _, tweakRow := range tweakRows {
+		if tweakRow != nil {
+			tweaks = append(tweaks, tweakRow.Tweak)
+		}
+	}
+
+	return &Tweaks{Block: newBlockID(height, blockhash), Tweaks: tweaks}, nil
+}
+
+// UTXOs returns the taproot outputs of a block, not available in tweaks only mode
+func (s *Service) UTXOs(height uint32, blockhash []byte) (*UTXOs, error) {
+	if config.TweaksOnly {
+		return nil, fmt.Errorf("utxos are not served in tweaks only mode: %w", database.ErrFeatureDisabled)
+	}
+
+	_, syncTip, err := s.db.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := s.db.FetchOutputsAll(blockhash, syncTip)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]UTXO, 0, len(outputs))
+	for _, output := range outputs {
+		if output == nil {
+			continue
+		}
+		utxo := UTXO{
+			Txid:   [32]byte(utils.ReverseBytesCopy(output.Txid)),
+			Vout:   output.Vout,
+			Amount: output.Amount,
+		}
+		copy(utxo.Pubkey[:], output.Pubkey)
+		utxos = append(utxos, utxo)
+	}
+
+	return &UTXOs{Block: newBlockID(height, blockhash), UTXOs: utxos}, nil
+}
+
+// SpentOutputs returns the shortened outputs spent in a block
+func (s *Service) SpentOutputs(height uint32, blockhash []byte) (*SpentOutputs, error) {
+	data, err := s.db.FetchSpentOutputsShort(blockhash)
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make([][8]byte, 0, len(data)/8)
+	for i := 0; i+8 <= len(data); i += 8 {
+		spent = append(spent, [8]byte(data[i:i+8]))
+	}
+
+	return &SpentOutputs{Block: newBlockID(height, blockhash), Spent: spent}, nil
+}
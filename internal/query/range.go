@@ -0,0 +1,38 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+// ErrInvalidRange the requested height range is malformed or longer than config.MaxRangePerRequest
+var ErrInvalidRange = errors.New("invalid range")
+
+// Range checks the height range from start to end (both inclusive) and clamps end to the synced tip,
+// so a stream ends cleanly once the tip is reached.
+// A start above the tip is database.ErrNotYetSynced.
+func (s *Service) Range(start, end uint64) (uint32, uint32, error) {
+	if start > end {
+		return 0, 0, fmt.Errorf("%w: start height is above end height", ErrInvalidRange)
+	}
+	if end > math.MaxUint32 {
+		return 0, 0, fmt.Errorf("%w: end height is not a valid block height", ErrInvalidRange)
+	}
+	if end-start >= uint64(config.MaxRangePerRequest) {
+		return 0, 0, fmt.Errorf("%w: range exceeds maximum of %d heights", ErrInvalidRange, config.MaxRangePerRequest)
+	}
+
+	tipHeight, err := s.TipHeight()
+	if err != nil {
+		return 0, 0, err
+	}
+	if start > uint64(tipHeight) {
+		return 0, 0, fmt.Errorf("start height %d above tip %d: %w", start, tipHeight, database.ErrNotYetSynced)
+	}
+
+	return uint32(start), min(uint32(end), tipHeight), nil
+}
@@ -0,0 +1,187 @@
+package query
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/go-bip352"
+)
+
+const (
+	// scanChunkHeights heights scanned before the matches are handed out, about a week of blocks
+	scanChunkHeights = 1008
+	// scanWorkRangeHeights heights a scan worker takes at once
+	scanWorkRangeHeights = 36
+)
+
+// ErrInvalidScanKeys the scan secret key or spend public key is malformed
+var ErrInvalidScanKeys = errors.New("invalid scan keys")
+
+// ScanMatch an output of the scanned range which belongs to the keys
+type ScanMatch struct {
+	Block BlockID
+	UTXO  UTXO
+	Tweak [33]byte
+	// SecKeyTweak added to the spend secret key gives the secret key of the output, includes the label tweak
+	SecKeyTweak [32]byte
+	// Label m of the label the output was sent to, nil for the plain spend key
+	Label *uint32
+}
+
+// NewScanKeys checks the receiver keys and derives the label public keys for the label numbers
+func NewScanKeys(scanSecretKey, spendPubKey []byte, labels []uint32) (*database.ScanKeys, error) {
+	if len(scanSecretKey) != 32 {
+		return nil, fmt.Errorf("%w: scan secret key has to be 32 bytes", ErrInvalidScanKeys)
+	}
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetByteSlice(scanSecretKey); overflow || scalar.IsZero() {
+		return nil, fmt.Errorf("%w: scan secret key is not a valid secret key", ErrInvalidScanKeys)
+	}
+	if len(spendPubKey) != 33 {
+		return nil, fmt.Errorf("%w: spend public key has to be 33 bytes compressed", ErrInvalidScanKeys)
+	}
+	if _, err := btcec.ParsePubKey(spendPubKey); err != nil {
+		return nil, fmt.Errorf("%w: spend public key is not a valid public key", ErrInvalidScanKeys)
+	}
+
+	keys := &database.ScanKeys{
+		ScanSecretKey: [32]byte(scanSecretKey),
+		SpendPubKey:   [33]byte(spendPubKey),
+	}
+	for _, m := range labels {
+		label, err := bip352.CreateLabel(&keys.ScanSecretKey, m)
+		if err != nil {
+			return nil, err
+		}
+		keys.Labels = append(keys.Labels, &label)
+	}
+	return keys, nil
+}
+
+// ScanRange scans the compute index from start to end (both inclusive, checked with Range) for outputs of keys.
+// fn gets the matches of every chunk of heights in order of height, txid and vout,
+// together with the last height scanned so far, also if nothing was found.
+// An error of fn stops the scan and is returned.
+func (s *Service) ScanRange(
+	ctx context.Context,
+	keys *database.ScanKeys,
+	start, end uint32,
+	fn func(matches []ScanMatch, scannedHeight uint32) error,
+) error {
+	if config.TweaksOnly {
+		return fmt.Errorf("scanning is not served in tweaks only mode: %w", database.ErrFeatureDisabled)
+	}
+	store, err := s.scanStore()
+	if err != nil {
+		return err
+	}
+
+	for chunkStart := start; chunkStart <= end; chunkStart += scanChunkHeights {
+		chunkEnd := end
+		if end-chunkStart >= scanChunkHeights {
+			chunkEnd = chunkStart + scanChunkHeights - 1
+		}
+
+		found, err := store.DBComputeComputeIndexParallel(
+			ctx, keys, chunkStart, chunkEnd, config.MaxCPUCores, scanWorkRangeHeights,
+		)
+		if err != nil {
+			return err
+		}
+
+		matches, err := s.scanMatches(store, keys, found)
+		if err != nil {
+			return err
+		}
+		if err = fn(matches, chunkEnd); err != nil {
+			return err
+		}
+
+		if chunkEnd == end {
+			break
+		}
+	}
+	return nil
+}
+
+// scanMatches resolves the shortened outputs of the scan to the full outputs.
+// The scan only compares the first 8 bytes, the full key is checked against B_spend + SecKeyTweak·G.
+func (s *Service) scanMatches(store database.ScanStore, keys *database.ScanKeys, found []*database.FoundOutputShort) ([]ScanMatch, error) {
+	matches := make([]ScanMatch, 0, len(found))
+	blockhashes := make(map[uint32][]byte)
+	for _, f := range found {
+		expected, err := outputPubKey(keys.SpendPubKey, f.SecKeyTweak)
+		if err != nil {
+			return nil, err
+		}
+		outputs, err := store.OutputsForTx(f.Txid[:])
+		if err != nil {
+			return nil, err
+		}
+		i := slices.IndexFunc(outputs, func(o *database.Output) bool {
+			return bytes.Equal(o.Pubkey, expected[:])
+		})
+		if i < 0 {
+			// a prefix collision, or the compute index outlived the outputs of the tx
+			continue
+		}
+		output := outputs[i]
+
+		blockhash, ok := blockhashes[f.Height]
+		if !ok {
+			blockhash, err = s.db.GetBlockHashByHeight(f.Height)
+			if err != nil {
+				return nil, err
+			}
+			blockhashes[f.Height] = blockhash
+		}
+
+		match := ScanMatch{
+			Block: newBlockID(f.Height, blockhash),
+			UTXO: UTXO{
+				Txid:   [32]byte(utils.ReverseBytesCopy(f.Txid[:])),
+				Vout:   output.Vout,
+				Amount: output.Amount,
+			},
+			Tweak:       f.Tweak,
+			SecKeyTweak: f.SecKeyTweak,
+		}
+		copy(match.UTXO.Pubkey[:], output.Pubkey)
+		if f.Label != nil {
+			m := f.Label.M
+			match.Label = &m
+		}
+		matches = append(matches, match)
+	}
+
+	slices.SortFunc(matches, func(a, b ScanMatch) int { return compareScanMatches(&a, &b) })
+	return matches, nil
+}
+
+// outputPubKey is the x-only key of the output spendable with the spend secret key plus secKeyTweak
+func outputPubKey(spendPubKey [33]byte, secKeyTweak [32]byte) ([32]byte, error) {
+	pubKey, err := bip352.AddPublicKeys(&spendPubKey, bip352.PubKeyFromSecKey(&secKeyTweak))
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return [32]byte(pubKey[1:]), nil
+}
+
+// compareScanMatches orders by height, txid and vout
+func compareScanMatches(a, b *ScanMatch) int {
+	if a.Block.Height != b.Block.Height {
+		return cmp.Compare(a.Block.Height, b.Block.Height)
+	}
+	if c := bytes.Compare(a.UTXO.Txid[:], b.UTXO.Txid[:]); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.UTXO.Vout, b.UTXO.Vout)
+}
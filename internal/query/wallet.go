@@ -0,0 +1,150 @@
+package query
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+)
+
+// WalletStatus the registration of a watch-only wallet and how far it is scanned
+type WalletStatus struct {
+	ID            [32]byte
+	BirthHeight   uint32
+	ScannedHeight uint32
+}
+
+// WalletOutput an output found for a wallet, Spent is the block spending it on the best chain
+type WalletOutput struct {
+	ScanMatch
+	Spent *BlockID
+}
+
+type WalletOutputs struct {
+	Wallet  WalletStatus
+	Outputs []WalletOutput
+}
+
+// WalletID is HMAC-SHA256 of the spend public key keyed with the scan secret key.
+// It acts as the bearer token of the wallet, so it must not be derivable from the public address.
+func WalletID(keys *database.ScanKeys) [32]byte {
+	mac := hmac.New(sha256.New, keys.ScanSecretKey[:])
+	mac.Write(keys.SpendPubKey[:])
+	return [32]byte(mac.Sum(nil))
+}
+
+// RegisterWallet stores a watch-only wallet which the indexer scans from birthHeight on.
+// Registering the same keys again replaces the labels and the birth height and starts the scan over.
+func (s *Service) RegisterWallet(
+	scanSecretKey, spendPubKey []byte, labels []uint32, birthHeight uint32,
+) (*WalletStatus, error) {
+	if config.TweaksOnly {
+		return nil, fmt.Errorf("wallets are not served in tweaks only mode: %w", database.ErrFeatureDisabled)
+	}
+	if birthHeight == 0 {
+		return nil, fmt.Errorf("%w: birth height has to be at least 1", ErrInvalidRange)
+	}
+	// validates the keys
+	keys, err := NewScanKeys(scanSecretKey, spendPubKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	labels = slices.Clone(labels)
+	slices.Sort(labels)
+	w := &database.Wallet{
+		ID:            WalletID(keys),
+		ScanSecretKey: keys.ScanSecretKey,
+		SpendPubKey:   keys.SpendPubKey,
+		Labels:        slices.Compact(labels),
+		BirthHeight:   birthHeight,
+	}
+	store, err := s.walletStore()
+	if err != nil {
+		return nil, err
+	}
+	if err = store.PutWallet(w); err != nil {
+		return nil, err
+	}
+	return walletStatus(w), nil
+}
+
+func (s *Service) UnregisterWallet(id [32]byte) error {
+	store, err := s.walletStore()
+	if err != nil {
+		return err
+	}
+	return store.DeleteWallet(id)
+}
+
+// Wallet returns database.ErrNotFound for unknown ids
+func (s *Service) Wallet(id [32]byte) (*WalletStatus, error) {
+	store, err := s.walletStore()
+	if err != nil {
+		return nil, err
+	}
+	w, err := store.FetchWallet(id)
+	if err != nil {
+		return nil, err
+	}
+	return walletStatus(w), nil
+}
+
+// WalletOutputs returns the outputs found for a wallet in order of height, txid and vout,
+// spent ones only with includeSpent
+func (s *Service) WalletOutputs(id [32]byte, includeSpent bool) (*WalletOutputs, error) {
+	store, err := s.walletStore()
+	if err != nil {
+		return nil, err
+	}
+	w, err := store.FetchWallet(id)
+	if err != nil {
+		return nil, err
+	}
+	found, err := store.FetchWalletOutputs(id)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]WalletOutput, 0, len(found))
+	for _, o := range found {
+		spendingBlock, spentHeight, err := store.SpendingBlock(o.Txid[:], o.Vout)
+		if err != nil {
+			return nil, err
+		}
+		if spendingBlock != nil && !includeSpent {
+			continue
+		}
+
+		output := WalletOutput{
+			ScanMatch: ScanMatch{
+				Block: newBlockID(o.Height, o.Blockhash),
+				UTXO: UTXO{
+					Txid:   [32]byte(utils.ReverseBytesCopy(o.Txid[:])),
+					Vout:   o.Vout,
+					Amount: o.Amount,
+					Pubkey: o.Pubkey,
+				},
+				Tweak:       o.Tweak,
+				SecKeyTweak: o.SecKeyTweak,
+				Label:       o.Label,
+			},
+		}
+		if spendingBlock != nil {
+			spent := newBlockID(spentHeight, spendingBlock)
+			output.Spent = &spent
+		}
+		outputs = append(outputs, output)
+	}
+	slices.SortFunc(outputs, func(a, b WalletOutput) int { return compareScanMatches(&a.ScanMatch, &b.ScanMatch) })
+
+	return &WalletOutputs{Wallet: *walletStatus(w), Outputs: outputs}, nil
+}
+
+func walletStatus(w *database.Wallet) *WalletStatus {
+	return &WalletStatus{ID: w.ID, BirthHeight: w.BirthHeight, ScannedHeight: w.ScannedHeight}
+}
@@ -0,0 +1,44 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/server"
+)
+
+// the rate limits are per client IP, X-Forwarded-For must only count from configured proxies
+func TestTrustedProxies(t *testing.T) {
+	db := newTestStore(t)
+	gin.SetMode(gin.TestMode)
+	t.Cleanup(func() { config.TrustedProxies = nil })
+
+	clientIP := func() string {
+		t.Helper()
+		router := server.NewRouter(server.NewHandler(db), nil)
+		router.GET("/client-ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+		req := httptest.NewRequest(http.MethodGet, "/client-ip", nil)
+		req.RemoteAddr = "10.1.1.1:4000"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	if ip := clientIP(); ip != "10.1.1.1" {
+		t.Errorf("no trusted proxies: got %s", ip)
+	}
+	config.TrustedProxies = []string{"10.1.1.0/24"}
+	if ip := clientIP(); ip != "203.0.113.7" {
+		t.Errorf("trusted proxy: got %s", ip)
+	}
+	config.TrustedProxies = []string{"not an ip"}
+	if ip := clientIP(); ip != "10.1.1.1" {
+		t.Errorf("invalid trusted proxies: got %s", ip)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+// setCacheHeaders sets ETag and Cache-Control for the data of a resolved block.
+// The ETag is the blockhash, the data only changes if the block is reorged out.
+// It is weak because every encoding from content negotiation shares it.
+// Returns true if the client already has this block (If-None-Match) and a 304 was written.
+func (h *Handler) setCacheHeaders(c *gin.Context, height uint32, blockhash []byte) bool {
+	_, tipHeight, err := h.db.GetChainTip()
+	if err != nil {
+		writeError(c, err, "could not fetch chain tip")
+		return true
+	}
+
+	maxAge := config.CacheMaxAgeTip
+	if tipHeight >= height && tipHeight-height+1 > config.CacheMinConfirmations {
+		maxAge = config.CacheMaxAgeDeep
+	}
+
+	etag := `W/"` + hex.EncodeToString(utils.ReverseBytesCopy(blockhash)) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	// a 304 has to carry Vary as well, otherwise caches revalidate one encoding for all
+	c.Header("Vary", "Accept")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches implements the weak comparison of If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setNoStore prevents caching of a response, used for errors
+// which may resolve once the index catches up
+func setNoStore(c *gin.Context) {
+	c.Writer.Header().Del("ETag")
+	c.Header("Cache-Control", "no-store")
+}
@@ -0,0 +1,102 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+func TestCacheHeaders(t *testing.T) {
+	router, _ := newTestClients(t, newTestStore(t))
+	minConfirmations, maxAgeDeep, maxAgeTip := config.CacheMinConfirmations, config.CacheMaxAgeDeep, config.CacheMaxAgeTip
+	t.Cleanup(func() {
+		config.CacheMinConfirmations, config.CacheMaxAgeDeep, config.CacheMaxAgeTip = minConfirmations, maxAgeDeep, maxAgeTip
+	})
+	// the tip is at 2, block 1 has 2 confirmations and block 2 one
+	config.CacheMinConfirmations, config.CacheMaxAgeDeep, config.CacheMaxAgeTip = 1, 86400, 10
+
+	serve := func(path, accept, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	hash1 := (&chainhash.Hash{0x01}).String()
+	etag1 := `W/"` + hash1 + `"`
+	etag2 := `W/"` + (&chainhash.Hash{0x02}).String() + `"`
+
+	cases := []struct {
+		name         string
+		path         string
+		accept       string
+		ifNoneMatch  string
+		status       int
+		etag         string
+		cacheControl string
+	}{
+		{name: "deep block", path: "/tweaks/1", status: http.StatusOK, etag: etag1, cacheControl: "public, max-age=86400"},
+		{name: "tip block", path: "/tweaks/2", status: http.StatusOK, etag: etag2, cacheControl: "public, max-age=10"},
+		{name: "by hash", path: "/tweaks/by-hash/" + hash1, status: http.StatusOK, etag: etag1, cacheControl: "public, max-age=86400"},
+		{
+			name: "every encoding shares the etag", path: "/tweaks/1", accept: "application/x-protobuf",
+			status: http.StatusOK, etag: etag1, cacheControl: "public, max-age=86400",
+		},
+		{
+			name: "not modified", path: "/tweaks/1", ifNoneMatch: etag1,
+			status: http.StatusNotModified, etag: etag1, cacheControl: "public, max-age=86400",
+		},
+		{
+			name: "strong etag compared weakly", path: "/tweaks/1", ifNoneMatch: `"` + hash1 + `"`,
+			status: http.StatusNotModified, etag: etag1, cacheControl: "public, max-age=86400",
+		},
+		{
+			name: "etag in a list", path: "/compute-index/1", ifNoneMatch: etag2 + ", " + etag1,
+			status: http.StatusNotModified, etag: etag1, cacheControl: "public, max-age=86400",
+		},
+		{
+			name: "any etag", path: "/tweaks/2", ifNoneMatch: "*",
+			status: http.StatusNotModified, etag: etag2, cacheControl: "public, max-age=10",
+		},
+		{
+			name: "other block", path: "/tweaks/1", ifNoneMatch: etag2,
+			status: http.StatusOK, etag: etag1, cacheControl: "public, max-age=86400",
+		},
+		{name: "errors are not cached", path: "/tweaks/3", ifNoneMatch: "*", status: http.StatusTooEarly, cacheControl: "no-store"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.path, tc.accept, tc.ifNoneMatch)
+			if rec.Code != tc.status {
+				t.Fatalf("status %d, want %d", rec.Code, tc.status)
+			}
+			if got := rec.Header().Get("ETag"); got != tc.etag {
+				t.Errorf("etag %q, want %q", got, tc.etag)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tc.cacheControl {
+				t.Errorf("cache control %q, want %q", got, tc.cacheControl)
+			}
+			if got := rec.Header().Get("Vary"); tc.etag != "" && got != "Accept" {
+				t.Errorf("vary %q, want Accept", got)
+			}
+			if tc.status == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 with a body: %s", rec.Body.String())
+			}
+		})
+	}
+
+	// once the block is deep enough a cached tip response is revalidated as deep
+	config.CacheMinConfirmations = 0
+	rec := serve("/tweaks/2", "", etag2)
+	if rec.Code != http.StatusNotModified || rec.Header().Get("Cache-Control") != "public, max-age=86400" {
+		t.Errorf("revalidated tip block: %d %q", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/access"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+)
+
+// Machine readable error codes sent in ErrorResponse.Code
+const (
+	ErrCodeBadRequest      = "bad_request"
+	ErrCodeNotFound        = "not_found"
+	ErrCodeNotYetSynced    = "not_yet_synced"
+	ErrCodePruned          = "pruned"
+	ErrCodeFeatureDisabled = "feature_disabled"
+	ErrCodeNotOnBestChain  = "not_on_best_chain"
+	ErrCodeUnauthorized    = "unauthorized"
+	ErrCodeRateLimited     = "rate_limited"
+	ErrCodeQuotaExceeded   = "quota_exceeded"
+	ErrCodeInternal        = "internal"
+)
+
+// errorStatus maps the typed database, query and access errors to a http status and error code.
+// ok is false for any other error.
+func errorStatus(err error) (status int, code string, ok bool) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return http.StatusNotFound, ErrCodeNotFound, true
+	case errors.Is(err, database.ErrNotYetSynced):
+		return http.StatusTooEarly, ErrCodeNotYetSynced, true
+	case errors.Is(err, database.ErrPruned):
+		return http.StatusGone, ErrCodePruned, true
+	case errors.Is(err, database.ErrFeatureDisabled):
+		return http.StatusNotImplemented, ErrCodeFeatureDisabled, true
+	case errors.Is(err, database.ErrNotOnBestChain):
+		return http.StatusConflict, ErrCodeNotOnBestChain, true
+	case errors.Is(err, query.ErrInvalidRange):
+		return http.StatusBadRequest, ErrCodeBadRequest, true
+	case errors.Is(err, access.ErrUnauthorized):
+		return http.StatusUnauthorized, ErrCodeUnauthorized, true
+	case errors.Is(err, access.ErrRateLimited):
+		return http.StatusTooManyRequests, ErrCodeRateLimited, true
+	case errors.Is(err, access.ErrQuotaExceeded):
+		return http.StatusTooManyRequests, ErrCodeQuotaExceeded, true
+	default:
+		return http.StatusInternalServerError, ErrCodeInternal, false
+	}
+}
+
+// writeError answers with the status and code matching err.
+// Untyped errors are logged and answered with a 500 and msg so internals are not exposed.
+func writeError(c *gin.Context, err error, msg string) {
+	setNoStore(c)
+	status, code, ok := errorStatus(err)
+	if !ok {
+		logging.L.Err(err).Msg(msg)
+		c.JSON(status, NewErrorResponseWithCode(code, errors.New(msg)))
+		return
+	}
+	c.JSON(status, NewErrorResponseWithCode(code, err))
+}
+
+// writeBadRequest answers with a 400 for malformed requests
+func writeBadRequest(c *gin.Context, err error) {
+	setNoStore(c)
+	c.JSON(http.StatusBadRequest, NewErrorResponseWithCode(ErrCodeBadRequest, err))
+}
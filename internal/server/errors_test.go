@@ -0,0 +1,92 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/setavenger/blindbit-lib/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// TestErrorMapping heights which can't be served get the same typed error on both transports
+func TestErrorMapping(t *testing.T) {
+	db := newTestStore(t)
+	// block 2 is replaced by a reorg, its old hash is no longer on the best chain
+	if err := db.ApplyBlock(&database.DBBlock{Height: 2, Hash: &chainhash.Hash{0x22}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.FlushBatch(true); err != nil {
+		t.Fatal(err)
+	}
+	router, client := newTestClients(t, db)
+	ctx := context.Background()
+	reorged := chainhash.Hash{0x02}
+
+	cases := []struct {
+		name       string
+		path       string
+		req        *pb.BlockHeightRequest
+		tweaksOnly bool
+		httpStatus int
+		code       string
+		grpcCode   codes.Code
+	}{
+		{
+			name: "not yet synced", path: "/utxos/3", req: height(3),
+			httpStatus: http.StatusTooEarly, code: "not_yet_synced", grpcCode: codes.OutOfRange,
+		},
+		{
+			name: "pruned", path: "/utxos/0", req: height(0),
+			httpStatus: http.StatusGone, code: "pruned", grpcCode: codes.NotFound,
+		},
+		{
+			name: "feature disabled", path: "/utxos/1", req: height(1), tweaksOnly: true,
+			httpStatus: http.StatusNotImplemented, code: "feature_disabled", grpcCode: codes.Unimplemented,
+		},
+		{
+			name: "not on best chain", path: "/utxos/by-hash/" + reorged.String(),
+			// the gRPC request takes the hash in display order as well
+			req: &pb.BlockHeightRequest{Block: &pb.BlockHeightRequest_BlockHash{
+				BlockHash: utils.ReverseBytesCopy(reorged[:]),
+			}},
+			httpStatus: http.StatusConflict, code: "not_on_best_chain", grpcCode: codes.FailedPrecondition,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config.TweaksOnly = tc.tweaksOnly
+			t.Cleanup(func() { config.TweaksOnly = false })
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			var resp struct {
+				Data struct {
+					Code string `json:"code"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tc.httpStatus || resp.Data.Code != tc.code {
+				t.Errorf("http %d %q, want %d %q", rec.Code, resp.Data.Code, tc.httpStatus, tc.code)
+			}
+			if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+				t.Errorf("error cached with %q", cc)
+			}
+
+			_, err := client.GetUtxos(ctx, tc.req)
+			if status.Code(err) != tc.grpcCode {
+				t.Errorf("grpc %v, want %v", status.Code(err), tc.grpcCode)
+			}
+		})
+	}
+}
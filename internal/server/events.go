@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/events"
+)
+
+const (
+	// eventsBuffer events a subscriber may fall behind before it is dropped
+	eventsBuffer = 64
+	// keepAliveInterval SSE comments and websocket pings so proxies don't close idle connections
+	keepAliveInterval = 30 * time.Second
+	wsWriteTimeout    = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" ||
+			slices.Contains(config.CORSAllowedOrigins, "*") ||
+			slices.Contains(config.CORSAllowedOrigins, origin)
+	},
+}
+
+// Events pushes block_connected and block_disconnected events as Server-Sent Events,
+// or as JSON text messages if the request is a WebSocket upgrade.
+// The stream ends if the client can't keep up, clients should reconnect and re-check the tip.
+func (h *Handler) Events(c *gin.Context) {
+	sub, unsubscribe := events.Blocks.Subscribe(eventsBuffer)
+	defer unsubscribe()
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.eventsWebSocket(c, sub)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Connection", "keep-alive")
+	// nginx buffers responses unless told otherwise
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+		case ev, ok := <-sub:
+			if !ok {
+				// dropped for being slow or the server shuts down
+				logging.L.Debug().Msg("event subscription ended")
+				return
+			}
+			c.SSEvent(ev.Type, ev)
+		}
+		c.Writer.Flush()
+	}
+}
+
+func (h *Handler) eventsWebSocket(c *gin.Context, sub <-chan events.BlockEvent) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade already answered with an error
+		logging.L.Debug().Err(err).Msg("websocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	// clients don't send anything, reading is needed to handle pongs and notice the close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-keepAlive.C:
+			deadline := time.Now().Add(wsWriteTimeout)
+			if err = conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case ev, ok := <-sub:
+			if !ok {
+				code, reason := websocket.CloseTryAgainLater, "too slow"
+				select {
+				case <-events.Blocks.Closed():
+					code, reason = websocket.CloseGoingAway, "server shutting down"
+				default:
+					logging.L.Debug().Msg("dropped slow event subscriber")
+				}
+				conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(code, reason),
+					time.Now().Add(wsWriteTimeout),
+				)
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err = conn.WriteJSON(ev); err != nil {
+				return
+			}
+		}
+	}
+}
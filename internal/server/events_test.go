@@ -0,0 +1,233 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/setavenger/blindbit-oracle/internal/events"
+	"github.com/setavenger/blindbit-oracle/internal/server"
+)
+
+// gatedWriter holds back everything written to the client while gated,
+// like a client which stopped reading
+type gatedWriter struct {
+	http.ResponseWriter
+	gated atomic.Bool
+	open  chan struct{}
+}
+
+func (w *gatedWriter) wait() {
+	if w.gated.Load() {
+		<-w.open
+	}
+}
+
+func (w *gatedWriter) Write(b []byte) (int, error) {
+	w.wait()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gatedWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *gatedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	return &gatedConn{Conn: conn, w: w}, rw, err
+}
+
+type gatedConn struct {
+	net.Conn
+	w *gatedWriter
+}
+
+func (c *gatedConn) Write(b []byte) (int, error) {
+	c.w.wait()
+	return c.Conn.Write(b)
+}
+
+// newEventsServer serves the router on a fresh events bus, writes go through the returned gatedWriter
+func newEventsServer(t *testing.T) (*httptest.Server, *gatedWriter) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	saved := events.Blocks
+	events.Blocks = events.NewBus()
+	t.Cleanup(func() { events.Blocks = saved })
+
+	router := server.NewRouter(server.NewHandler(newTestStore(t)), nil)
+	gw := &gatedWriter{open: make(chan struct{})}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gw.ResponseWriter = w
+		router.ServeHTTP(gw, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, gw
+}
+
+// openSSE returns once the handler subscribed, it flushes the headers right after
+func openSSE(t *testing.T, url string) (*http.Response, *bufio.Scanner) {
+	t.Helper()
+	resp, err := http.Get(url + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return resp, bufio.NewScanner(resp.Body)
+}
+
+// nextSSE reads the next event, ok is false at the end of the stream
+func nextSSE(t *testing.T, scanner *bufio.Scanner) (eventType string, ev events.BlockEvent, ok bool) {
+	t.Helper()
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &ev); err != nil {
+				t.Fatal(err)
+			}
+		case line == "" && eventType != "":
+			return eventType, ev, true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return "", ev, false
+}
+
+func dialEvents(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+var (
+	connected    = events.NewBlockEvent(events.BlockConnected, 3, []byte{0x03, 0x01})
+	disconnected = events.NewBlockEvent(events.BlockDisconnected, 3, []byte{0x03, 0x02})
+)
+
+func TestEventsSSE(t *testing.T) {
+	srv, _ := newEventsServer(t)
+	_, scanner := openSSE(t, srv.URL)
+
+	events.Blocks.Publish(disconnected)
+	events.Blocks.Publish(connected)
+	for _, want := range []events.BlockEvent{disconnected, connected} {
+		eventType, ev, ok := nextSSE(t, scanner)
+		if !ok {
+			t.Fatal("stream ended early")
+		}
+		if eventType != want.Type || ev != want {
+			t.Errorf("got %s %+v, want %+v", eventType, ev, want)
+		}
+	}
+
+	// the stream ends on shutdown
+	events.Blocks.Close()
+	if eventType, _, ok := nextSSE(t, scanner); ok {
+		t.Fatalf("got %s after shutdown", eventType)
+	}
+}
+
+func TestEventsSSEDropsSlowClient(t *testing.T) {
+	srv, gw := newEventsServer(t)
+	_, scanner := openSSE(t, srv.URL)
+
+	// the handler takes at most one event before it blocks writing, the rest overflow its buffer
+	gw.gated.Store(true)
+	const published = 200
+	for range published {
+		events.Blocks.Publish(connected)
+	}
+	close(gw.open)
+
+	received := 0
+	for {
+		_, _, ok := nextSSE(t, scanner)
+		if !ok {
+			break
+		}
+		received++
+	}
+	if received == 0 || received >= published {
+		t.Fatalf("received %d of %d events before the stream ended", received, published)
+	}
+	select {
+	case <-events.Blocks.Closed():
+		t.Fatal("bus closed")
+	default:
+	}
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	srv, _ := newEventsServer(t)
+	conn := dialEvents(t, srv.URL)
+
+	events.Blocks.Publish(connected)
+	events.Blocks.Publish(disconnected)
+	for _, want := range []events.BlockEvent{connected, disconnected} {
+		var ev events.BlockEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev != want {
+			t.Errorf("got %+v, want %+v", ev, want)
+		}
+	}
+
+	events.Blocks.Close()
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("got %v, want going away close", err)
+	}
+}
+
+func TestEventsWebSocketDropsSlowClient(t *testing.T) {
+	srv, gw := newEventsServer(t)
+	conn := dialEvents(t, srv.URL)
+
+	gw.gated.Store(true)
+	const published = 200
+	for range published {
+		events.Blocks.Publish(connected)
+	}
+	close(gw.open)
+
+	received := 0
+	for {
+		_, _, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseTryAgainLater {
+				t.Fatalf("got %v, want try again later close", err)
+			}
+			break
+		}
+		received++
+	}
+	if received == 0 || received >= published {
+		t.Fatalf("received %d of %d events before the close", received, published)
+	}
+}
@@ -4,459 +4,195 @@ import (
 	"encoding/hex"
 	"errors"
 	"net/http"
-	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/setavenger/blindbit-lib/api"
 	"github.com/setavenger/blindbit-lib/logging"
 	"github.com/setavenger/blindbit-lib/utils"
-	"github.com/setavenger/blindbit-oracle/internal/config"
 	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/query"
 )
 
 type Handler struct {
-	db database.DB
+	db    database.DB
+	query *query.Service
 }
 
 func NewHandler(db database.DB) *Handler {
-	return &Handler{db: db}
+	return &Handler{db: db, query: query.New(db)}
 }
 
 func (h *Handler) GetInfo(c *gin.Context) {
-	_, height, err := h.db.GetChainTip()
+	info, err := h.query.Info()
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching chain tip")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "could could not retrieve data from database",
-		})
+		writeError(c, err, "could not retrieve data from database")
 		return
 	}
-	c.JSON(http.StatusOK, api.InfoResponseOracle{
-		Network:                        config.ChainToString(config.Chain),
-		Height:                         height,
-		TweaksOnly:                     config.TweaksOnly,
-		TweaksFullBasic:                config.TweakIndexFullNoDust,
-		TweaksFullWithDustFilter:       config.TweakIndexFullIncludingDust,
-		TweaksCutThroughWithDustFilter: config.TweaksCutThroughWithDust,
-	})
+	render(c, http.StatusOK, newInfoResponse(info))
 }
 
 func (h *Handler) GetBestBlockHeight(c *gin.Context) {
-	_, height, err := h.db.GetChainTip()
+	height, err := h.query.TipHeight()
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching chain tip")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "could could not retrieve data from database",
-		})
+		writeError(c, err, "could not retrieve data from database")
 		return
 	}
-	c.JSON(http.StatusOK, api.BlockHeightResponseOracle{
+	render(c, http.StatusOK, BlockHeightResponse{
 		BlockHeight: height,
 	})
 }
 
 func (h *Handler) GetBlockHashByHeight(c *gin.Context) {
-	heightStr := c.Param("blockheight")
-	if heightStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "block height is required"})
+	_, blockhash, ok := h.resolveBlock(c)
+	if !ok {
 		return
 	}
 
-	height, err := strconv.ParseUint(heightStr, 10, 32)
-	if err != nil {
-		logging.L.Err(err).Msg("could not parse block height")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "could not parse block height"})
-		return
-	}
+	render(c, http.StatusOK, BlockHashResponse{
+		BlockHash: utils.ReverseBytesCopy(blockhash),
+	})
+}
 
-	blockhash, err := h.db.GetBlockHashByHeight(uint32(height))
-	if err != nil {
-		logging.L.Err(err).Msg("could not fetch block hash")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not fetch block hash"})
-		return
+// resolveBlock reads the requested block from either the :blockheight or the :blockhash path parameter.
+// Hashes are given in display order and have to be on the best chain.
+// Cache headers are set for the block. If ok is false the error response
+// or a 304 for a matching If-None-Match was already written.
+func (h *Handler) resolveBlock(c *gin.Context) (height uint32, blockhash []byte, ok bool) {
+	if !consumeHeights(c, 1) {
+		return 0, nil, false
 	}
 
-	c.JSON(http.StatusOK, api.BlockHashResponseOracle{
-		BlockHash: hex.EncodeToString(utils.ReverseBytesCopy(blockhash)),
-	})
-}
+	if hashStr := c.Param("blockhash"); hashStr != "" {
+		hashBytes, err := hex.DecodeString(hashStr)
+		if err != nil || len(hashBytes) != 32 {
+			writeBadRequest(c, errors.New("could not parse block hash"))
+			return 0, nil, false
+		}
+		height, blockhash, err = h.query.BlockByHash(hashBytes)
+		if err != nil {
+			writeError(c, err, "could not fetch block height")
+			return 0, nil, false
+		}
+		return height, blockhash, !h.setCacheHeaders(c, height, blockhash)
+	}
 
-// GetUtxos returns UTXO information for a specific block
-func (h *Handler) GetUtxos(c *gin.Context) {
 	heightStr := c.Param("blockheight")
 	if heightStr == "" {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("block height is required")))
-		return
+		writeBadRequest(c, errors.New("block height is required"))
+		return 0, nil, false
 	}
 
-	height, err := strconv.ParseUint(heightStr, 10, 32)
+	height64, err := strconv.ParseUint(heightStr, 10, 32)
 	if err != nil {
-		logging.L.Err(err).Msg("could not parse block height")
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("could not parse block height")))
-		return
+		logging.L.Debug().Err(err).Msg("could not parse block height")
+		writeBadRequest(c, errors.New("could not parse block height"))
+		return 0, nil, false
 	}
+	height = uint32(height64)
 
-	blockhash, err := h.db.GetBlockHashByHeight(uint32(height))
+	blockhash, err = h.query.BlockByHeight(height)
 	if err != nil {
-		logging.L.Err(err).Msg("could not fetch block hash")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not fetch block hash")))
-		return
+		writeError(c, err, "could not fetch block hash")
+		return 0, nil, false
 	}
 
-	// Get chain tip for FetchOutputsAll
-	_, syncTip, err := h.db.GetChainTip()
-	if err != nil {
-		logging.L.Err(err).Msg("could not fetch chain tip")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not fetch chain tip")))
+	return height, blockhash, !h.setCacheHeaders(c, height, blockhash)
+}
+
+// GetUtxos returns UTXO information for a specific block
+func (h *Handler) GetUtxos(c *gin.Context) {
+	height, blockhash, ok := h.resolveBlock(c)
+	if !ok {
 		return
 	}
 
-	outputs, err := h.db.FetchOutputsAll(blockhash, syncTip)
+	utxos, err := h.query.UTXOs(height, blockhash)
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching outputs")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not retrieve data from database")))
+		writeError(c, err, "could not retrieve data from database")
 		return
 	}
 
-	// Convert outputs to the expected format
-	var utxoItems []UTXOItem
-	for _, output := range outputs {
-		if output != nil {
-			var pubkey [32]byte
-			copy(pubkey[:], output.Pubkey)
-
-			utxoItems = append(utxoItems, UTXOItem{
-				TxId:   [32]byte(utils.ReverseBytesCopy(output.Txid)),
-				Vout:   output.Vout,
-				Amount: output.Amount,
-				Pubkey: pubkey,
-			})
-		}
-	}
-
-	response := struct {
-		BlockIdentifier BlockIdentifier `json:"block_identifier"`
-		Index           []UTXOItem      `json:"index"`
-	}{
-		BlockIdentifier: BlockIdentifier{
-			BlockHash:   utils.ReverseBytesCopy(blockhash),
-			BlockHeight: uint32(height),
-		},
-		Index: utxoItems,
-	}
-
-	c.JSON(http.StatusOK, response)
+	render(c, http.StatusOK, newUTXOResponse(utxos))
 }
 
 // GetTweaks returns a simple list of tweaks as 33-byte public keys
 func (h *Handler) GetTweaks(c *gin.Context) {
-	heightStr := c.Param("blockheight")
-	if heightStr == "" {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("block height is required")))
+	height, blockhash, ok := h.resolveBlock(c)
+	if !ok {
 		return
 	}
 
-	height, err := strconv.ParseUint(heightStr, 10, 32)
+	response, err := h.tweakIndexForBlock(height, blockhash)
 	if err != nil {
-		logging.L.Err(err).Msg("could not parse block height")
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("could not parse block height")))
+		writeError(c, err, "could not retrieve tweaks from database")
 		return
 	}
 
-	blockhash, err := h.db.GetBlockHashByHeight(uint32(height))
-	if err != nil {
-		logging.L.Err(err).Msg("could not fetch block hash")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not fetch block hash")))
-		return
-	}
+	render(c, http.StatusOK, response)
+}
 
-	tweakRows, err := h.db.TweaksForBlockAll(blockhash)
+// tweakIndexForBlock builds the tweak index response for a single block
+func (h *Handler) tweakIndexForBlock(height uint32, blockhash []byte) (*TweakIndexResponse, error) {
+	tweaks, err := h.query.Tweaks(height, blockhash)
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching tweak rows")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not retrieve tweaks from database")))
-		return
-	}
-
-	// Convert tweaks to hex strings
-	tweaksOut := make([][33]byte, 0, len(tweakRows))
-	for _, tweakRow := range tweakRows {
-		if tweakRow != nil {
-			tweaksOut = append(tweaksOut, tweakRow.Tweak)
-		}
+		return nil, err
 	}
-
-	response := TweakIndexResponse{
-		BlockIdentifier: BlockIdentifier{
-			BlockHash:   utils.ReverseBytesCopy(blockhash),
-			BlockHeight: uint32(height),
-		},
-		Index: tweaksOut,
-	}
-
-	c.JSON(http.StatusOK, response)
+	return newTweakIndexResponse(tweaks), nil
 }
 
 // GetSpentOutputs returns spent output information in a compact format
 func (h *Handler) GetSpentOutputs(c *gin.Context) {
-	heightStr := c.Param("blockheight")
-	if heightStr == "" {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("block height is required")))
-		return
-	}
-
-	height, err := strconv.ParseUint(heightStr, 10, 32)
-	if err != nil {
-		logging.L.Err(err).Msg("could not parse block height")
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("could not parse block height")))
+	height, blockhash, ok := h.resolveBlock(c)
+	if !ok {
 		return
 	}
 
-	blockhash, err := h.db.GetBlockHashByHeight(uint32(height))
+	spent, err := h.query.SpentOutputs(height, blockhash)
 	if err != nil {
-		logging.L.Err(err).Msg("could not fetch block hash")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not fetch block hash")))
+		writeError(c, err, "could not retrieve spent outputs from database")
 		return
 	}
 
-	// Fetch spent outputs data
-	spentOutputsData, err := h.db.FetchSpentOutputsShort(blockhash)
-	if err != nil {
-		logging.L.Err(err).Msg("error fetching spent outputs")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not retrieve spent outputs from database")))
-		return
-	}
-
-	// Convert spent outputs data to SpentIndex format
-	var spentOutputsShort SpentIndex
-	if len(spentOutputsData) > 0 {
-		for i := 0; i+8 <= len(spentOutputsData); i += 8 {
-			// if i+8 <= len(spentOutputsData) {
-			var outputBytes [8]byte
-			copy(outputBytes[:], spentOutputsData[i:i+8])
-			spentOutputsShort = append(spentOutputsShort, outputBytes)
-			// }
-		}
-	}
-
-	response := SpentIndexResponse{
-		BlockIdentifier: BlockIdentifier{
-			BlockHash:   utils.ReverseBytesCopy(blockhash),
-			BlockHeight: uint32(height),
-		},
-		Index: spentOutputsShort,
-	}
-
-	c.JSON(http.StatusOK, response)
+	render(c, http.StatusOK, newSpentIndexResponse(spent))
 }
 
 // GetComputeIndex returns a compact transaction index with tweak mappings
 func (h *Handler) GetComputeIndex(c *gin.Context) {
-	heightStr := c.Param("blockheight")
-	if heightStr == "" {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("block height is required")))
+	height, blockhash, ok := h.resolveBlock(c)
+	if !ok {
 		return
 	}
 
-	height, err := strconv.ParseUint(heightStr, 10, 32)
+	response, err := h.computeIndexForBlock(height, blockhash)
 	if err != nil {
-		logging.L.Err(err).Msg("could not parse block height")
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("could not parse block height")))
+		writeError(c, err, "could not retrieve data from database")
 		return
 	}
 
-	blockhash, err := h.db.GetBlockHashByHeight(uint32(height))
-	if err != nil {
-		logging.L.Err(err).Msg("could not fetch block hash")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not fetch block hash")))
-		return
-	}
+	render(c, http.StatusOK, response)
+}
 
-	computeIndexItems, err := h.db.FetchComputeIndex(uint32(height))
+// computeIndexForBlock builds the compute index response for a single block
+func (h *Handler) computeIndexForBlock(height uint32, blockhash []byte) (*ComputeIndexResponse, error) {
+	computeIndex, err := h.query.ComputeIndex(height, blockhash, query.ComputeIndexFilter{})
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching compute index")
-		c.JSON(
-			http.StatusInternalServerError,
-			NewErrorResponse(errors.New("could not retrieve data from database")),
-		)
-		return
+		return nil, err
 	}
-
-	// Handle empty data gracefully - return empty slice instead of null
-	var indexItems []ComputeIndexItem
-	if len(computeIndexItems) == 0 {
-		indexItems = []ComputeIndexItem{}
-	} else {
-		// Convert to the expected format
-		for _, item := range computeIndexItems {
-			if item != nil {
-				// Convert outputs short from []byte to OutputsShort
-				var outputsShort OutputsShort
-				outputsData := item.OutputsShort
-				for i := 0; i < len(outputsData); i += 8 {
-					if i+8 <= len(outputsData) {
-						var outputBytes [8]byte
-						copy(outputBytes[:], outputsData[i:i+8])
-						outputsShort = append(outputsShort, outputBytes)
-					}
-				}
-
-				indexItems = append(indexItems, ComputeIndexItem{
-					TxId:         [32]byte(item.Txid[:]),
-					Tweak:        [33]byte(item.Tweak[:]),
-					OutputsShort: outputsShort,
-				})
-			}
-		}
-	}
-
-	response := ComputeIndexResponse{
-		BlockIdentifier: BlockIdentifier{
-			BlockHash:   utils.ReverseBytesCopy(blockhash),
-			BlockHeight: uint32(height),
-		},
-		Index: indexItems,
-	}
-
-	c.JSON(http.StatusOK, response)
+	return newComputeIndexResponse(computeIndex), nil
 }
 
 // GetFullBlock returns complete block data with all transaction details
 func (h *Handler) GetFullBlock(c *gin.Context) {
-	heightStr := c.Param("blockheight")
-	if heightStr == "" {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("block height is required")))
-		return
-	}
-
-	height, err := strconv.ParseUint(heightStr, 10, 32)
-	if err != nil {
-		logging.L.Err(err).Msg("could not parse block height")
-		c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("could not parse block height")))
-		return
-	}
-
-	blockhash, err := h.db.GetBlockHashByHeight(uint32(height))
-	if err != nil {
-		logging.L.Err(err).Msg("could not fetch block hash")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not fetch block hash")))
-		return
-	}
-
-	// Get chain tip for FetchOutputsAll
-	_, syncTip, err := h.db.GetChainTip()
-	if err != nil {
-		logging.L.Err(err).Msg("could not fetch chain tip")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not fetch chain tip")))
-		return
-	}
-
-	// Fetch all the data we need for the full block
-	outputs, err := h.db.FetchOutputsAll(blockhash, syncTip)
-	if err != nil {
-		logging.L.Err(err).Msg("error fetching outputs")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not retrieve outputs from database")))
+	height, blockhash, ok := h.resolveBlock(c)
+	if !ok {
 		return
 	}
 
-	// Fetch tweaks with transaction IDs
-	tweakRows, err := h.db.TweaksForBlockAll(blockhash)
+	fullBlock, err := h.query.FullBlock(height, blockhash)
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching tweak rows")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not retrieve tweaks from database")))
+		writeError(c, err, "could not retrieve data from database")
 		return
 	}
 
-	// Group outputs by transaction ID
-	txOutputs := make(map[string][]*database.Output)
-	for _, output := range outputs {
-		if output != nil {
-			txid := hex.EncodeToString(output.Txid)
-			txOutputs[txid] = append(txOutputs[txid], output)
-		}
-	}
-
-	// Create a map of txid to tweak for quick lookup
-	txidToTweak := make(map[string][33]byte)
-	for _, tweakRow := range tweakRows {
-		if tweakRow != nil {
-			txid := hex.EncodeToString(tweakRow.Txid[:])
-			txidToTweak[txid] = tweakRow.Tweak
-		}
-	}
-
-	// Fetch all txid-outpoints mappings for this block
-	txidOutpointsMap, err := h.db.FetchAllTxidOutpointsForBlock(blockhash)
-	if err != nil {
-		logging.L.Err(err).Msg("error fetching txid-outpoints mappings")
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not retrieve txid-outpoints from database")))
-		return
-	}
-
-	// Build the full transaction items
-	var fullTxItems []FullTxItem
-
-	// Sort transaction IDs to ensure consistent ordering
-	var sortedTxids []string
-	for txid := range txOutputs {
-		sortedTxids = append(sortedTxids, txid)
-	}
-	sort.Strings(sortedTxids)
-
-	for _, txid := range sortedTxids {
-		outputs := txOutputs[txid]
-		txidBytes, err := hex.DecodeString(txid)
-		if err != nil {
-			logging.L.Err(err).Msg("error decoding txid")
-			continue
-		}
-
-		var txidArray [32]byte
-		copy(txidArray[:], txidBytes)
-
-		// Get tweak for this transaction
-		var tweak [33]byte
-		if tweakBytes, exists := txidToTweak[txid]; exists {
-			tweak = tweakBytes
-		}
-
-		// Get inputs (spent outpoints) for this transaction
-		var inputs SpentOutpoints
-		if outpoints, exists := txidOutpointsMap[txidArray]; exists {
-			for i := range outpoints {
-				utils.ReverseBytes(outpoints[i][:32])
-			}
-			inputs = SpentOutpoints(outpoints)
-		}
-
-		// Convert outputs to UTXO items
-		var utxoItems []UTXOItemLight
-		for _, output := range outputs {
-			var pubkey [32]byte
-			copy(pubkey[:], output.Pubkey)
-
-			utxoItems = append(utxoItems, UTXOItemLight{
-				Vout:   output.Vout,
-				Amount: output.Amount,
-				Pubkey: pubkey,
-			})
-		}
-
-		fullTxItems = append(fullTxItems, FullTxItem{
-			TxId:   [32]byte(utils.ReverseBytesCopy(txidArray[:])),
-			Tweak:  tweak,
-			Inputs: inputs,
-			UTXOs:  utxoItems,
-		})
-	}
-
-	response := FullBlockResponse{
-		BlockIdentifier: BlockIdentifier{
-			BlockHash:   utils.ReverseBytesCopy(blockhash),
-			BlockHeight: uint32(height),
-		},
-		Index: fullTxItems,
-	}
-
-	c.JSON(http.StatusOK, response)
+	render(c, http.StatusOK, newFullBlockResponse(fullBlock))
 }
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/health"
+)
+
+// Healthz is the liveness check, the process answers and the database can be read
+func (h *Handler) Healthz(c *gin.Context) {
+	if _, _, err := h.db.GetChainTip(); err != nil {
+		logging.L.Err(err).Msg("health check: could not read chain tip")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": "database not readable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz is the readiness check, see health.Ready
+func (h *Handler) Readyz(c *gin.Context) {
+	if err := health.Ready(); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
@@ -0,0 +1,58 @@
+package server_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/health"
+)
+
+func TestReadyz(t *testing.T) {
+	router, _ := newTestClients(t, newTestStore(t))
+	savedLag := config.ReadinessMaxLag
+	config.ReadinessMaxLag = 3
+	t.Cleanup(func() { config.ReadinessMaxLag = savedLag })
+
+	steps := []struct {
+		name  string
+		apply func()
+		ready bool
+	}{
+		{name: "initial sync", apply: func() {
+			health.SetIndexerRunning()
+			health.SetInitialSync(true)
+			health.SetSyncState(1, 2)
+		}},
+		{name: "synced", apply: func() {
+			health.SetSyncState(2, 2)
+			health.SetInitialSync(false)
+		}, ready: true},
+		{name: "lag above the limit", apply: func() { health.SetSyncState(2, 6) }},
+		{name: "caught up", apply: func() { health.SetSyncState(6, 6) }, ready: true},
+		{name: "builder error", apply: func() { health.SetBuilderError(errors.New("core unreachable")) }},
+		{name: "builder running again", apply: func() { health.SetBuilderError(nil) }, ready: true},
+	}
+
+	for _, step := range steps {
+		step.apply()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		want := http.StatusServiceUnavailable
+		if step.ready {
+			want = http.StatusOK
+		}
+		if rec.Code != want {
+			t.Errorf("%s: status %d, want %d: %s", step.name, rec.Code, want, rec.Body.String())
+		}
+
+		// liveness does not depend on the sync state
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: healthz status %d", step.name, rec.Code)
+		}
+	}
+}
@@ -1 +1,128 @@
 package server
+
+import (
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/access"
+	"github.com/setavenger/blindbit-oracle/internal/metrics"
+)
+
+// recoveryMiddleware answers a panicking request with a 500 like gin.Recovery,
+// but passes http.ErrAbortHandler on so net/http cuts the connection (see streamRange)
+func recoveryMiddleware(c *gin.Context) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			return
+		}
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+		logging.L.Error().Any("panic", err).Bytes("stack", debug.Stack()).Msg("handler panicked")
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}()
+	c.Next()
+}
+
+// metricsMiddleware counts requests and records their latency per route template,
+// so /tweaks/850000 and /tweaks/850001 share one series
+func metricsMiddleware(c *gin.Context) {
+	start := time.Now()
+	// deferred so panics are recorded too, recoveryMiddleware answers them with a 500 afterwards
+	panicked := true
+	defer func() {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		if panicked && !c.Writer.Written() {
+			status = http.StatusInternalServerError
+		}
+		metrics.HTTPRequests.
+			WithLabelValues(route, c.Request.Method, strconv.Itoa(status)).
+			Inc()
+		metrics.HTTPRequestDuration.
+			WithLabelValues(route, c.Request.Method).
+			Observe(time.Since(start).Seconds())
+	}()
+	c.Next()
+	panicked = false
+}
+
+// grpcWebMiddleware hands gRPC-Web calls, their CORS preflights and websockets to the wrapped gRPC server.
+// Global middlewares also run for unmatched routes, so the gRPC paths need no route of their own.
+func grpcWebMiddleware(grpcWeb *grpcweb.WrappedGrpcServer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := c.Request
+		if grpcWeb.IsGrpcWebRequest(req) ||
+			grpcWeb.IsAcceptableGrpcCorsRequest(req) ||
+			grpcWeb.IsGrpcWebSocketRequest(req) {
+			// gin presets 404 for unmatched routes, the gRPC status is sent in the trailers
+			c.Status(http.StatusOK)
+			grpcWeb.ServeHTTP(c.Writer, req)
+			c.Abort()
+		}
+	}
+}
+
+// clientIDKey is where accessMiddleware stores the client for the height quota
+const clientIDKey = "access_client_id"
+
+// accessMiddleware checks the API key and the client's rate limit, see package access.
+// Health checks and metrics are registered outside of it.
+func accessMiddleware(c *gin.Context) {
+	limiter := access.Shared()
+
+	apiKey := requestAPIKey(c)
+	if err := limiter.Authenticate(apiKey); err != nil {
+		writeError(c, err, "")
+		c.Abort()
+		return
+	}
+
+	clientID := limiter.ClientID(apiKey, c.ClientIP())
+	if wait, err := limiter.Allow(clientID); err != nil {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(c, err, "")
+		c.Abort()
+		return
+	}
+
+	c.Set(clientIDKey, clientID)
+	c.Next()
+}
+
+// requestAPIKey reads the key from X-API-Key or an Authorization bearer token
+func requestAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// consumeHeights counts n requested heights against the client's quota.
+// If false is returned the error response was already written.
+func consumeHeights(c *gin.Context, n uint64) bool {
+	limiter := access.Shared()
+	clientID := c.GetString(clientIDKey)
+	if clientID == "" {
+		clientID = limiter.ClientID(requestAPIKey(c), c.ClientIP())
+	}
+	if err := limiter.ConsumeHeights(clientID, n); err != nil {
+		writeError(c, err, "")
+		return false
+	}
+	return true
+}
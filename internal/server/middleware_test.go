@@ -0,0 +1,31 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/setavenger/blindbit-oracle/internal/metrics"
+	"github.com/setavenger/blindbit-oracle/internal/server"
+)
+
+func TestMetricsCountPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := server.NewRouter(server.NewHandler(newTestStore(t)), nil)
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	requests := metrics.HTTPRequests.WithLabelValues("/panic", http.MethodGet, "500")
+	before := testutil.ToFloat64(requests)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if got := testutil.ToFloat64(requests) - before; got != 1 {
+		t.Errorf("counted %v panicking requests as 500", got)
+	}
+}
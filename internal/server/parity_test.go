@@ -0,0 +1,437 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/server"
+	v2 "github.com/setavenger/blindbit-oracle/internal/server/v2"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// newTestStore indexes two blocks: block 1 has a tx with a tweak and a tx without,
+// block 2 spends an output of block 1 in a tx with a tweak
+func newTestStore(t *testing.T) database.DB {
+	t.Helper()
+	fill := func(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+	tweak1 := [33]byte{0x02, 0x11}
+	tweak2 := [33]byte{0x03, 0x22}
+	txA, txB, txC := fill(0xa1), fill(0xb2), fill(0xc3)
+
+	blocks := []*database.DBBlock{
+		{
+			Height: 1,
+			Hash:   &chainhash.Hash{0x01},
+			Txs: []*database.Tx{
+				{
+					Txid:  txA,
+					Tweak: &tweak1,
+					Outs: []*database.Output{
+						{Txid: txA, Vout: 0, Amount: 1_000, Pubkey: fill(0x0a)},
+						{Txid: txA, Vout: 1, Amount: 500, Pubkey: fill(0x1a)},
+					},
+				},
+				{
+					Txid: txB,
+					Outs: []*database.Output{
+						{Txid: txB, Vout: 0, Amount: 2_000, Pubkey: fill(0x0b)},
+					},
+				},
+			},
+		},
+		{
+			Height: 2,
+			Hash:   &chainhash.Hash{0x02},
+			Txs: []*database.Tx{
+				{
+					Txid:  txC,
+					Tweak: &tweak2,
+					Ins: []*database.In{
+						{SpendTxid: txC, Idx: 0, PrevTxid: txA, PrevVout: 0, Pubkey: fill(0x0a)},
+					},
+					Outs: []*database.Output{
+						{Txid: txC, Vout: 1, Amount: 3_000, Pubkey: fill(0x1c)},
+					},
+				},
+			},
+		},
+	}
+	return testhelpers.NewStore(t, blocks...)
+}
+
+func newTestClients(t *testing.T, db database.DB) (http.Handler, pb.OracleServiceClient) {
+	t.Helper()
+	config.Chain = config.Regtest
+	gin.SetMode(gin.TestMode)
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	pb.RegisterOracleServiceServer(grpcServer, v2.NewOracleService(db))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	router := server.NewRouter(server.NewHandler(db), v2.NewGRPCWebServer(grpcServer))
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return router, pb.NewOracleServiceClient(conn)
+}
+
+func get(t *testing.T, router http.Handler, path, accept string) []byte {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept", accept)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", path, rec.Code, rec.Body.String())
+	}
+	return rec.Body.Bytes()
+}
+
+func height(h uint64) *pb.BlockHeightRequest {
+	return &pb.BlockHeightRequest{Block: &pb.BlockHeightRequest_BlockHeight{BlockHeight: h}}
+}
+
+func firstOfStream[T any](t *testing.T, stream grpc.ServerStreamingClient[T], err error) *T {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); err != io.EOF {
+		t.Fatalf("expected the end of the stream, got %v", err)
+	}
+	return msg
+}
+
+// TestHTTPMatchesGRPC requests the protobuf encoding over HTTP,
+// which has to be the message the gRPC server returns for the same block
+func TestHTTPMatchesGRPC(t *testing.T) {
+	router, client := newTestClients(t, newTestStore(t))
+	ctx := context.Background()
+
+	cases := []struct {
+		path string
+		want func() (proto.Message, error)
+		got  proto.Message
+	}{
+		{"/info", func() (proto.Message, error) {
+			info, err := client.GetInfo(ctx, &emptypb.Empty{})
+			if err != nil {
+				return nil, err
+			}
+			if !info.TweaksFullWithDustFilter || !info.TweaksCutThroughWithDustFilter {
+				t.Error("gRPC reports no filters")
+			}
+			// HTTP /info reports the storage flags, none of which are set here
+			info.TweaksFullWithDustFilter, info.TweaksCutThroughWithDustFilter = false, false
+			return info, nil
+		}, &pb.InfoResponse{}},
+		{"/tweaks/1", func() (proto.Message, error) {
+			return client.GetTweaks(ctx, height(1))
+		}, &pb.IndexResponse{}},
+		{"/tweaks/2", func() (proto.Message, error) {
+			return client.GetTweaks(ctx, height(2))
+		}, &pb.IndexResponse{}},
+		{"/utxos/1", func() (proto.Message, error) {
+			return client.GetUtxos(ctx, height(1))
+		}, &pb.UTXOResponse{}},
+		{"/spent-outputs/2", func() (proto.Message, error) {
+			return client.GetSpentOutputs(ctx, height(2))
+		}, &pb.IndexResponse{}},
+		{"/compute-index/1", func() (proto.Message, error) {
+			stream, err := client.StreamComputeIndex(ctx, &pb.RangedBlockHeightRequestFiltered{Start: 1, End: 1})
+			return firstOfStream(t, stream, err), nil
+		}, &pb.ComputeIndexResponse{}},
+		{"/compute-index/2", func() (proto.Message, error) {
+			stream, err := client.StreamComputeIndex(ctx, &pb.RangedBlockHeightRequestFiltered{Start: 2, End: 2})
+			return firstOfStream(t, stream, err), nil
+		}, &pb.ComputeIndexResponse{}},
+		{"/full-block/1", func() (proto.Message, error) {
+			return client.GetFullBlock(ctx, height(1))
+		}, &pb.FullBlockResponse{}},
+		{"/full-block/2", func() (proto.Message, error) {
+			return client.GetFullBlock(ctx, height(2))
+		}, &pb.FullBlockResponse{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			want, err := tc.want()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = proto.Unmarshal(get(t, router, tc.path, "application/x-protobuf"), tc.got); err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(tc.got, want) {
+				t.Errorf("HTTP and gRPC differ\nhttp: %v\ngrpc: %v", tc.got, want)
+			}
+		})
+	}
+}
+
+// TestHTTPGolden pins the JSON responses, run with -update to rewrite testdata
+func TestHTTPGolden(t *testing.T) {
+	router, _ := newTestClients(t, newTestStore(t))
+
+	paths := []string{
+		"/info",
+		"/tweaks/1",
+		"/utxos/1",
+		"/spent-outputs/2",
+		"/compute-index/1",
+		"/full-block/1",
+		"/full-block/2",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := json.Indent(&out, get(t, router, path, "application/json"), "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			out.WriteByte('\n')
+
+			name := filepath.Join("testdata", strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")+".golden.json")
+			if *update {
+				if err := os.WriteFile(name, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("%s differs from %s\ngot:\n%s", path, name, out.String())
+			}
+		})
+	}
+}
+
+// TestRangeChecks both transports reject bad ranges and end the stream at the tip
+func TestRangeChecks(t *testing.T) {
+	router, client := newTestClients(t, newTestStore(t))
+	ctx := context.Background()
+
+	cases := []struct {
+		start, end uint64
+		httpStatus int
+		grpcCode   codes.Code
+		blocks     int
+	}{
+		{start: 1, end: 2, httpStatus: http.StatusOK, grpcCode: codes.OK, blocks: 2},
+		{start: 1, end: 50, httpStatus: http.StatusOK, grpcCode: codes.OK, blocks: 2},
+		{start: 2, end: 1, httpStatus: http.StatusBadRequest, grpcCode: codes.InvalidArgument},
+		{start: 0, end: uint64(config.MaxRangePerRequest), httpStatus: http.StatusBadRequest, grpcCode: codes.InvalidArgument},
+		{start: 3, end: 4, httpStatus: http.StatusTooEarly, grpcCode: codes.OutOfRange},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%d-%d", tc.start, tc.end), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/compute-index?start=%d&end=%d", tc.start, tc.end), nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tc.httpStatus {
+				t.Errorf("http status %d, want %d", rec.Code, tc.httpStatus)
+			}
+
+			stream, err := client.StreamComputeIndex(ctx, &pb.RangedBlockHeightRequestFiltered{Start: tc.start, End: tc.end})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var blocks int
+			for {
+				_, err = stream.Recv()
+				if err != nil {
+					break
+				}
+				blocks++
+			}
+			if err != io.EOF && status.Code(err) != tc.grpcCode {
+				t.Errorf("grpc code %v, want %v", status.Code(err), tc.grpcCode)
+			}
+			if err == io.EOF && tc.grpcCode != codes.OK {
+				t.Errorf("stream ended without error, want %v", tc.grpcCode)
+			}
+			if blocks != tc.blocks {
+				t.Errorf("got %d blocks, want %d", blocks, tc.blocks)
+			}
+		})
+	}
+}
+
+// TestGRPCWebStream streams over gRPC-Web on the HTTP router like a browser would
+// and expects the messages of the native stream
+func TestGRPCWebStream(t *testing.T) {
+	router, client := newTestClients(t, newTestStore(t))
+	httpServer := httptest.NewServer(router)
+	t.Cleanup(httpServer.Close)
+
+	req := &pb.RangedBlockHeightRequestFiltered{Start: 1, End: 2}
+	msg, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(msg)))
+	body = append(body, msg...)
+
+	httpReq, err := http.NewRequest(
+		http.MethodPost,
+		httpServer.URL+"/blindbit.oracle.v1.OracleService/StreamComputeIndex",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.Header.Set("Content-Type", "application/grpc-web+proto")
+	httpReq.Header.Set("Origin", "https://wallet.example")
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") == "" {
+		t.Error("no CORS headers for the browser")
+	}
+
+	// grpc-web frames: 1 byte flags (0x80 for trailers), 4 bytes length, payload
+	var got []*pb.ComputeIndexResponse
+	var trailers string
+	for {
+		var header [5]byte
+		if _, err = io.ReadFull(resp.Body, header[:]); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+		if _, err = io.ReadFull(resp.Body, payload); err != nil {
+			t.Fatal(err)
+		}
+		if header[0]&0x80 != 0 {
+			trailers = string(payload)
+			continue
+		}
+		item := &pb.ComputeIndexResponse{}
+		if err = proto.Unmarshal(payload, item); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, item)
+	}
+	if !strings.Contains(trailers, "grpc-status: 0") {
+		t.Errorf("unexpected trailers %q", trailers)
+	}
+
+	stream, err := client.StreamComputeIndex(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []*pb.ComputeIndexResponse
+	for {
+		item, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, item)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages over gRPC-Web, %d natively", len(got), len(want))
+	}
+	for i := range want {
+		if !proto.Equal(got[i], want[i]) {
+			t.Errorf("message %d differs\nweb:    %v\nnative: %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnixSocketH2C(t *testing.T) {
+	db := newTestStore(t)
+	newTestClients(t, db)
+
+	path := filepath.Join(t.TempDir(), "http.sock")
+	config.HTTPHost = "unix://" + path
+	config.HTTPH2C = true
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go server.RunServer(ctx, server.NewHandler(db), nil, nil)
+
+	// prior knowledge HTTP/2 without TLS, like a local wallet daemon would connect
+	transport := &http.Transport{
+		Protocols: new(http.Protocols),
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	t.Cleanup(transport.CloseIdleConnections)
+	client := &http.Client{Transport: transport}
+
+	var resp *http.Response
+	var err error
+	for range 100 {
+		if resp, err = client.Get("http://oracle/info"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status %d", resp.StatusCode)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("served over %s, want HTTP/2", resp.Proto)
+	}
+}
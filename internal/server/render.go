@@ -0,0 +1,132 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/setavenger/blindbit-lib/logging"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeCBOR     = "application/cbor"
+)
+
+// offeredFormats in order of preference, JSON is the default if no Accept header is sent
+var offeredFormats = []string{gin.MIMEJSON, contentTypeProtobuf, contentTypeCBOR}
+
+// protoMessage is implemented by responses which have a protobuf representation.
+// The messages are the same ones the gRPC server returns.
+type protoMessage interface {
+	Proto() proto.Message
+}
+
+// cborEncMode encodes fixed size byte arrays (txids, tweaks, pubkeys) as byte strings
+// instead of arrays of integers
+var cborEncMode cbor.EncMode
+
+func init() {
+	var err error
+	cborEncMode, err = cbor.EncOptions{
+		ByteArray: cbor.ByteArrayToByteSlice,
+	}.EncMode()
+	if err != nil {
+		logging.L.Panic().Err(err).Msg("invalid cbor options")
+	}
+}
+
+// render writes obj in the format negotiated from the Accept header.
+// Protobuf is only possible if obj implements protoMessage,
+// everything else (e.g. error responses) falls back to JSON.
+func render(c *gin.Context, code int, obj any) {
+	c.Header("Vary", "Accept")
+
+	switch negotiateFormat(c, offeredFormats...) {
+	case contentTypeProtobuf:
+		msg, ok := obj.(protoMessage)
+		if !ok {
+			c.JSON(code, obj)
+			return
+		}
+		data, err := proto.Marshal(msg.Proto())
+		if err != nil {
+			logging.L.Err(err).Msg("failed to marshal protobuf response")
+			setNoStore(c)
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not encode response")))
+			return
+		}
+		c.Data(code, contentTypeProtobuf, data)
+	case contentTypeCBOR:
+		data, err := cborEncMode.Marshal(obj)
+		if err != nil {
+			logging.L.Err(err).Msg("failed to marshal cbor response")
+			setNoStore(c)
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(errors.New("could not encode response")))
+			return
+		}
+		c.Data(code, contentTypeCBOR, data)
+	default:
+		c.JSON(code, obj)
+	}
+}
+
+// negotiateFormat picks the offered content type with the highest q-value in the Accept header,
+// ties go to the earlier offer. gin's NegotiateFormat ignores q-values.
+// Without an Accept header the first offer is used, "" if none is acceptable.
+func negotiateFormat(c *gin.Context, offered ...string) string {
+	accept := c.GetHeader("Accept")
+	if accept == "" {
+		return offered[0]
+	}
+
+	var best string
+	var bestQ float64
+	for _, offer := range offered {
+		if q := acceptQuality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the q-value of the most specific media range in accept matching contentType
+func acceptQuality(accept, contentType string) float64 {
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, _ := strings.Cut(part, ";")
+		mediaRange = strings.ToLower(strings.TrimSpace(mediaRange))
+
+		var s int
+		switch {
+		case mediaRange == contentType:
+			s = 2
+		case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*")):
+			s = 1
+		case mediaRange == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s < specificity {
+			continue
+		}
+
+		partQ := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(param, "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				partQ = v
+			}
+		}
+		q, specificity = partQ, s
+	}
+	return q
+}
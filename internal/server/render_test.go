@@ -0,0 +1,168 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// cborIndex is the CBOR form of the tweak index, byte arrays are byte strings
+type cborIndex struct {
+	BlockIdentifier struct {
+		BlockHash   []byte `cbor:"block_hash"`
+		BlockHeight uint64 `cbor:"block_height"`
+	} `cbor:"block_identifier"`
+	Index [][]byte `cbor:"index"`
+}
+
+func TestContentNegotiation(t *testing.T) {
+	router, _ := newTestClients(t, newTestStore(t))
+	wantTweak, _ := hex.DecodeString("0211" + "00000000000000000000000000000000000000000000000000000000000000")
+
+	cases := []struct {
+		accept      string
+		contentType string
+	}{
+		{accept: "", contentType: "application/json"},
+		{accept: "text/html", contentType: "application/json"},
+		{accept: "application/x-protobuf", contentType: "application/x-protobuf"},
+		{accept: "application/cbor", contentType: "application/cbor"},
+		{accept: "application/cbor;q=0.5, application/x-protobuf", contentType: "application/x-protobuf"},
+		{accept: "application/*;q=0.1, application/cbor", contentType: "application/cbor"},
+		{accept: "application/json;q=0, */*", contentType: "application/x-protobuf"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tweaks/1", nil)
+			req.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); !bytes.HasPrefix([]byte(ct), []byte(tc.contentType)) {
+				t.Fatalf("content type %q, want %q", ct, tc.contentType)
+			}
+			if rec.Header().Get("Vary") != "Accept" {
+				t.Error("responses differing by Accept without Vary")
+			}
+
+			var height uint64
+			var tweaks [][]byte
+			switch tc.contentType {
+			case "application/x-protobuf":
+				var msg pb.IndexResponse
+				if err := proto.Unmarshal(rec.Body.Bytes(), &msg); err != nil {
+					t.Fatal(err)
+				}
+				height, tweaks = msg.GetBlockIdentifier().GetBlockHeight(), msg.GetIndex()
+			case "application/cbor":
+				var msg cborIndex
+				if err := cbor.Unmarshal(rec.Body.Bytes(), &msg); err != nil {
+					t.Fatal(err)
+				}
+				height, tweaks = msg.BlockIdentifier.BlockHeight, msg.Index
+			default:
+				var msg struct {
+					BlockIdentifier struct {
+						BlockHeight uint64 `json:"block_height"`
+					} `json:"block_identifier"`
+					Index []string `json:"index"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &msg); err != nil {
+					t.Fatal(err)
+				}
+				height = msg.BlockIdentifier.BlockHeight
+				for _, tweak := range msg.Index {
+					b, _ := hex.DecodeString(tweak)
+					tweaks = append(tweaks, b)
+				}
+			}
+			if height != 1 || len(tweaks) != 1 || !bytes.Equal(tweaks[0], wantTweak) {
+				t.Errorf("height %d, tweaks %x", height, tweaks)
+			}
+		})
+	}
+}
+
+// errors have no protobuf or CBOR form and are always JSON
+func TestErrorsAreJSON(t *testing.T) {
+	router, _ := newTestClients(t, newTestStore(t))
+	for _, accept := range []string{"application/x-protobuf", "application/cbor"} {
+		req := httptest.NewRequest(http.MethodGet, "/tweaks/99", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var resp struct {
+			Data struct {
+				Code string `json:"code"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Data.Code != "not_yet_synced" {
+			t.Errorf("%s: %d %s", accept, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestStreamNegotiation(t *testing.T) {
+	router, _ := newTestClients(t, newTestStore(t))
+	httpServer := httptest.NewServer(router)
+	t.Cleanup(httpServer.Close)
+
+	t.Run("protobuf", func(t *testing.T) {
+		resp := streamRequest(t, httpServer.URL+"/tweaks?start=1&end=2", "application/x-protobuf")
+		if ct := resp.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+			t.Errorf("content type %q", ct)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := bytes.NewReader(body)
+		for _, h := range []uint64{1, 2} {
+			var msg pb.IndexResponse
+			if err = protodelim.UnmarshalFrom(r, &msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.GetBlockIdentifier().GetBlockHeight() != h {
+				t.Errorf("got height %d, want %d", msg.GetBlockIdentifier().GetBlockHeight(), h)
+			}
+		}
+		if r.Len() != 0 {
+			t.Errorf("%d bytes after the last block", r.Len())
+		}
+	})
+
+	// plain cbor is answered with a sequence as well
+	for _, accept := range []string{"application/cbor-seq", "application/cbor"} {
+		t.Run(accept, func(t *testing.T) {
+			resp := streamRequest(t, httpServer.URL+"/tweaks?start=1&end=2", accept)
+			if ct := resp.Header.Get("Content-Type"); ct != "application/cbor-seq" {
+				t.Errorf("content type %q", ct)
+			}
+			dec := cbor.NewDecoder(resp.Body)
+			for _, h := range []uint64{1, 2} {
+				var msg cborIndex
+				if err := dec.Decode(&msg); err != nil {
+					t.Fatal(err)
+				}
+				if msg.BlockIdentifier.BlockHeight != h {
+					t.Errorf("got height %d, want %d", msg.BlockIdentifier.BlockHeight, h)
+				}
+			}
+			if err := dec.Decode(new(any)); err != io.EOF {
+				t.Errorf("expected the end of the sequence, got %v", err)
+			}
+		})
+	}
+}
@@ -15,6 +15,15 @@ func NewErrorResponse(error error, extra ...any) *ApiResponse {
 	return &ApiResponse{Success: false, Data: errResp}
 }
 
+// NewErrorResponseWithCode is NewErrorResponse with a machine readable error code
+func NewErrorResponseWithCode(code string, error error, extra ...any) *ApiResponse {
+	resp := NewErrorResponse(error, extra...)
+	errResp := resp.Data.(ErrorResponse)
+	errResp.Code = code
+	resp.Data = errResp
+	return resp
+}
+
 type ApiResponse struct {
 	Success bool `json:"success"`
 	Data    any  `json:"data"`
@@ -26,5 +35,7 @@ type SuccessResponse struct {
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// Code is a stable machine readable identifier, see the ErrCode constants
+	Code  string `json:"code,omitempty"`
 	Extra any    `json:"extra,omitempty"`
 }
@@ -1,46 +1,135 @@
 package server
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/setavenger/blindbit-lib/logging"
 	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/listener"
+	"github.com/setavenger/blindbit-oracle/internal/tlsconfig"
 )
 
-func RunServer(handler *Handler) {
+// RunServer serves the HTTP API until ctx is done, grpcWeb and tlsReloader are optional.
+// On shutdown it stops accepting connections and waits config.ShutdownTimeout
+// for in-flight requests and streams before closing them.
+func RunServer(ctx context.Context, handler *Handler, grpcWeb *grpcweb.WrappedGrpcServer, tlsReloader *tlsconfig.Reloader) {
 	if handler.db == nil {
 		err := errors.New("db of handler was nil")
 		logging.L.Panic().Err(err).Msg("missing db in handler")
 	}
 	gin.SetMode(gin.ReleaseMode)
 
+	httpServer := &http.Server{
+		Handler: NewRouter(handler, grpcWeb),
+	}
+
+	lis, err := listener.Listen(config.HTTPHost)
+	if err != nil {
+		logging.L.Err(err).Msg("could not listen for http")
+		return
+	}
+
+	serveErr := make(chan error, 1)
+	if tlsReloader != nil {
+		httpServer.TLSConfig = tlsReloader.Config()
+		logging.L.Info().Bool("mtls", tlsReloader.MutualTLS()).Msgf("Starting HTTPS server on host %s", config.HTTPHost)
+		// the certificate comes from TLSConfig.GetCertificate, HTTP/2 is negotiated via ALPN
+		go func() { serveErr <- httpServer.ServeTLS(lis, "", "") }()
+	} else {
+		if config.HTTPH2C {
+			// h2c with prior knowledge, HTTP/1.1 clients are served as before
+			httpServer.Protocols = new(http.Protocols)
+			httpServer.Protocols.SetHTTP1(true)
+			httpServer.Protocols.SetUnencryptedHTTP2(true)
+		}
+		logging.L.Info().Bool("h2c", config.HTTPH2C).Msgf("Starting HTTP server on host %s", config.HTTPHost)
+		go func() { serveErr <- httpServer.Serve(lis) }()
+	}
+
+	select {
+	case err = <-serveErr:
+		logging.L.Err(err).Msg("could not run server")
+		return
+	case <-ctx.Done():
+	}
+
+	logging.L.Info().Msg("shutting down HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeout)*time.Second)
+	defer cancel()
+	// hijacked websockets are not waited for, they end with their event subscription or gRPC call
+	if err = httpServer.Shutdown(shutdownCtx); err != nil {
+		logging.L.Warn().Err(err).Msg("HTTP requests still running at the shutdown deadline, closing them")
+		httpServer.Close()
+	}
+}
+
+// NewRouter registers all routes and middlewares of the HTTP API.
+// gRPC-Web requests are handed to grpcWeb if set.
+func NewRouter(handler *Handler, grpcWeb *grpcweb.WrappedGrpcServer) *gin.Engine {
 	// todo merge gin logging into blindbit lib logging
-	router := gin.Default()
-	// router.Use(gin.Recovery())
-	router.Use(gzip.Gzip(gzip.DefaultCompression))
+	router := gin.New()
+	router.Use(gin.Logger(), recoveryMiddleware)
+	// forwarded headers are only believed from configured proxies, the rate limits are per client IP
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		logging.L.Err(err).Strs("trusted_proxies", config.TrustedProxies).Msg("invalid trusted proxies, trusting none")
+		_ = router.SetTrustedProxies(nil)
+	}
+	if grpcWeb != nil {
+		// before compression and access checks, the gRPC interceptors take care of those
+		router.Use(grpcWebMiddleware(grpcWeb))
+	}
+	router.Use(metricsMiddleware)
+	// the event stream has to reach clients unbuffered
+	router.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/events"})))
 
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "PUT"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
-		MaxAge:           12 * time.Hour,
-		AllowCredentials: true,
+		AllowOrigins: config.CORSAllowedOrigins,
+		AllowMethods: []string{"GET", "PUT"},
+		AllowHeaders: []string{"Content-Type", "Authorization", "X-API-Key"},
+		MaxAge:       12 * time.Hour,
+		// browsers reject credentials for a wildcard origin
+		AllowCredentials: !slices.Contains(config.CORSAllowedOrigins, "*"),
 	}))
 
+	// everything except health checks and metrics goes through API keys and rate limits
+	api := router.Group("", accessMiddleware)
+
 	// New API endpoints following README specification
-	router.GET("/info", handler.GetInfo)
-	router.GET("/tweaks/:blockheight", handler.GetTweaks)
-	router.GET("/utxos/:blockheight", handler.GetUtxos)
-	router.GET("/spent-outputs/:blockheight", handler.GetSpentOutputs) // todo: do we really need this?
-	router.GET("/compute-index/:blockheight", handler.GetComputeIndex)
-	router.GET("/full-block/:blockheight", handler.GetFullBlock)
-
-	if err := router.Run(config.HTTPHost); err != nil {
-		logging.L.Err(err).Msg("could not run server")
-	}
+	api.GET("/info", handler.GetInfo)
+	api.GET("/tweaks/:blockheight", handler.GetTweaks)
+	api.GET("/utxos/:blockheight", handler.GetUtxos)
+	api.GET("/spent-outputs/:blockheight", handler.GetSpentOutputs) // todo: do we really need this?
+	api.GET("/compute-index/:blockheight", handler.GetComputeIndex)
+	api.GET("/full-block/:blockheight", handler.GetFullBlock)
+
+	// same data addressed by block hash (display order), the block has to be on the best chain
+	api.GET("/tweaks/by-hash/:blockhash", handler.GetTweaks)
+	api.GET("/utxos/by-hash/:blockhash", handler.GetUtxos)
+	api.GET("/spent-outputs/by-hash/:blockhash", handler.GetSpentOutputs)
+	api.GET("/compute-index/by-hash/:blockhash", handler.GetComputeIndex)
+	api.GET("/full-block/by-hash/:blockhash", handler.GetFullBlock)
+
+	// ranged variants stream one JSON object per block
+	api.GET("/tweaks", handler.StreamTweaks)
+	api.GET("/compute-index", handler.StreamComputeIndex)
+
+	// new and reorged blocks as SSE or WebSocket
+	api.GET("/events", handler.Events)
+
+	// probes and scrapers are not limited
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/healthz", handler.Healthz)
+	router.GET("/readyz", handler.Readyz)
+
+	return router
 }
@@ -0,0 +1,412 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/go-bip352"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/listener"
+	v2 "github.com/setavenger/blindbit-oracle/internal/server/v2"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+type scanFixture struct {
+	db            database.DB
+	scanSecretKey [32]byte
+	spendPubKey   [33]byte
+	tweak         [33]byte
+	txid          []byte
+	// outputs sent to the spend key (vout 0) and to label 1 (vout 2), vout 1 belongs to someone else
+	outputs [3][32]byte
+	// secKeyTweaks of vout 0 and vout 2
+	secKeyTweaks [2][32]byte
+}
+
+// newScanStore indexes block 1 with a tx paying the receiver twice
+func newScanStore(t *testing.T) *scanFixture {
+	t.Helper()
+	f := &scanFixture{
+		scanSecretKey: [32]byte{0x5c, 0x01},
+		txid:          append([]byte{0x01}, bytes.Repeat([]byte{0xd4}, 31)...),
+	}
+	spendSecretKey := [32]byte{0x5e, 0x02}
+	f.spendPubKey = *bip352.PubKeyFromSecKey(&spendSecretKey)
+	// stands in for input_hash * A_sum of the sender
+	f.tweak = *bip352.PubKeyFromSecKey(&[32]byte{0x7a, 0x03})
+
+	publicComponent := f.tweak
+	sharedSecret, err := bip352.CreateSharedSecret(&publicComponent, &f.scanSecretKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.outputs[0], f.secKeyTweaks[0], err = bip352.CreateOutputPubKeyTweak(sharedSecret, &f.spendPubKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	label, err := bip352.CreateLabel(&f.scanSecretKey, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labelledSpendPubKey, err := bip352.CreateLabelledSpendPubKey(&f.spendPubKey, &label.PubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.outputs[2], f.secKeyTweaks[1], err = bip352.CreateOutputPubKeyTweak(sharedSecret, &labelledSpendPubKey, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = bip352.AddPrivateKeys(&f.secKeyTweaks[1], &label.Tweak); err != nil {
+		t.Fatal(err)
+	}
+	f.outputs[1] = [32]byte(bytes.Repeat([]byte{0x0f}, 32))
+
+	block := &database.DBBlock{
+		Height: 1,
+		Hash:   &chainhash.Hash{0x01},
+		Txs: []*database.Tx{{
+			Txid:  f.txid,
+			Tweak: &f.tweak,
+			Outs: []*database.Output{
+				{Txid: f.txid, Vout: 0, Amount: 1_000, Pubkey: f.outputs[0][:]},
+				{Txid: f.txid, Vout: 1, Amount: 2_000, Pubkey: f.outputs[1][:]},
+				{Txid: f.txid, Vout: 2, Amount: 3_000, Pubkey: f.outputs[2][:]},
+			},
+		}},
+	}
+	f.db = testhelpers.NewStore(t, block)
+	return f
+}
+
+// newUnixClient serves the oracle service on a unix socket, a local client for scanAllowed
+func newUnixClient(t *testing.T, db database.DB) pb.OracleServiceClient {
+	t.Helper()
+	config.Chain = config.Regtest
+	config.GRPCHost = "unix://" + filepath.Join(t.TempDir(), "grpc.sock")
+	lis, err := listener.Listen(config.GRPCHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterOracleServiceServer(grpcServer, v2.NewOracleService(db))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(config.GRPCHost, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewOracleServiceClient(conn)
+}
+
+var grpcStatusTrailer = regexp.MustCompile(`grpc-status: ?(\d+)`)
+
+// grpcWebCode calls method of OracleService over gRPC-Web and returns the status from the trailers
+func grpcWebCode(t *testing.T, url, method string, req proto.Message) codes.Code {
+	t.Helper()
+	msg, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(msg)))
+	body = append(body, msg...)
+	httpReq, err := http.NewRequest(
+		http.MethodPost, url+"/blindbit.oracle.v1.OracleService/"+method, bytes.NewReader(body),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.Header.Set("Content-Type", "application/grpc-web+proto")
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// a status without messages may come in the headers instead of a trailer frame
+	if code := resp.Header.Get("Grpc-Status"); code != "" {
+		n, _ := strconv.Atoi(code)
+		return codes.Code(n)
+	}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := grpcStatusTrailer.FindSubmatch(raw)
+	if m == nil {
+		t.Fatalf("no grpc-status in %q", raw)
+	}
+	n, _ := strconv.Atoi(string(m[1]))
+	return codes.Code(n)
+}
+
+func enableScanRPC(t *testing.T) {
+	t.Helper()
+	config.ScanRPC = true
+	t.Cleanup(func() { config.ScanRPC = false })
+}
+
+func TestScanRange(t *testing.T) {
+	enableScanRPC(t)
+	f := newScanStore(t)
+	client := newUnixClient(t, f.db)
+
+	stream, err := client.ScanRange(context.Background(), &pb.ScanRangeRequest{
+		Start:         1,
+		End:           1,
+		ScanSecretKey: f.scanSecretKey[:],
+		SpendPubkey:   f.spendPubKey[:],
+		Labels:        []uint32{0, 1},
+	})
+	resp := firstOfStream(t, stream, err)
+	if resp.ScannedHeight != 1 {
+		t.Errorf("scanned height %d", resp.ScannedHeight)
+	}
+	if len(resp.Matches) != 2 {
+		t.Fatalf("found %d outputs, want 2", len(resp.Matches))
+	}
+
+	labelOne := uint32(1)
+	for i, want := range []struct {
+		vout   uint32
+		amount uint64
+		label  *uint32
+	}{
+		{vout: 0, amount: 1_000},
+		{vout: 2, amount: 3_000, label: &labelOne},
+	} {
+		match := resp.Matches[i]
+		utxo := match.Utxo
+		if utxo.Vout != want.vout || utxo.Amount != want.amount {
+			t.Errorf("match %d: vout %d amount %d", i, utxo.Vout, utxo.Amount)
+		}
+		if !bytes.Equal(utxo.Pubkey, f.outputs[want.vout][:]) {
+			t.Errorf("match %d: pubkey %x", i, utxo.Pubkey)
+		}
+		if !bytes.Equal(utxo.Txid, utils.ReverseBytesCopy(f.txid)) {
+			t.Errorf("match %d: txid %x", i, utxo.Txid)
+		}
+		if !bytes.Equal(match.Tweak, f.tweak[:]) {
+			t.Errorf("match %d: tweak %x", i, match.Tweak)
+		}
+		if !bytes.Equal(match.PrivKeyTweak, f.secKeyTweaks[i][:]) {
+			t.Errorf("match %d: secret key tweak %x", i, match.PrivKeyTweak)
+		}
+		if (match.Label == nil) != (want.label == nil) || (want.label != nil && *match.Label != *want.label) {
+			t.Errorf("match %d: label %v", i, match.Label)
+		}
+		if match.BlockIdentifier.BlockHeight != 1 {
+			t.Errorf("match %d: height %d", i, match.BlockIdentifier.BlockHeight)
+		}
+	}
+
+	// without the label the labelled output is not found
+	stream, err = client.ScanRange(context.Background(), &pb.ScanRangeRequest{
+		Start:         1,
+		End:           1,
+		ScanSecretKey: f.scanSecretKey[:],
+		SpendPubkey:   f.spendPubKey[:],
+	})
+	if resp = firstOfStream(t, stream, err); len(resp.Matches) != 1 {
+		t.Errorf("found %d outputs without labels, want 1", len(resp.Matches))
+	}
+}
+
+// the scan compares 8 byte prefixes, a colliding output must neither match nor shadow the real one
+func TestScanRangeChecksFullKey(t *testing.T) {
+	enableScanRPC(t)
+	f := newScanStore(t)
+	client := newUnixClient(t, f.db)
+
+	decoy := f.outputs[0]
+	decoy[31] ^= 0xff
+	shadowed := append([]byte{0x02}, bytes.Repeat([]byte{0xd5}, 31)...)
+	decoyOnly := append([]byte{0x03}, bytes.Repeat([]byte{0xd6}, 31)...)
+	block := &database.DBBlock{
+		Height: 2,
+		Hash:   &chainhash.Hash{0x02},
+		Txs: []*database.Tx{
+			{
+				Txid:  shadowed,
+				Tweak: &f.tweak,
+				Outs: []*database.Output{
+					{Txid: shadowed, Vout: 0, Amount: 4_000, Pubkey: decoy[:]},
+					{Txid: shadowed, Vout: 1, Amount: 5_000, Pubkey: f.outputs[0][:]},
+				},
+			},
+			{
+				Txid:  decoyOnly,
+				Tweak: &f.tweak,
+				Outs:  []*database.Output{{Txid: decoyOnly, Vout: 0, Amount: 6_000, Pubkey: decoy[:]}},
+			},
+		},
+	}
+	testhelpers.ApplyBlocks(t, f.db, block)
+
+	stream, err := client.ScanRange(context.Background(), &pb.ScanRangeRequest{
+		Start:         2,
+		End:           2,
+		ScanSecretKey: f.scanSecretKey[:],
+		SpendPubkey:   f.spendPubKey[:],
+	})
+	resp := firstOfStream(t, stream, err)
+	if len(resp.Matches) != 1 {
+		t.Fatalf("found %d outputs, want 1", len(resp.Matches))
+	}
+	utxo := resp.Matches[0].Utxo
+	if !bytes.Equal(utxo.Txid, utils.ReverseBytesCopy(shadowed)) || utxo.Vout != 1 || utxo.Amount != 5_000 {
+		t.Errorf("matched %x:%d amount %d", utxo.Txid, utxo.Vout, utxo.Amount)
+	}
+}
+
+func TestScanRangeRejected(t *testing.T) {
+	f := newScanStore(t)
+	unixClient := newUnixClient(t, f.db)
+	_, bufconnClient := newTestClients(t, f.db)
+
+	valid := &pb.ScanRangeRequest{
+		Start:         1,
+		End:           1,
+		ScanSecretKey: f.scanSecretKey[:],
+		SpendPubkey:   f.spendPubKey[:],
+	}
+	scanCode := func(client pb.OracleServiceClient, req *pb.ScanRangeRequest) codes.Code {
+		t.Helper()
+		stream, err := client.ScanRange(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for err == nil {
+			_, err = stream.Recv()
+		}
+		if err == io.EOF {
+			return codes.OK
+		}
+		return status.Code(err)
+	}
+
+	if code := scanCode(unixClient, valid); code != codes.Unimplemented {
+		t.Errorf("disabled: got %s", code)
+	}
+
+	enableScanRPC(t)
+	// bufconn is neither a unix socket nor loopback
+	if code := scanCode(bufconnClient, valid); code != codes.PermissionDenied {
+		t.Errorf("remote client: got %s", code)
+	}
+
+	// gRPC-Web from loopback, as every browser behind a local reverse proxy would arrive
+	router, _ := newTestClients(t, f.db)
+	httpServer := httptest.NewServer(router)
+	t.Cleanup(httpServer.Close)
+	if code := grpcWebCode(t, httpServer.URL, "ScanRange", valid); code != codes.PermissionDenied {
+		t.Errorf("gRPC-Web from loopback: got %s", code)
+	}
+
+	// only scan API keys grant scanning
+	config.APIKeys = []string{"public"}
+	config.ScanAPIKeys = []string{"scanner"}
+	t.Cleanup(func() { config.APIKeys, config.ScanAPIKeys = nil, nil })
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+	if _, err := bufconnClient.RegisterWallet(withKey("public"), &pb.RegisterWalletRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("public api key: got %v", err)
+	}
+	if _, err := bufconnClient.RegisterWallet(withKey("scanner"), &pb.RegisterWalletRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("scan api key: got %v", err)
+	}
+
+	invalid := []*pb.ScanRangeRequest{
+		{Start: 1, End: 1, ScanSecretKey: make([]byte, 32), SpendPubkey: f.spendPubKey[:]},
+		{Start: 1, End: 1, ScanSecretKey: f.scanSecretKey[:31], SpendPubkey: f.spendPubKey[:]},
+		{Start: 1, End: 1, ScanSecretKey: f.scanSecretKey[:], SpendPubkey: append([]byte{0x04}, f.spendPubKey[1:]...)},
+		{Start: 2, End: 1, ScanSecretKey: f.scanSecretKey[:], SpendPubkey: f.spendPubKey[:]},
+	}
+	for i, req := range invalid {
+		if code := scanCode(unixClient, req); code != codes.InvalidArgument {
+			t.Errorf("invalid request %d: got %s", i, code)
+		}
+	}
+}
+
+func TestWalletRPCs(t *testing.T) {
+	f := newScanStore(t)
+	unixClient := newUnixClient(t, f.db)
+	_, bufconnClient := newTestClients(t, f.db)
+	ctx := context.Background()
+
+	register := &pb.RegisterWalletRequest{
+		ScanSecretKey: f.scanSecretKey[:],
+		SpendPubkey:   f.spendPubKey[:],
+		BirthHeight:   1,
+	}
+	if _, err := unixClient.RegisterWallet(ctx, register); status.Code(err) != codes.Unimplemented {
+		t.Errorf("disabled: got %v", err)
+	}
+
+	enableScanRPC(t)
+	if _, err := bufconnClient.RegisterWallet(ctx, register); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("remote client: got %v", err)
+	}
+	if _, err := unixClient.RegisterWallet(ctx, &pb.RegisterWalletRequest{
+		ScanSecretKey: f.scanSecretKey[:], SpendPubkey: f.spendPubKey[:],
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("no birth height: got %v", err)
+	}
+
+	wallet, err := unixClient.RegisterWallet(ctx, register)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.GetBirthHeight() != 1 || wallet.GetScannedHeight() != 0 {
+		t.Errorf("unexpected status %v", wallet)
+	}
+	// the id is a bearer token, it must not be computable from the address
+	mac := hmac.New(sha256.New, f.scanSecretKey[:])
+	mac.Write(f.spendPubKey[:])
+	if !bytes.Equal(wallet.GetWalletId(), mac.Sum(nil)) {
+		t.Errorf("wallet id %x is not keyed with the scan secret key", wallet.GetWalletId())
+	}
+	// nothing is scanned without the indexer
+	outputs, err := unixClient.GetWalletOutputs(ctx, &pb.WalletOutputsRequest{WalletId: wallet.GetWalletId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs.GetOutputs()) != 0 || outputs.GetWallet().GetScannedHeight() != 0 {
+		t.Errorf("unexpected outputs %v", outputs)
+	}
+
+	if _, err = unixClient.UnregisterWallet(ctx, &pb.WalletRequest{WalletId: wallet.GetWalletId()}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = unixClient.GetWalletStatus(ctx, &pb.WalletRequest{WalletId: wallet.GetWalletId()})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unregistered wallet: got %v", err)
+	}
+	if _, err = unixClient.GetWalletStatus(ctx, &pb.WalletRequest{WalletId: []byte{0x01}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("short wallet id: got %v", err)
+	}
+}
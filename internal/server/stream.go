@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/setavenger/blindbit-lib/logging"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+const (
+	contentTypeNDJSON = "application/x-ndjson"
+	// contentTypeCBORSeq is a sequence of concatenated cbor items (RFC 8742)
+	contentTypeCBORSeq = "application/cbor-seq"
+)
+
+// offeredStreamFormats in order of preference, the binary formats mirror offeredFormats.
+// Protobuf messages are streamed with a varint length prefix (see protodelim).
+var offeredStreamFormats = []string{
+	contentTypeNDJSON,
+	gin.MIMEJSON,
+	contentTypeProtobuf,
+	contentTypeCBORSeq,
+	contentTypeCBOR,
+}
+
+// streamEncoder writes a single item of a stream
+type streamEncoder func(item any) error
+
+// newStreamEncoder picks the encoder from the Accept header and returns the content type to send
+func newStreamEncoder(c *gin.Context) (string, streamEncoder) {
+	w := c.Writer
+	switch negotiateFormat(c, offeredStreamFormats...) {
+	case contentTypeProtobuf:
+		return contentTypeProtobuf, func(item any) error {
+			msg, ok := item.(protoMessage)
+			if !ok {
+				return fmt.Errorf("%T has no protobuf representation", item)
+			}
+			_, err := protodelim.MarshalTo(w, msg.Proto())
+			return err
+		}
+	case contentTypeCBORSeq, contentTypeCBOR:
+		enc := cborEncMode.NewEncoder(w)
+		return contentTypeCBORSeq, enc.Encode
+	default:
+		return contentTypeNDJSON, json.NewEncoder(w).Encode
+	}
+}
+
+// StreamTweaks streams the tweak index for every block in ?start=&end=, newline-delimited JSON by default
+func (h *Handler) StreamTweaks(c *gin.Context) {
+	h.streamRange(c, func(height uint32, blockhash []byte) (any, error) {
+		return h.tweakIndexForBlock(height, blockhash)
+	})
+}
+
+// StreamComputeIndex streams the compute index for every block in ?start=&end=, newline-delimited JSON by default
+func (h *Handler) StreamComputeIndex(c *gin.Context) {
+	h.streamRange(c, func(height uint32, blockhash []byte) (any, error) {
+		return h.computeIndexForBlock(height, blockhash)
+	})
+}
+
+// streamRange writes one object per block and flushes after every block,
+// so only a single block is held in memory at any time.
+// Heights which are not indexed are skipped.
+// Errors after the headers were sent abort the response (see abortStream),
+// so a failed stream never looks like a complete one.
+func (h *Handler) streamRange(
+	c *gin.Context,
+	forBlock func(height uint32, blockhash []byte) (any, error),
+) {
+	start64, end64, err := parseHeightRange(c)
+	if err != nil {
+		writeBadRequest(c, err)
+		return
+	}
+
+	// end is clamped to the synced tip
+	start, end, err := h.query.Range(start64, end64)
+	if err != nil {
+		writeError(c, err, "could not fetch chain tip")
+		return
+	}
+	if !consumeHeights(c, uint64(end-start)+1) {
+		return
+	}
+
+	contentType, encode := newStreamEncoder(c)
+	c.Header("Content-Type", contentType)
+	c.Header("Vary", "Accept")
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+
+	for height := start; height <= end; height++ {
+		if ctx.Err() != nil {
+			// client went away
+			return
+		}
+
+		blockhash, err := h.db.GetBlockHashByHeight(height)
+		if err != nil {
+			logging.L.Err(err).Uint32("height", height).Msg("could not fetch block hash")
+			abortStream(c, contentType, encode, errors.New("could not fetch block hash"))
+		}
+		if blockhash == nil {
+			continue
+		}
+
+		item, err := forBlock(height, blockhash)
+		if err != nil {
+			logging.L.Err(err).Uint32("height", height).Msg("error fetching block data")
+			abortStream(c, contentType, encode, errors.New("could not retrieve data from database"))
+		}
+
+		if err = encode(item); err != nil {
+			logging.L.Debug().Err(err).Uint32("height", height).Msg("failed writing to stream")
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// abortStream ends a stream which failed after the headers were sent.
+// NDJSON and CBOR streams get an error object with code "internal" as their last item,
+// then the connection is cut without finishing the response (no final chunk on HTTP/1.1,
+// RST_STREAM on HTTP/2), for protobuf streams that is the only signal.
+func abortStream(c *gin.Context, contentType string, encode streamEncoder, err error) {
+	if contentType != contentTypeProtobuf {
+		if encode(NewErrorResponseWithCode(ErrCodeInternal, err)) == nil {
+			c.Writer.Flush()
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// parseHeightRange reads ?start=&end= (both inclusive), the range is checked by query.Range
+func parseHeightRange(c *gin.Context) (start, end uint64, err error) {
+	startStr, endStr := c.Query("start"), c.Query("end")
+	if startStr == "" || endStr == "" {
+		return 0, 0, errors.New("start and end are required")
+	}
+
+	start, err = strconv.ParseUint(startStr, 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("could not parse start height")
+	}
+	end, err = strconv.ParseUint(endStr, 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("could not parse end height")
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,133 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// failingDB fails to look up the block at failHeight
+type failingDB struct {
+	database.DB
+	failHeight uint32
+}
+
+func (db *failingDB) GetBlockHashByHeight(height uint32) ([]byte, error) {
+	if height == db.failHeight {
+		return nil, errors.New("disk on fire")
+	}
+	return db.DB.GetBlockHashByHeight(height)
+}
+
+func streamRequest(t *testing.T, url, accept string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	return resp
+}
+
+// TestStreamNDJSON every line of a range is the response of the single block route
+func TestStreamNDJSON(t *testing.T) {
+	router, _ := newTestClients(t, newTestStore(t))
+	httpServer := httptest.NewServer(router)
+	t.Cleanup(httpServer.Close)
+
+	resp := streamRequest(t, httpServer.URL+"/tweaks?start=1&end=2", "")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("content type %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("stream did not end cleanly: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(body, []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines:\n%s", len(lines), body)
+	}
+	for i, path := range []string{"/tweaks/1", "/tweaks/2"} {
+		var got, want any
+		if err = json.Unmarshal(lines[i], &got); err != nil {
+			t.Fatal(err)
+		}
+		if err = json.Unmarshal(get(t, router, path, "application/json"), &want); err != nil {
+			t.Fatal(err)
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if !bytes.Equal(gotJSON, wantJSON) {
+			t.Errorf("line %d differs from %s\ngot:  %s\nwant: %s", i, path, gotJSON, wantJSON)
+		}
+	}
+}
+
+// TestStreamFailsMidway a block that can't be read after the first one was sent
+// has to fail the response, it must not look like a stream which ended at the tip
+func TestStreamFailsMidway(t *testing.T) {
+	router, _ := newTestClients(t, &failingDB{DB: newTestStore(t), failHeight: 2})
+	httpServer := httptest.NewServer(router)
+	t.Cleanup(httpServer.Close)
+
+	t.Run("ndjson", func(t *testing.T) {
+		resp := streamRequest(t, httpServer.URL+"/compute-index?start=1&end=2", "application/x-ndjson")
+		var lines []map[string]any
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, line)
+		}
+		if err := scanner.Err(); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("stream ended with %v, want an unexpected EOF", err)
+		}
+		if len(lines) != 2 {
+			t.Fatalf("got %d lines, want block 1 and the error", len(lines))
+		}
+		if _, ok := lines[0]["error"]; ok {
+			t.Errorf("block 1 replaced by %v", lines[0])
+		}
+		if data, _ := lines[1]["data"].(map[string]any); data["code"] != "internal" {
+			t.Errorf("last line %v is not the error", lines[1])
+		}
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		resp := streamRequest(t, httpServer.URL+"/compute-index?start=1&end=2", "application/x-protobuf")
+		r := bufio.NewReader(resp.Body)
+		first := &pb.ComputeIndexResponse{}
+		if err := protodelim.UnmarshalFrom(r, first); err != nil {
+			t.Fatal(err)
+		}
+		if len(first.GetIndex()) == 0 {
+			t.Errorf("block 1 is empty: %v", first)
+		}
+		// no terminal record, the cut connection is the error
+		err := protodelim.UnmarshalFrom(r, &pb.ComputeIndexResponse{})
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("stream ended with %v, want an unexpected EOF", err)
+		}
+	})
+}
@@ -3,6 +3,9 @@ package server
 import (
 	"encoding/hex"
 	"encoding/json"
+
+	"github.com/setavenger/blindbit-lib/api"
+	"github.com/setavenger/blindbit-oracle/internal/query"
 )
 
 type BlockIdentifier struct {
@@ -20,6 +23,26 @@ func (b BlockIdentifier) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// InfoResponse has the same layout as api.InfoResponseOracle
+type InfoResponse api.InfoResponseOracle
+
+// BlockHeightResponse has the same layout as api.BlockHeightResponseOracle
+type BlockHeightResponse api.BlockHeightResponseOracle
+
+// BlockHashResponse is marshalled to the same JSON as api.BlockHashResponseOracle
+// but keeps the raw bytes for binary encodings
+type BlockHashResponse struct {
+	BlockHash []byte `json:"block_hash"`
+}
+
+func (b BlockHashResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		BlockHash string `json:"block_hash"`
+	}{
+		BlockHash: hex.EncodeToString(b.BlockHash),
+	})
+}
+
 type FullBlockResponse struct {
 	BlockIdentifier BlockIdentifier `json:"block_identifier"`
 	Index           []FullTxItem    `json:"index"`
@@ -166,6 +189,12 @@ func (u UTXOItemLight) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// UTXOResponse UTXOItem array for block
+type UTXOResponse struct {
+	BlockIdentifier BlockIdentifier `json:"block_identifier"`
+	Index           []UTXOItem      `json:"index"`
+}
+
 type UTXOItem struct {
 	TxId   [32]byte `json:"txid,omitempty"`
 	Vout   uint32   `json:"vout"`
@@ -186,3 +215,87 @@ func (u UTXOItem) MarshalJSON() ([]byte, error) {
 		Pubkey: hex.EncodeToString(u.Pubkey[:]),
 	})
 }
+
+func newBlockIdentifier(b query.BlockID) BlockIdentifier {
+	return BlockIdentifier{BlockHash: b.Hash, BlockHeight: b.Height}
+}
+
+func newTweakIndexResponse(t *query.Tweaks) *TweakIndexResponse {
+	return &TweakIndexResponse{
+		BlockIdentifier: newBlockIdentifier(t.Block),
+		Index:           t.Tweaks,
+	}
+}
+
+func newUTXOResponse(u *query.UTXOs) *UTXOResponse {
+	index := make([]UTXOItem, len(u.UTXOs))
+	for i, utxo := range u.UTXOs {
+		index[i] = UTXOItem{
+			TxId:   utxo.Txid,
+			Vout:   utxo.Vout,
+			Amount: utxo.Amount,
+			Pubkey: utxo.Pubkey,
+		}
+	}
+	return &UTXOResponse{
+		BlockIdentifier: newBlockIdentifier(u.Block),
+		Index:           index,
+	}
+}
+
+func newSpentIndexResponse(s *query.SpentOutputs) *SpentIndexResponse {
+	return &SpentIndexResponse{
+		BlockIdentifier: newBlockIdentifier(s.Block),
+		Index:           s.Spent,
+	}
+}
+
+func newInfoResponse(i *query.Info) InfoResponse {
+	return InfoResponse{
+		Network:                        i.Network,
+		Height:                         i.Height,
+		TweaksOnly:                     i.TweaksOnly,
+		TweaksFullBasic:                i.TweaksFullBasic,
+		TweaksFullWithDustFilter:       i.TweaksFullWithDustFilter,
+		TweaksCutThroughWithDustFilter: i.TweaksCutThroughWithDustFilter,
+	}
+}
+
+func newComputeIndexResponse(ci *query.ComputeIndex) *ComputeIndexResponse {
+	index := make([]ComputeIndexItem, len(ci.Txs))
+	for i, tx := range ci.Txs {
+		outputsShort := make(OutputsShort, len(tx.OutputsShort)/8)
+		for j := range outputsShort {
+			copy(outputsShort[j][:], tx.OutputsShort[j*8:])
+		}
+		index[i] = ComputeIndexItem{
+			TxId:         tx.Txid,
+			Tweak:        tx.Tweak,
+			OutputsShort: outputsShort,
+		}
+	}
+	return &ComputeIndexResponse{
+		BlockIdentifier: newBlockIdentifier(ci.Block),
+		Index:           index,
+	}
+}
+
+func newFullBlockResponse(f *query.FullBlock) *FullBlockResponse {
+	index := make([]FullTxItem, len(f.Txs))
+	for i, tx := range f.Txs {
+		utxos := make([]UTXOItemLight, len(tx.Outputs))
+		for j, out := range tx.Outputs {
+			utxos[j] = UTXOItemLight{Vout: out.Vout, Amount: out.Amount, Pubkey: out.Pubkey}
+		}
+		index[i] = FullTxItem{
+			TxId:   tx.Txid,
+			Tweak:  tx.Tweak,
+			Inputs: tx.Inputs,
+			UTXOs:  utxos,
+		}
+	}
+	return &FullBlockResponse{
+		BlockIdentifier: newBlockIdentifier(f.Block),
+		Index:           index,
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// Protobuf representations of the HTTP responses.
+// Byte layouts follow the gRPC server: hashes and txids in display order,
+// outputs short and outpoints as one contiguous byte slice.
+
+func (b BlockIdentifier) proto() *pb.BlockIdentifier {
+	return &pb.BlockIdentifier{
+		BlockHash:   b.BlockHash,
+		BlockHeight: uint64(b.BlockHeight),
+	}
+}
+
+func (i InfoResponse) Proto() proto.Message {
+	return &pb.InfoResponse{
+		Network:                        i.Network,
+		Height:                         uint64(i.Height),
+		TweaksOnly:                     i.TweaksOnly,
+		TweaksFullBasic:                i.TweaksFullBasic,
+		TweaksFullWithDustFilter:       i.TweaksFullWithDustFilter,
+		TweaksCutThroughWithDustFilter: i.TweaksCutThroughWithDustFilter,
+	}
+}
+
+func (b BlockHeightResponse) Proto() proto.Message {
+	return &pb.BlockHeightResponse{BlockHeight: uint64(b.BlockHeight)}
+}
+
+func (b BlockHashResponse) Proto() proto.Message {
+	return &pb.BlockHashResponse{BlockHash: b.BlockHash}
+}
+
+func (t TweakIndexResponse) Proto() proto.Message {
+	index := make([][]byte, len(t.Index))
+	for i := range t.Index {
+		index[i] = t.Index[i][:]
+	}
+	return &pb.IndexResponse{
+		BlockIdentifier: t.BlockIdentifier.proto(),
+		Index:           index,
+	}
+}
+
+func (s SpentIndexResponse) Proto() proto.Message {
+	index := make([][]byte, len(s.Index))
+	for i := range s.Index {
+		index[i] = s.Index[i][:]
+	}
+	return &pb.IndexResponse{
+		BlockIdentifier: s.BlockIdentifier.proto(),
+		Index:           index,
+	}
+}
+
+func (c ComputeIndexResponse) Proto() proto.Message {
+	index := make([]*pb.ComputeIndexTxItem, len(c.Index))
+	for i, item := range c.Index {
+		outputsShort := make([]byte, 0, len(item.OutputsShort)*8)
+		for j := range item.OutputsShort {
+			outputsShort = append(outputsShort, item.OutputsShort[j][:]...)
+		}
+		index[i] = &pb.ComputeIndexTxItem{
+			Txid:         item.TxId[:],
+			Tweak:        item.Tweak[:],
+			OutputsShort: outputsShort,
+		}
+	}
+	return &pb.ComputeIndexResponse{
+		BlockIdentifier: c.BlockIdentifier.proto(),
+		Index:           index,
+	}
+}
+
+func (u UTXOResponse) Proto() proto.Message {
+	index := make([]*pb.UTXOItem, len(u.Index))
+	for i, item := range u.Index {
+		index[i] = &pb.UTXOItem{
+			Txid:   item.TxId[:],
+			Vout:   item.Vout,
+			Amount: item.Amount,
+			Pubkey: item.Pubkey[:],
+		}
+	}
+	return &pb.UTXOResponse{
+		BlockIdentifier: u.BlockIdentifier.proto(),
+		Index:           index,
+	}
+}
+
+func (f FullBlockResponse) Proto() proto.Message {
+	index := make([]*pb.FullTxItem, len(f.Index))
+	for i, item := range f.Index {
+		inputs := make([]byte, 0, len(item.Inputs)*36)
+		for j := range item.Inputs {
+			inputs = append(inputs, item.Inputs[j][:]...)
+		}
+		utxos := make([]*pb.UTXOItemLight, len(item.UTXOs))
+		for j, utxo := range item.UTXOs {
+			utxos[j] = &pb.UTXOItemLight{
+				Vout:   utxo.Vout,
+				Amount: utxo.Amount,
+				Pubkey: utxo.Pubkey[:],
+			}
+		}
+		index[i] = &pb.FullTxItem{
+			Txid:   item.TxId[:],
+			Tweak:  item.Tweak[:],
+			Inputs: inputs,
+			Utxos:  utxos,
+		}
+	}
+	return &pb.FullBlockResponse{
+		BlockIdentifier: f.BlockIdentifier.proto(),
+		Index:           index,
+	}
+}
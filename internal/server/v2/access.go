@@ -0,0 +1,105 @@
+package v2
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/setavenger/blindbit-oracle/internal/access"
+)
+
+// apiKeyMetadata is the metadata key clients send their API key in
+const apiKeyMetadata = "x-api-key"
+
+type clientIDKey struct{}
+
+// exemptFromAccess health checks and reflection are open like /healthz on HTTP
+func exemptFromAccess(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") ||
+		strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+// checkAccess authenticates the call and applies the rate limit.
+// The returned context carries the client for consumeHeights.
+func checkAccess(ctx context.Context) (context.Context, error) {
+	limiter := access.Shared()
+
+	var apiKey string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(apiKeyMetadata); len(values) > 0 {
+			apiKey = values[0]
+		}
+	}
+	if err := limiter.Authenticate(apiKey); err != nil {
+		return nil, statusFromError(err, "")
+	}
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	clientID := limiter.ClientID(apiKey, ip)
+	if _, err := limiter.Allow(clientID); err != nil {
+		return nil, statusFromError(err, "")
+	}
+
+	return context.WithValue(ctx, clientIDKey{}, clientID), nil
+}
+
+// consumeHeights counts n requested heights against the client's quota
+func consumeHeights(ctx context.Context, n uint64) error {
+	clientID, _ := ctx.Value(clientIDKey{}).(string)
+	if err := access.Shared().ConsumeHeights(clientID, n); err != nil {
+		return statusFromError(err, "")
+	}
+	return nil
+}
+
+func unaryAccessInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if exemptFromAccess(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := checkAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func streamAccessInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if exemptFromAccess(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, err := checkAccess(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &accessStream{ServerStream: ss, ctx: ctx})
+}
+
+// accessStream replaces the stream's context with the one carrying the client
+type accessStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *accessStream) Context() context.Context {
+	return s.ctx
+}
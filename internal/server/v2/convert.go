@@ -0,0 +1,173 @@
+package v2
+
+import (
+	"github.com/setavenger/blindbit-oracle/internal/query"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// Protobuf encodings of the query results
+
+func blockIdentifierProto(b query.BlockID) *pb.BlockIdentifier {
+	return &pb.BlockIdentifier{
+		BlockHash:   b.Hash,
+		BlockHeight: uint64(b.Height),
+	}
+}
+
+func tweaksProto(t *query.Tweaks) *pb.IndexResponse {
+	index := make([][]byte, len(t.Tweaks))
+	for i := range t.Tweaks {
+		index[i] = t.Tweaks[i][:]
+	}
+	return &pb.IndexResponse{
+		BlockIdentifier: blockIdentifierProto(t.Block),
+		Index:           index,
+	}
+}
+
+func utxosProto(u *query.UTXOs) *pb.UTXOResponse {
+	index := make([]*pb.UTXOItem, len(u.UTXOs))
+	for i := range u.UTXOs {
+		utxo := &u.UTXOs[i]
+		index[i] = &pb.UTXOItem{
+			Txid:   utxo.Txid[:],
+			Vout:   utxo.Vout,
+			Amount: utxo.Amount,
+			Pubkey: utxo.Pubkey[:],
+		}
+	}
+	return &pb.UTXOResponse{
+		BlockIdentifier: blockIdentifierProto(u.Block),
+		Index:           index,
+	}
+}
+
+func spentOutputsProto(s *query.SpentOutputs) *pb.IndexResponse {
+	index := make([][]byte, len(s.Spent))
+	for i := range s.Spent {
+		index[i] = s.Spent[i][:]
+	}
+	return &pb.IndexResponse{
+		BlockIdentifier: blockIdentifierProto(s.Block),
+		Index:           index,
+	}
+}
+
+// infoProto advertises the filters StreamComputeIndex and StreamBlockScanDataShort can apply
+func infoProto(i *query.Info) *pb.InfoResponse {
+	return &pb.InfoResponse{
+		Network:                        i.Network,
+		Height:                         uint64(i.Height),
+		TweaksOnly:                     i.TweaksOnly,
+		TweaksFullBasic:                i.TweaksFullBasic,
+		TweaksFullWithDustFilter:       i.DustFilter,
+		TweaksCutThroughWithDustFilter: i.CutThrough,
+	}
+}
+
+func computeIndexTxsProto(txs []query.ComputeIndexTx) []*pb.ComputeIndexTxItem {
+	items := make([]*pb.ComputeIndexTxItem, len(txs))
+	for i := range txs {
+		tx := &txs[i]
+		items[i] = &pb.ComputeIndexTxItem{
+			Txid:         tx.Txid[:],
+			Tweak:        tx.Tweak[:],
+			OutputsShort: tx.OutputsShort,
+		}
+	}
+	return items
+}
+
+func computeIndexProto(ci *query.ComputeIndex) *pb.ComputeIndexResponse {
+	return &pb.ComputeIndexResponse{
+		BlockIdentifier: blockIdentifierProto(ci.Block),
+		Index:           computeIndexTxsProto(ci.Txs),
+	}
+}
+
+func blockScanDataProto(b *query.BlockScanData) *pb.BlockScanDataShortResponse {
+	return &pb.BlockScanDataShortResponse{
+		BlockIdentifier: blockIdentifierProto(b.Block),
+		CompIndex:       computeIndexTxsProto(b.ComputeIndex),
+		SpentOutputs:    b.SpentOutputs,
+	}
+}
+
+func fullBlockProto(f *query.FullBlock) *pb.FullBlockResponse {
+	index := make([]*pb.FullTxItem, len(f.Txs))
+	for i := range f.Txs {
+		tx := &f.Txs[i]
+		inputs := make([]byte, 0, len(tx.Inputs)*36)
+		for j := range tx.Inputs {
+			inputs = append(inputs, tx.Inputs[j][:]...)
+		}
+		utxos := make([]*pb.UTXOItemLight, len(tx.Outputs))
+		for j := range tx.Outputs {
+			out := &tx.Outputs[j]
+			utxos[j] = &pb.UTXOItemLight{
+				Vout:   out.Vout,
+				Amount: out.Amount,
+				Pubkey: out.Pubkey[:],
+			}
+		}
+		index[i] = &pb.FullTxItem{
+			Txid:   tx.Txid[:],
+			Tweak:  tx.Tweak[:],
+			Inputs: inputs,
+			Utxos:  utxos,
+		}
+	}
+	return &pb.FullBlockResponse{
+		BlockIdentifier: blockIdentifierProto(f.Block),
+		Index:           index,
+	}
+}
+
+func scanRangeProto(matches []query.ScanMatch, scannedHeight uint32) *pb.ScanRangeResponse {
+	items := make([]*pb.ScanRangeMatch, len(matches))
+	for i := range matches {
+		items[i] = scanMatchProto(&matches[i])
+	}
+	return &pb.ScanRangeResponse{
+		Matches:       items,
+		ScannedHeight: uint64(scannedHeight),
+	}
+}
+
+func scanMatchProto(match *query.ScanMatch) *pb.ScanRangeMatch {
+	return &pb.ScanRangeMatch{
+		BlockIdentifier: blockIdentifierProto(match.Block),
+		Utxo: &pb.UTXOItem{
+			Txid:   match.UTXO.Txid[:],
+			Vout:   match.UTXO.Vout,
+			Amount: match.UTXO.Amount,
+			Pubkey: match.UTXO.Pubkey[:],
+		},
+		Tweak:        match.Tweak[:],
+		PrivKeyTweak: match.SecKeyTweak[:],
+		Label:        match.Label,
+	}
+}
+
+func walletStatusProto(w *query.WalletStatus) *pb.WalletStatus {
+	return &pb.WalletStatus{
+		WalletId:      w.ID[:],
+		BirthHeight:   uint64(w.BirthHeight),
+		ScannedHeight: uint64(w.ScannedHeight),
+	}
+}
+
+func walletOutputsProto(w *query.WalletOutputs) *pb.WalletOutputsResponse {
+	outputs := make([]*pb.WalletOutput, len(w.Outputs))
+	for i := range w.Outputs {
+		output := &w.Outputs[i]
+		outputs[i] = &pb.WalletOutput{Match: scanMatchProto(&output.ScanMatch)}
+		if output.Spent != nil {
+			outputs[i].SpentIn = blockIdentifierProto(*output.Spent)
+		}
+	}
+	return &pb.WalletOutputsResponse{
+		Wallet:  walletStatusProto(&w.Wallet),
+		Outputs: outputs,
+	}
+}
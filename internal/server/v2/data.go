@@ -0,0 +1,151 @@
+package v2
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// GetTweaks returns the tweaks of a block, the counterpart of GET /tweaks/:blockheight
+func (s *OracleService) GetTweaks(
+	ctx context.Context, req *pb.BlockHeightRequest,
+) (*pb.IndexResponse, error) {
+	logging.L.Info().Any("req", req).Msg("GetTweaks")
+	height, blockhash, err := s.resolveBlock(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	tweaks, err := s.query.Tweaks(height, blockhash)
+	if err != nil {
+		return nil, statusFromError(err, "could not retrieve tweaks from database")
+	}
+	return tweaksProto(tweaks), nil
+}
+
+// GetUtxos returns the taproot outputs of a block, the counterpart of GET /utxos/:blockheight
+func (s *OracleService) GetUtxos(
+	ctx context.Context, req *pb.BlockHeightRequest,
+) (*pb.UTXOResponse, error) {
+	logging.L.Info().Any("req", req).Msg("GetUtxos")
+	height, blockhash, err := s.resolveBlock(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := s.query.UTXOs(height, blockhash)
+	if err != nil {
+		return nil, statusFromError(err, "could not retrieve utxos from database")
+	}
+	return utxosProto(utxos), nil
+}
+
+// GetSpentOutputs returns the shortened spent outputs of a block, the counterpart of GET /spent-outputs/:blockheight
+func (s *OracleService) GetSpentOutputs(
+	ctx context.Context, req *pb.BlockHeightRequest,
+) (*pb.IndexResponse, error) {
+	logging.L.Info().Any("req", req).Msg("GetSpentOutputs")
+	height, blockhash, err := s.resolveBlock(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	spent, err := s.query.SpentOutputs(height, blockhash)
+	if err != nil {
+		return nil, statusFromError(err, "could not retrieve spent outputs from database")
+	}
+	return spentOutputsProto(spent), nil
+}
+
+func (s *OracleService) StreamTweaks(
+	req *pb.RangedBlockHeightRequest,
+	stream pb.OracleService_StreamTweaksServer,
+) error {
+	logging.L.Info().Any("req", req).Msg("StreamTweaks")
+	return s.streamRange(stream.Context(), req.Start, req.End, func(height uint32, blockhash []byte) error {
+		tweaks, err := s.query.Tweaks(height, blockhash)
+		if err != nil {
+			return statusFromError(err, "could not retrieve tweaks from database")
+		}
+		return stream.Send(tweaksProto(tweaks))
+	})
+}
+
+func (s *OracleService) StreamUtxos(
+	req *pb.RangedBlockHeightRequest,
+	stream pb.OracleService_StreamUtxosServer,
+) error {
+	logging.L.Info().Any("req", req).Msg("StreamUtxos")
+	return s.streamRange(stream.Context(), req.Start, req.End, func(height uint32, blockhash []byte) error {
+		utxos, err := s.query.UTXOs(height, blockhash)
+		if err != nil {
+			return statusFromError(err, "could not retrieve utxos from database")
+		}
+		return stream.Send(utxosProto(utxos))
+	})
+}
+
+func (s *OracleService) StreamSpentOutputs(
+	req *pb.RangedBlockHeightRequest,
+	stream pb.OracleService_StreamSpentOutputsServer,
+) error {
+	logging.L.Info().Any("req", req).Msg("StreamSpentOutputs")
+	return s.streamRange(stream.Context(), req.Start, req.End, func(height uint32, blockhash []byte) error {
+		spent, err := s.query.SpentOutputs(height, blockhash)
+		if err != nil {
+			return statusFromError(err, "could not retrieve spent outputs from database")
+		}
+		return stream.Send(spentOutputsProto(spent))
+	})
+}
+
+// streamRange calls send for every indexed block from start to end (inclusive).
+// end is clamped to the synced tip, the stream ends there.
+// Blocks are built one at a time and Send blocks while the client's flow control window is full,
+// so a slow client slows the loop down instead of piling up blocks in memory.
+func (s *OracleService) streamRange(
+	ctx context.Context, start, end uint64, send func(height uint32, blockhash []byte) error,
+) error {
+	first, last, err := s.query.Range(start, end)
+	if err != nil {
+		return statusFromError(err, "could not fetch chain tip")
+	}
+	if err = consumeHeights(ctx, uint64(last-first)+1); err != nil {
+		return err
+	}
+
+	for height := first; height <= last; height++ {
+		if err = ctx.Err(); err != nil {
+			// client went away or the deadline passed
+			return status.FromContextError(err).Err()
+		}
+
+		blockhash, err := s.db.GetBlockHashByHeight(height)
+		if err != nil {
+			logging.L.Err(err).
+				Uint32("height", height).
+				Msg("failed to blockash by height")
+			return statusFromError(err, "could not fetch block hash")
+		}
+		if blockhash == nil {
+			// not indexed
+			continue
+		}
+
+		if err = send(height, blockhash); err != nil {
+			if _, ok := status.FromError(err); ok {
+				return err
+			}
+			logging.L.Err(err).Msg("error sending block batch")
+			return status.Errorf(
+				codes.Internal,
+				"failed to send block batch for height %d", height,
+			)
+		}
+	}
+	return nil
+}
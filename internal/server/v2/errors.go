@@ -0,0 +1,41 @@
+package v2
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/access"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+)
+
+// statusFromError maps the typed database, query and access errors to a gRPC status.
+// Untyped errors are logged and returned as Internal with msg so internals are not exposed.
+func statusFromError(err error, msg string) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, database.ErrNotYetSynced):
+		code = codes.OutOfRange
+	case errors.Is(err, database.ErrPruned):
+		code = codes.NotFound
+	case errors.Is(err, database.ErrFeatureDisabled):
+		code = codes.Unimplemented
+	case errors.Is(err, database.ErrNotOnBestChain):
+		code = codes.FailedPrecondition
+	case errors.Is(err, query.ErrInvalidRange), errors.Is(err, query.ErrInvalidScanKeys):
+		code = codes.InvalidArgument
+	case errors.Is(err, access.ErrUnauthorized):
+		code = codes.Unauthenticated
+	case errors.Is(err, access.ErrRateLimited), errors.Is(err, access.ErrQuotaExceeded):
+		code = codes.ResourceExhausted
+	default:
+		logging.L.Err(err).Msg(msg)
+		return status.Error(codes.Internal, msg)
+	}
+	return status.Error(code, err.Error())
+}
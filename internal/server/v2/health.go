@@ -0,0 +1,38 @@
+package v2
+
+import (
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	oraclehealth "github.com/setavenger/blindbit-oracle/internal/health"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// healthUpdateInterval how often the gRPC health status is refreshed
+const healthUpdateInterval = 5 * time.Second
+
+// runHealthUpdates keeps the standard gRPC health service in line with /readyz
+func runHealthUpdates(srv *health.Server, db database.DB) {
+	updateHealth(srv, db)
+	for range time.Tick(healthUpdateInterval) {
+		updateHealth(srv, db)
+	}
+}
+
+// updateHealth sets the status of the overall server ("") and the OracleService, both report the same
+func updateHealth(srv *health.Server, db database.DB) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if _, _, err := db.GetChainTip(); err != nil {
+		logging.L.Warn().Err(err).Msg("health check: could not read chain tip")
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	} else if err = oraclehealth.Ready(); err != nil {
+		logging.L.Debug().Err(err).Msg("not ready")
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	srv.SetServingStatus("", status)
+	srv.SetServingStatus(pb.OracleService_ServiceDesc.ServiceName, status)
+}
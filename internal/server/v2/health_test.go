@@ -0,0 +1,62 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	oraclehealth "github.com/setavenger/blindbit-oracle/internal/health"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+func TestUpdateHealth(t *testing.T) {
+	store := testhelpers.NewStore(t)
+	applyBlocks(t, store, 1, 'a')
+	savedLag := config.ReadinessMaxLag
+	config.ReadinessMaxLag = 3
+	t.Cleanup(func() { config.ReadinessMaxLag = savedLag })
+
+	srv := health.NewServer()
+	steps := []struct {
+		name  string
+		apply func()
+		ready bool
+	}{
+		{name: "initial sync", apply: func() {
+			oraclehealth.SetIndexerRunning()
+			oraclehealth.SetInitialSync(true)
+			oraclehealth.SetSyncState(1, 2)
+		}},
+		{name: "synced", apply: func() {
+			oraclehealth.SetSyncState(2, 2)
+			oraclehealth.SetInitialSync(false)
+		}, ready: true},
+		{name: "lag above the limit", apply: func() { oraclehealth.SetSyncState(2, 6) }},
+		{name: "caught up", apply: func() { oraclehealth.SetSyncState(6, 6) }, ready: true},
+		{name: "builder error", apply: func() { oraclehealth.SetBuilderError(errors.New("core unreachable")) }},
+		{name: "builder running again", apply: func() { oraclehealth.SetBuilderError(nil) }, ready: true},
+	}
+
+	for _, step := range steps {
+		step.apply()
+		updateHealth(srv, store)
+		want := healthpb.HealthCheckResponse_NOT_SERVING
+		if step.ready {
+			want = healthpb.HealthCheckResponse_SERVING
+		}
+		for _, service := range []string{"", pb.OracleService_ServiceDesc.ServiceName} {
+			resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != want {
+				t.Errorf("%s: service %q is %v, want %v", step.name, service, resp.Status, want)
+			}
+		}
+	}
+}
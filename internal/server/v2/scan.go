@@ -0,0 +1,106 @@
+package v2
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// ScanRange scans the blocks from req.Start to req.End (inclusive) with the receiver's keys on the server
+// and streams the matches of every chunk of heights, also if there are none, so clients can track progress.
+// The request holds the scan secret key and is never logged.
+func (s *OracleService) ScanRange(req *pb.ScanRangeRequest, stream pb.OracleService_ScanRangeServer) error {
+	ctx := stream.Context()
+	if err := scanAllowed(ctx); err != nil {
+		return err
+	}
+
+	keys, err := query.NewScanKeys(req.GetScanSecretKey(), req.GetSpendPubkey(), req.GetLabels())
+	if err != nil {
+		return statusFromError(err, "could not derive labels")
+	}
+
+	first, last, err := s.query.Range(req.GetStart(), req.GetEnd())
+	if err != nil {
+		return statusFromError(err, "could not fetch chain tip")
+	}
+	if err = consumeHeights(ctx, uint64(last-first)+1); err != nil {
+		return err
+	}
+	logging.L.Info().Uint32("start", first).Uint32("end", last).Msg("ScanRange")
+
+	err = s.query.ScanRange(ctx, keys, first, last, func(matches []query.ScanMatch, scannedHeight uint32) error {
+		return stream.Send(scanRangeProto(matches, scannedHeight))
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return status.FromContextError(ctxErr).Err()
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return statusFromError(err, "could not scan range")
+	}
+	return nil
+}
+
+// scanAllowed only lets trusted callers hand over their scan secret key: holders of a scan API key,
+// clients with a verified certificate and native gRPC clients on the same host.
+// gRPC-Web calls never count as local, behind a reverse proxy every browser would.
+func scanAllowed(ctx context.Context) error {
+	if !config.ScanRPC {
+		return status.Error(codes.Unimplemented, "scanning is disabled, see scan_rpc")
+	}
+	if hasScanAPIKey(ctx) {
+		return nil
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+			return nil
+		}
+		if isLocalAddr(p.Addr) {
+			return nil
+		}
+	}
+	return status.Error(codes.PermissionDenied, "scanning is only served to local or authenticated clients")
+}
+
+func hasScanAPIKey(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, apiKey := range md.Get(apiKeyMetadata) {
+		for _, key := range config.ScanAPIKeys {
+			if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isLocalAddr reports unix socket and loopback peers of native gRPC connections.
+// gRPC-Web calls only carry the remote address of the HTTP request as a plain string and are never local.
+func isLocalAddr(addr net.Addr) bool {
+	switch a := addr.(type) {
+	case *net.UnixAddr:
+		return true
+	case *net.TCPAddr:
+		return a.IP.IsLoopback()
+	default:
+		return false
+	}
+}
@@ -1,29 +1,55 @@
 package v2
 
 import (
-	"net"
+	"context"
 
 	"github.com/setavenger/blindbit-lib/logging"
-	"github.com/setavenger/blindbit-lib/proto/pb"
 	"github.com/setavenger/blindbit-oracle/internal/config"
 	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/listener"
+	"github.com/setavenger/blindbit-oracle/internal/tlsconfig"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
-func RunGRPCServer(db database.DB) {
+// NewGRPCServer registers the OracleService and the health service.
+// The same server is served natively on grpc_host and as gRPC-Web on the HTTP listener.
+// With tlsReloader set grpc_host is served over TLS, gRPC-Web uses the TLS of the HTTP listener.
+func NewGRPCServer(db database.DB, tlsReloader *tlsconfig.Reloader) *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryMetricsInterceptor, unaryDrainInterceptor, unaryAccessInterceptor),
+		grpc.ChainStreamInterceptor(streamMetricsInterceptor, streamDrainInterceptor, streamAccessInterceptor),
+	}
+	if tlsReloader != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsReloader.Config())))
+	}
+
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(opts...)
 
 	// Register the OracleService
 	oracleService := NewOracleService(db)
 	pb.RegisterOracleServiceServer(grpcServer, oracleService)
 
+	// Standard health service, reports the same readiness as /readyz
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go runHealthUpdates(healthServer, db)
+
 	// Enable reflection for debugging (optional)
 	reflection.Register(grpcServer)
 
-	// Create listener for gRPC
-	lis, err := net.Listen("tcp", config.GRPCHost)
+	return grpcServer
+}
+
+// RunGRPCServer serves grpc_host until ShutdownGRPCServer stops the server
+func RunGRPCServer(grpcServer *grpc.Server) {
+	// Create listener for gRPC, tcp or unix://
+	lis, err := listener.Listen(config.GRPCHost)
 	if err != nil {
 		logging.L.Err(err).Msg("failed to listen for gRPC")
 		panic(err)
@@ -31,9 +57,19 @@ func RunGRPCServer(db database.DB) {
 
 	logging.L.Info().Msgf("Starting gRPC server on host %s", config.GRPCHost)
 
-	// Start gRPC server
+	// Start gRPC server, returns nil after Stop
 	if err := grpcServer.Serve(lis); err != nil {
 		logging.L.Err(err).Msg("failed to serve gRPC")
 		panic(err)
 	}
 }
+
+// ShutdownGRPCServer rejects new calls, waits for running calls on grpc_host and gRPC-Web
+// until ctx is done and then stops the server, which cancels whatever is left.
+func ShutdownGRPCServer(ctx context.Context, grpcServer *grpc.Server) {
+	logging.L.Info().Msg("draining gRPC calls")
+	if err := inflight.drain(ctx); err != nil {
+		logging.L.Warn().Err(err).Msg("gRPC calls still running at the shutdown deadline, cancelling them")
+	}
+	grpcServer.Stop()
+}
@@ -3,30 +3,30 @@ package v2
 
 import (
 	"context"
-	"encoding/hex"
-	"sort"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/setavenger/blindbit-lib/logging"
-	"github.com/setavenger/blindbit-lib/proto/pb"
 	"github.com/setavenger/blindbit-lib/utils"
-	"github.com/setavenger/blindbit-oracle/internal/config"
 	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
 )
 
 // OracleService implements the gRPC OracleService interface
 type OracleService struct {
-	db database.DB
+	db    database.DB
+	query *query.Service
 	pb.UnimplementedOracleServiceServer
 }
 
 // NewOracleService creates a new OracleService instance
 func NewOracleService(db database.DB) *OracleService {
 	return &OracleService{
-		db: db,
+		db:    db,
+		query: query.New(db),
 	}
 }
 
@@ -37,22 +37,13 @@ func (s *OracleService) GetInfo(
 	*pb.InfoResponse, error,
 ) {
 	logging.L.Info().Msg("GetInfo")
-	blockhash, height, err := s.db.GetChainTip()
+	info, err := s.query.Info()
 	if err != nil {
 		logging.L.Err(err).Msg("failed pulling chain tip")
-		return nil, err
+		return nil, statusFromError(err, "could not fetch chain tip")
 	}
 
-	_ = blockhash //todo: also add current blockhash to info
-
-	return &pb.InfoResponse{
-		Network:                        config.ChainToString(config.Chain),
-		Height:                         uint64(height),
-		TweaksOnly:                     config.TweaksOnly,
-		TweaksFullBasic:                config.TweakIndexFullNoDust,
-		TweaksFullWithDustFilter:       config.TweakIndexFullIncludingDust,
-		TweaksCutThroughWithDustFilter: config.TweaksCutThroughWithDust,
-	}, nil
+	return infoProto(info), nil
 }
 
 // GetBestBlockHeight returns the current best block height
@@ -60,10 +51,10 @@ func (s *OracleService) GetBestBlockHeight(
 	ctx context.Context, _ *emptypb.Empty,
 ) (*pb.BlockHeightResponse, error) {
 	logging.L.Info().Msg("GetBestBlockHeight")
-	_, height, err := s.db.GetChainTip()
+	height, err := s.query.TipHeight()
 	if err != nil {
 		logging.L.Err(err).Msg("failed pulling chain tip")
-		return nil, err
+		return nil, statusFromError(err, "could not fetch chain tip")
 	}
 
 	return &pb.BlockHeightResponse{
@@ -76,11 +67,8 @@ func (s *OracleService) GetBlockHashByHeight(
 	ctx context.Context, req *pb.BlockHeightRequest,
 ) (*pb.BlockHashResponse, error) {
 	logging.L.Info().Any("req", req).Msg("GetBlockHashByHeight")
-	blockhash, err := s.db.GetBlockHashByHeight(uint32(req.BlockHeight))
+	_, blockhash, err := s.resolveBlock(ctx, req)
 	if err != nil {
-		logging.L.Err(err).
-			Uint64("height", req.BlockHeight).
-			Msg("failed pulling blockhash for height")
 		return nil, err
 	}
 
@@ -89,51 +77,55 @@ func (s *OracleService) GetBlockHashByHeight(
 	}, nil
 }
 
+// resolveBlock returns height and blockhash for either variant of the request.
+// Hashes are given in display order and have to be on the best chain.
+func (s *OracleService) resolveBlock(ctx context.Context, req *pb.BlockHeightRequest) (uint32, []byte, error) {
+	if err := consumeHeights(ctx, 1); err != nil {
+		return 0, nil, err
+	}
+
+	switch block := req.GetBlock().(type) {
+	case *pb.BlockHeightRequest_BlockHash:
+		if len(block.BlockHash) != 32 {
+			return 0, nil, status.Error(codes.InvalidArgument, "block hash has to be 32 bytes")
+		}
+		height, blockhash, err := s.query.BlockByHash(block.BlockHash)
+		if err != nil {
+			return 0, nil, statusFromError(err, "could not fetch block height")
+		}
+		return height, blockhash, nil
+	default:
+		// an unset oneof is treated as height 0 like before the oneof existed
+		height := uint32(req.GetBlockHeight())
+		blockhash, err := s.query.BlockByHeight(height)
+		if err != nil {
+			return 0, nil, statusFromError(err, "could not fetch block hash")
+		}
+		return height, blockhash, nil
+	}
+}
+
 func (s *OracleService) StreamComputeIndex(
 	req *pb.RangedBlockHeightRequestFiltered,
 	stream pb.OracleService_StreamComputeIndexServer,
 ) error {
 	logging.L.Info().Any("req", req).Msg("StreamComputeIndexServer")
-	for height := req.Start; height <= req.End; height++ {
-		logging.L.Trace().Uint64("height", height).Msg("processing height")
-		blockhash, err := s.db.GetBlockHashByHeight(uint32(height))
-		if err != nil {
-			logging.L.Err(err).
-				Uint64("height", height).
-				Msg("failed to blockash by height")
-			return err
-		}
-
-		computeIndex, err := s.db.FetchComputeIndex(uint32(height))
+	filter, err := s.query.NewComputeIndexFilter(req.GetDustlimit(), req.GetCutThrough())
+	if err != nil {
+		return statusFromError(err, "could not fetch chain tip")
+	}
+	return s.streamRange(stream.Context(), req.Start, req.End, func(height uint32, blockhash []byte) error {
+		computeIndex, err := s.query.ComputeIndex(height, blockhash, filter)
 		if err != nil {
-			logging.L.Err(err).
-				Uint64("height", height).
-				Msg("failed to pull short outs")
-			return err
-		}
-
-		batch := &pb.ComputeIndexResponse{
-			BlockIdentifier: &pb.BlockIdentifier{
-				BlockHash:   utils.ReverseBytesCopy(blockhash),
-				BlockHeight: height,
-			},
-			Index: computeIndex,
+			return statusFromError(err, "could not retrieve compute index from database")
 		}
 
 		logging.L.Debug().
-			Int("height", int(height)).
-			Int("count", len(computeIndex)).
+			Uint32("height", height).
+			Int("count", len(computeIndex.Txs)).
 			Msg("sending block batch")
-		if err := stream.Send(batch); err != nil {
-			logging.L.Err(err).Msg("error sending block batch")
-			return status.Errorf(
-				codes.Internal,
-				"failed to send block batch for height %d", height,
-			)
-		}
-	}
-
-	return nil
+		return stream.Send(computeIndexProto(computeIndex))
+	})
 }
 
 func (s *OracleService) StreamBlockScanDataShort(
@@ -141,55 +133,22 @@ func (s *OracleService) StreamBlockScanDataShort(
 	stream pb.OracleService_StreamBlockScanDataShortServer,
 ) error {
 	logging.L.Info().Any("req", req).Msg("StreamBlockScanDataShort")
-	for height := req.Start; height <= req.End; height++ {
-		blockhash, err := s.db.GetBlockHashByHeight(uint32(height))
-		if err != nil {
-			logging.L.Err(err).
-				Uint64("height", height).
-				Msg("failed to blockash by height")
-			return err
-		}
-
-		computeIndex, err := s.db.FetchComputeIndex(uint32(height))
-		if err != nil {
-			logging.L.Err(err).
-				Uint64("height", height).
-				Msg("failed to pull short outs")
-			return err
-		}
-
-		var spentOuts []byte
-		spentOuts, err = s.db.FetchSpentOutputsShort(blockhash)
+	filter, err := s.query.NewComputeIndexFilter(req.GetDustlimit(), req.GetCutThrough())
+	if err != nil {
+		return statusFromError(err, "could not fetch chain tip")
+	}
+	return s.streamRange(stream.Context(), req.Start, req.End, func(height uint32, blockhash []byte) error {
+		scanData, err := s.query.BlockScanData(height, blockhash, filter)
 		if err != nil {
-			logging.L.Err(err).
-				Uint64("height", height).
-				Msg("failed to pull spent outputs")
-			return err
-		}
-
-		batch := &pb.BlockScanDataShortResponse{
-			BlockIdentifier: &pb.BlockIdentifier{
-				BlockHash:   utils.ReverseBytesCopy(blockhash),
-				BlockHeight: height,
-			},
-			CompIndex:    computeIndex,
-			SpentOutputs: spentOuts,
+			return statusFromError(err, "could not retrieve scan data from database")
 		}
 
 		logging.L.Trace().
-			Int("height", int(height)).
-			Int("count", len(computeIndex)).
+			Uint32("height", height).
+			Int("count", len(scanData.ComputeIndex)).
 			Msg("sending block batch")
-		if err := stream.Send(batch); err != nil {
-			logging.L.Err(err).Msg("error sending block batch")
-			return status.Errorf(
-				codes.Internal,
-				"failed to send block batch for height %d", height,
-			)
-		}
-	}
-
-	return nil
+		return stream.Send(blockScanDataProto(scanData))
+	})
 }
 
 // GetFullBlock returns complete block data with all transaction details
@@ -197,124 +156,14 @@ func (s *OracleService) GetFullBlock(
 	ctx context.Context, req *pb.BlockHeightRequest,
 ) (*pb.FullBlockResponse, error) {
 	logging.L.Info().Any("req", req).Msg("GetFullBlock")
-	blockhash, err := s.db.GetBlockHashByHeight(uint32(req.BlockHeight))
-	if err != nil {
-		logging.L.Err(err).
-			Uint64("height", req.BlockHeight).
-			Msg("could not fetch block hash")
-		return nil, status.Errorf(codes.Internal, "could not fetch block hash: %v", err)
-	}
-
-	// Get chain tip for FetchOutputsAll
-	_, syncTip, err := s.db.GetChainTip()
-	if err != nil {
-		logging.L.Err(err).Msg("could not fetch chain tip")
-		return nil, status.Errorf(codes.Internal, "could not fetch chain tip: %v", err)
-	}
-
-	// Fetch all the data we need for the full block
-	outputs, err := s.db.FetchOutputsAll(blockhash, syncTip)
-	if err != nil {
-		logging.L.Err(err).Msg("error fetching outputs")
-		return nil, status.Errorf(codes.Internal, "could not retrieve outputs from database: %v", err)
-	}
-
-	// Fetch tweaks with transaction IDs
-	tweakRows, err := s.db.TweaksForBlockAll(blockhash)
+	height, blockhash, err := s.resolveBlock(ctx, req)
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching tweak rows")
-		return nil, status.Errorf(codes.Internal, "could not retrieve tweaks from database: %v", err)
-	}
-
-	// Group outputs by transaction ID
-	txOutputs := make(map[string][]*database.Output)
-	for _, output := range outputs {
-		if output != nil {
-			txid := hex.EncodeToString(output.Txid)
-			txOutputs[txid] = append(txOutputs[txid], output)
-		}
-	}
-
-	// Create a map of txid to tweak for quick lookup
-	txidToTweak := make(map[string][33]byte)
-	for _, tweakRow := range tweakRows {
-		if tweakRow != nil {
-			txid := hex.EncodeToString(tweakRow.Txid[:])
-			txidToTweak[txid] = tweakRow.Tweak
-		}
+		return nil, err
 	}
 
-	// Fetch all txid-outpoints mappings for this block
-	txidOutpointsMap, err := s.db.FetchAllTxidOutpointsForBlock(blockhash)
+	fullBlock, err := s.query.FullBlock(height, blockhash)
 	if err != nil {
-		logging.L.Err(err).Msg("error fetching txid-outpoints mappings")
-		return nil, status.Errorf(codes.Internal, "could not retrieve txid-outpoints from database: %v", err)
-	}
-
-	// Build the full transaction items
-	var fullTxItems []*pb.FullTxItem
-
-	// Sort transaction IDs to ensure consistent ordering
-	var sortedTxids []string
-	for txid := range txOutputs {
-		sortedTxids = append(sortedTxids, txid)
-	}
-	sort.Strings(sortedTxids)
-
-	for _, txid := range sortedTxids {
-		outputs := txOutputs[txid]
-		txidBytes, err := hex.DecodeString(txid)
-		if err != nil {
-			logging.L.Err(err).Msg("error decoding txid")
-			continue
-		}
-
-		var txidArray [32]byte
-		copy(txidArray[:], txidBytes)
-
-		// Get tweak for this transaction
-		var tweak [33]byte
-		if tweakBytes, exists := txidToTweak[txid]; exists {
-			tweak = tweakBytes
-		}
-
-		// Get inputs (spent outpoints) for this transaction
-		var inputs []byte
-		if outpoints, exists := txidOutpointsMap[txidArray]; exists {
-			for i := range outpoints {
-				utils.ReverseBytes(outpoints[i][:32])
-				inputs = append(inputs, outpoints[i][:]...)
-			}
-		}
-
-		// Convert outputs to UTXO items
-		var utxoItems []*pb.UTXOItemLight
-		for _, output := range outputs {
-			var pubkey [32]byte
-			copy(pubkey[:], output.Pubkey)
-
-			utxoItems = append(utxoItems, &pb.UTXOItemLight{
-				Vout:   output.Vout,
-				Amount: output.Amount,
-				Pubkey: pubkey[:],
-			})
-		}
-
-		fullTxItems = append(fullTxItems, &pb.FullTxItem{
-			Txid:   utils.ReverseBytesCopy(txidArray[:]),
-			Tweak:  tweak[:],
-			Inputs: inputs,
-			Utxos:  utxoItems,
-		})
-	}
-
-	response := &pb.FullBlockResponse{
-		BlockIdentifier: &pb.BlockIdentifier{
-			BlockHash:   utils.ReverseBytesCopy(blockhash),
-			BlockHeight: req.BlockHeight,
-		},
-		Index: fullTxItems,
+		return nil, statusFromError(err, "could not retrieve full block from database")
 	}
-
-	return response, nil
+	return fullBlockProto(fullBlock), nil
 }
@@ -0,0 +1,91 @@
+package v2
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// inflight counts running RPCs for the shutdown.
+// grpc.Server.GracefulStop panics on gRPC-Web calls served through ServeHTTP,
+// so the calls are drained here and the server is stopped hard afterwards.
+var inflight = new(rpcTracker)
+
+var errShuttingDown = status.Error(codes.Unavailable, "server is shutting down")
+
+type rpcTracker struct {
+	mu       sync.Mutex
+	draining bool
+	wg       sync.WaitGroup
+}
+
+// begin returns false once draining started, the call has to be rejected then
+func (t *rpcTracker) begin() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return false
+	}
+	t.wg.Add(1)
+	return true
+}
+
+func (t *rpcTracker) end() {
+	t.wg.Done()
+}
+
+// drain rejects new calls and waits for the running ones until ctx is done
+func (t *rpcTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// health checks and reflection are not waited for, Watch streams never end by themselves
+func unaryDrainInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if exemptFromAccess(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	if !inflight.begin() {
+		return nil, errShuttingDown
+	}
+	defer inflight.end()
+	return handler(ctx, req)
+}
+
+func streamDrainInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if exemptFromAccess(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	if !inflight.begin() {
+		return errShuttingDown
+	}
+	defer inflight.end()
+	return handler(srv, ss)
+}
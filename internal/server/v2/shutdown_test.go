@@ -0,0 +1,76 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDrainWaitsForRunningCalls(t *testing.T) {
+	tracker := new(rpcTracker)
+	if !tracker.begin() {
+		t.Fatal("call rejected before draining")
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- tracker.drain(context.Background()) }()
+
+	// once draining new calls are refused while the running one continues
+	deadline := time.Now().Add(time.Second)
+	for tracker.begin() {
+		tracker.end()
+		if time.Now().After(deadline) {
+			t.Fatal("new calls still accepted while draining")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-drained:
+		t.Fatal("drain returned with a call running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	tracker.end()
+	if err := <-drained; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDrainDeadline(t *testing.T) {
+	tracker := new(rpcTracker)
+	tracker.begin()
+	defer tracker.end()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tracker.drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want deadline exceeded", err)
+	}
+}
+
+func TestDrainInterceptorRejects(t *testing.T) {
+	saved := inflight
+	inflight = new(rpcTracker)
+	t.Cleanup(func() { inflight = saved })
+	inflight.drain(context.Background())
+
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+
+	_, err := unaryDrainInterceptor(context.Background(), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/blindbit.oracle.v1.OracleService/GetInfo"}, handler)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("got %v, want Unavailable", err)
+	}
+
+	// probes keep answering until the server is stopped
+	resp, err := unaryDrainInterceptor(context.Background(), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	if err != nil || resp != "ok" {
+		t.Errorf("health check rejected: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/events"
+	"github.com/setavenger/blindbit-oracle/internal/query"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+const (
+	// subscribeWindow how many sent blockhashes are kept to find the fork point of a reorg
+	subscribeWindow = 144
+	// subscribePollInterval picks up blocks indexed by another process, which publishes no events
+	subscribePollInterval = 5 * time.Second
+	subscribeEventsBuffer = 16
+)
+
+// SubscribeBlocks replays the blocks after req.LastBlock and then pushes new blocks once they are committed.
+// Without LastBlock the stream starts with the next new block.
+//
+// If sent blocks are replaced by a reorg a rewind to the last block still on the best chain is sent,
+// followed by the blocks of the new chain. The client drops everything above the rewind block.
+// Should the client's hash at the rewind height differ as well, the fork is deeper than
+// this stream knows of and the client resubscribes from an earlier block.
+func (s *OracleService) SubscribeBlocks(
+	req *pb.SubscribeBlocksRequest,
+	stream pb.OracleService_SubscribeBlocksServer,
+) error {
+	logging.L.Info().Any("req", req).Msg("SubscribeBlocks")
+	ctx := stream.Context()
+
+	// subscribe before the replay so no block committed in between is missed
+	notify, unsubscribe := events.Blocks.Subscribe(subscribeEventsBuffer)
+	defer func() { unsubscribe() }()
+
+	sub := &blockSubscription{
+		s:      s,
+		stream: stream,
+		sent:   make(map[uint32][]byte),
+	}
+	if err := sub.start(req.GetLastBlock()); err != nil {
+		return err
+	}
+
+	poll := time.NewTicker(subscribePollInterval)
+	defer poll.Stop()
+
+	full := true
+	for {
+		if err := sub.catchUp(ctx, full); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case ev, ok := <-notify:
+			if !ok {
+				select {
+				case <-events.Blocks.Closed():
+					return errShuttingDown
+				default:
+				}
+				// dropped for being slow, nothing is lost as catchUp reads from the store
+				notify, unsubscribe = events.Blocks.Subscribe(subscribeEventsBuffer)
+			}
+			full = !ok || ev.Type == events.BlockDisconnected
+		case <-poll.C:
+			// another process may have indexed a reorg without events
+			full = true
+		}
+	}
+}
+
+// blockSubscription tracks what was sent to one SubscribeBlocks client
+type blockSubscription struct {
+	s      *OracleService
+	stream pb.OracleService_SubscribeBlocksServer
+
+	lastHeight uint32
+	// sent blockhashes by height, the last subscribeWindow blocks
+	sent map[uint32][]byte
+}
+
+// start sets the block the client has, the tip if it did not send one
+func (b *blockSubscription) start(last *pb.BlockIdentifier) error {
+	if last == nil {
+		blockhash, height, err := b.s.db.GetChainTip()
+		if err != nil {
+			return statusFromError(err, "could not fetch chain tip")
+		}
+		b.lastHeight = height
+		b.sent[height] = blockhash
+		return nil
+	}
+
+	if len(last.BlockHash) != 32 {
+		return status.Error(codes.InvalidArgument, "block hash has to be 32 bytes")
+	}
+	blockhash := utils.ReverseBytesCopy(last.BlockHash)
+
+	// a block replaced by a reorg still resolves to its former height,
+	// catchUp then sends the rewind
+	height, err := b.s.db.BlockHeightByHash(blockhash)
+	if err != nil && !errors.Is(err, database.ErrNotOnBestChain) {
+		return statusFromError(err, "could not fetch block height")
+	}
+	if uint64(height) != last.BlockHeight {
+		return status.Errorf(
+			codes.InvalidArgument,
+			"block is at height %d not %d", height, last.BlockHeight,
+		)
+	}
+
+	b.lastHeight = height
+	b.sent[height] = blockhash
+	return nil
+}
+
+// catchUp rewinds if sent blocks were replaced and sends everything up to the tip of the store.
+// full checks every sent block in the window for a reorg, see rewindIfReorged.
+func (b *blockSubscription) catchUp(ctx context.Context, full bool) error {
+	if err := b.rewindIfReorged(full); err != nil {
+		return err
+	}
+
+	_, tipHeight, err := b.s.db.GetChainTip()
+	if err != nil {
+		return statusFromError(err, "could not fetch chain tip")
+	}
+
+	for height := b.lastHeight + 1; height <= tipHeight; height++ {
+		if err = ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		blockhash, err := b.s.db.GetBlockHashByHeight(height)
+		if err != nil {
+			return statusFromError(err, "could not fetch block hash")
+		}
+		if blockhash == nil {
+			// blocks can be committed out of order during a sync,
+			// wait for the gap to be filled instead of skipping it
+			return nil
+		}
+
+		if err = consumeHeights(ctx, 1); err != nil {
+			return err
+		}
+
+		block, err := b.s.query.BlockScanData(height, blockhash, query.ComputeIndexFilter{})
+		if err != nil {
+			logging.L.Err(err).Uint32("height", height).Msg("failed to pull block scan data")
+			return status.Errorf(codes.Internal, "could not retrieve block %d", height)
+		}
+		err = b.stream.Send(&pb.SubscribeBlocksResponse{
+			Event: &pb.SubscribeBlocksResponse_Block{Block: blockScanDataProto(block)},
+		})
+		if err != nil {
+			logging.L.Debug().Err(err).Uint32("height", height).Msg("failed sending block")
+			return err
+		}
+
+		b.lastHeight = height
+		b.sent[height] = blockhash
+		delete(b.sent, height-subscribeWindow)
+	}
+
+	return nil
+}
+
+// rewindIfReorged finds the lowest sent block which is no longer on the best chain
+// and rewinds to the block below it. Unless full is set only the blocks from the last one down
+// to the first match are checked, a disconnect can replace blocks below a newer tip though.
+// Below the window (or the client's resume block) the best chain block is assumed to be shared.
+func (b *blockSubscription) rewindIfReorged(full bool) error {
+	lowest := b.lastHeight + 1
+	for height := b.lastHeight; ; height-- {
+		sentHash, ok := b.sent[height]
+		if !ok {
+			break
+		}
+		bestHash, err := b.s.db.GetBlockHashByHeight(height)
+		if err != nil {
+			return statusFromError(err, "could not fetch block hash")
+		}
+		if !bytes.Equal(sentHash, bestHash) {
+			lowest = height
+		} else if !full {
+			break
+		}
+		if height == 0 {
+			break
+		}
+	}
+	if lowest > b.lastHeight {
+		return nil
+	}
+
+	for height := lowest; height <= b.lastHeight; height++ {
+		delete(b.sent, height)
+	}
+	rewindHeight := lowest
+	if lowest > 0 {
+		rewindHeight = lowest - 1
+	}
+
+	bestHash, err := b.s.db.GetBlockHashByHeight(rewindHeight)
+	if err != nil {
+		return statusFromError(err, "could not fetch block hash")
+	}
+
+	logging.L.Info().
+		Uint32("from_height", b.lastHeight).
+		Uint32("to_height", rewindHeight).
+		Msg("rewinding block subscription")
+
+	err = b.stream.Send(&pb.SubscribeBlocksResponse{
+		Event: &pb.SubscribeBlocksResponse_Rewind{
+			Rewind: &pb.BlockRewind{
+				Block: &pb.BlockIdentifier{
+					BlockHash:   utils.ReverseBytesCopy(bestHash),
+					BlockHeight: uint64(rewindHeight),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	b.lastHeight = rewindHeight
+	b.sent[rewindHeight] = bestHash
+	return nil
+}
@@ -0,0 +1,230 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/setavenger/blindbit-lib/utils"
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+	"github.com/setavenger/blindbit-oracle/internal/testhelpers"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// subscribeStream records what is sent to a SubscribeBlocks client
+type subscribeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.SubscribeBlocksResponse
+}
+
+func (s *subscribeStream) Context() context.Context { return s.ctx }
+
+func (s *subscribeStream) Send(resp *pb.SubscribeBlocksResponse) error {
+	s.sent = append(s.sent, resp)
+	return nil
+}
+
+// takeEvents returns the sent events as "block <height> <hash>" and "rewind <height> <hash>"
+// with the first hash byte, and resets the stream
+func (s *subscribeStream) takeEvents(t *testing.T) []string {
+	t.Helper()
+	var events []string
+	for _, resp := range s.sent {
+		var kind string
+		var id *pb.BlockIdentifier
+		switch {
+		case resp.GetBlock() != nil:
+			kind, id = "block", resp.GetBlock().GetBlockIdentifier()
+		case resp.GetRewind() != nil:
+			kind, id = "rewind", resp.GetRewind().GetBlock()
+		default:
+			t.Fatalf("empty event %v", resp)
+		}
+		// hashes are sent in display order
+		hash := utils.ReverseBytesCopy(id.GetBlockHash())
+		events = append(events, blockEvent(kind, uint32(id.GetBlockHeight()), hash[0]))
+	}
+	s.sent = nil
+	return events
+}
+
+func blockEvent(kind string, height uint32, hash byte) string {
+	return fmt.Sprintf("%s %d %c", kind, height, hash)
+}
+
+// applyBlocks indexes a block with blockhash {hash} at each height from start on
+func applyBlocks(t *testing.T, store *dbpebble.Store, start uint32, hashes ...byte) {
+	t.Helper()
+	blocks := make([]*database.DBBlock, len(hashes))
+	for i, hash := range hashes {
+		blocks[i] = &database.DBBlock{Height: start + uint32(i), Hash: &chainhash.Hash{hash}}
+	}
+	testhelpers.ApplyBlocks(t, store, blocks...)
+}
+
+func newBlockSubscription(store database.DB, stream *subscribeStream) *blockSubscription {
+	return &blockSubscription{
+		s:      NewOracleService(store),
+		stream: stream,
+		sent:   make(map[uint32][]byte),
+	}
+}
+
+func lastBlock(height uint32, hash byte) *pb.BlockIdentifier {
+	h := chainhash.Hash{hash}
+	return &pb.BlockIdentifier{BlockHash: utils.ReverseBytesCopy(h[:]), BlockHeight: uint64(height)}
+}
+
+// sent blocks replaced by a reorg are rewound to the fork point before the new chain is sent
+func TestSubscribeBlocksRewind(t *testing.T) {
+	ctx := context.Background()
+	store := testhelpers.NewStore(t)
+	applyBlocks(t, store, 1, 'a', 'b', 'c')
+
+	stream := &subscribeStream{ctx: ctx}
+	sub := newBlockSubscription(store, stream)
+	if err := sub.start(lastBlock(1, 'a')); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.catchUp(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{blockEvent("block", 2, 'b'), blockEvent("block", 3, 'c')}
+	if got := stream.takeEvents(t); !slices.Equal(got, want) {
+		t.Fatalf("replay: got %q, want %q", got, want)
+	}
+
+	// nothing new, nothing sent
+	if err := sub.catchUp(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := stream.takeEvents(t); len(got) != 0 {
+		t.Fatalf("sent %q without new blocks", got)
+	}
+
+	// 2 and 3 are replaced and the new chain is one block longer
+	applyBlocks(t, store, 2, 'B', 'C', 'D')
+	if err := sub.catchUp(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		blockEvent("rewind", 1, 'a'),
+		blockEvent("block", 2, 'B'),
+		blockEvent("block", 3, 'C'),
+		blockEvent("block", 4, 'D'),
+	}
+	if got := stream.takeEvents(t); !slices.Equal(got, want) {
+		t.Fatalf("reorg: got %q, want %q", got, want)
+	}
+
+	// a replaced block below the new tip is only found by a full check, as after a disconnect
+	applyBlocks(t, store, 3, 'x')
+	if err := sub.catchUp(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := stream.takeEvents(t); len(got) != 0 {
+		t.Fatalf("partial check sent %q", got)
+	}
+	if err := sub.catchUp(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		blockEvent("rewind", 2, 'B'),
+		blockEvent("block", 3, 'x'),
+		blockEvent("block", 4, 'D'),
+	}
+	if got := stream.takeEvents(t); !slices.Equal(got, want) {
+		t.Fatalf("full check: got %q, want %q", got, want)
+	}
+}
+
+// a client resuming from a block which was replaced while it was offline is rewound first
+func TestSubscribeBlocksResumeFromReplacedBlock(t *testing.T) {
+	ctx := context.Background()
+	store := testhelpers.NewStore(t)
+	applyBlocks(t, store, 1, 'a', 'b', 'c')
+	applyBlocks(t, store, 2, 'B', 'C')
+
+	stream := &subscribeStream{ctx: ctx}
+	sub := newBlockSubscription(store, stream)
+	if err := sub.start(lastBlock(3, 'c')); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.catchUp(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	// the client only knows 3 was replaced, 2 is rewound once the subscription sees it
+	want := []string{
+		blockEvent("rewind", 2, 'B'),
+		blockEvent("block", 3, 'C'),
+	}
+	if got := stream.takeEvents(t); !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// the height has to match the hash
+	err := newBlockSubscription(store, stream).start(lastBlock(2, 'c'))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("wrong height: got %v, want InvalidArgument", err)
+	}
+	err = newBlockSubscription(store, stream).start(lastBlock(9, 'z'))
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown block: got %v, want NotFound", err)
+	}
+}
+
+// only the last subscribeWindow sent blocks are checked for a reorg
+func TestSubscribeBlocksWindow(t *testing.T) {
+	ctx := context.Background()
+	store := testhelpers.NewStore(t)
+	const blocks = subscribeWindow + 10
+	for height := uint32(1); height <= blocks; height++ {
+		applyBlocks(t, store, height, byte(height))
+	}
+
+	stream := &subscribeStream{ctx: ctx}
+	sub := newBlockSubscription(store, stream)
+	if err := sub.start(lastBlock(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.catchUp(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != blocks-1 {
+		t.Fatalf("replayed %d blocks, want %d", len(stream.sent), blocks-1)
+	}
+	stream.sent = nil
+	if len(sub.sent) != subscribeWindow {
+		t.Fatalf("kept %d hashes, want %d", len(sub.sent), subscribeWindow)
+	}
+	if _, ok := sub.sent[blocks-subscribeWindow+1]; !ok {
+		t.Fatalf("oldest hash in the window missing")
+	}
+
+	// a block below the window is assumed to be shared, only the tip is rewound
+	applyBlocks(t, store, 2, 0xf2)
+	applyBlocks(t, store, blocks, 0xff)
+	if err := sub.catchUp(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 2 {
+		t.Fatalf("sent %d events, want rewind and block", len(stream.sent))
+	}
+	rewind := stream.sent[0].GetRewind().GetBlock()
+	if rewind.GetBlockHeight() != blocks-1 {
+		t.Errorf("rewound to %d, want %d", rewind.GetBlockHeight(), blocks-1)
+	}
+	block := stream.sent[1].GetBlock().GetBlockIdentifier()
+	if block.GetBlockHeight() != blocks || !bytes.Equal(block.GetBlockHash()[31:], []byte{0xff}) {
+		t.Errorf("got block %d %x after the rewind", block.GetBlockHeight(), block.GetBlockHash())
+	}
+}
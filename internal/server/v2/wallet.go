@@ -0,0 +1,87 @@
+package v2
+
+import (
+	"context"
+	"math"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/proto/pb"
+)
+
+// Watch-only wallets are trusted with the scan secret key like ScanRange, see scanAllowed.
+// Requests are never logged.
+
+// RegisterWallet stores the keys so the indexer scans every block from req.BirthHeight on for them.
+// Registering the same keys again replaces labels and birth height and starts the scan over.
+func (s *OracleService) RegisterWallet(ctx context.Context, req *pb.RegisterWalletRequest) (*pb.WalletStatus, error) {
+	if err := scanAllowed(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetBirthHeight() > math.MaxUint32 {
+		return nil, status.Error(codes.InvalidArgument, "birth height is not a valid block height")
+	}
+
+	wallet, err := s.query.RegisterWallet(
+		req.GetScanSecretKey(), req.GetSpendPubkey(), req.GetLabels(), uint32(req.GetBirthHeight()),
+	)
+	if err != nil {
+		return nil, statusFromError(err, "could not register wallet")
+	}
+	logging.L.Info().Hex("wallet_id", wallet.ID[:]).Uint32("birth_height", wallet.BirthHeight).Msg("RegisterWallet")
+	return walletStatusProto(wallet), nil
+}
+
+func (s *OracleService) UnregisterWallet(ctx context.Context, req *pb.WalletRequest) (*emptypb.Empty, error) {
+	id, err := walletID(ctx, req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+	if err = s.query.UnregisterWallet(id); err != nil {
+		return nil, statusFromError(err, "could not remove wallet")
+	}
+	logging.L.Info().Hex("wallet_id", id[:]).Msg("UnregisterWallet")
+	return &emptypb.Empty{}, nil
+}
+
+// GetWalletStatus tells how far the indexer has scanned the wallet
+func (s *OracleService) GetWalletStatus(ctx context.Context, req *pb.WalletRequest) (*pb.WalletStatus, error) {
+	id, err := walletID(ctx, req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := s.query.Wallet(id)
+	if err != nil {
+		return nil, statusFromError(err, "could not fetch wallet")
+	}
+	return walletStatusProto(wallet), nil
+}
+
+// GetWalletOutputs returns the outputs found for the wallet up to its scanned height,
+// spent ones only with req.IncludeSpent
+func (s *OracleService) GetWalletOutputs(
+	ctx context.Context, req *pb.WalletOutputsRequest,
+) (*pb.WalletOutputsResponse, error) {
+	id, err := walletID(ctx, req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := s.query.WalletOutputs(id, req.GetIncludeSpent())
+	if err != nil {
+		return nil, statusFromError(err, "could not fetch wallet outputs")
+	}
+	return walletOutputsProto(outputs), nil
+}
+
+func walletID(ctx context.Context, id []byte) ([32]byte, error) {
+	if err := scanAllowed(ctx); err != nil {
+		return [32]byte{}, err
+	}
+	if len(id) != 32 {
+		return [32]byte{}, status.Error(codes.InvalidArgument, "wallet id has to be 32 bytes")
+	}
+	return [32]byte(id), nil
+}
@@ -0,0 +1,31 @@
+package v2
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc"
+
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+// NewGRPCWebServer wraps grpcServer for browsers, which can't speak native gRPC.
+// Server streams (e.g. StreamBlockScanDataShort) are sent as a chunked HTTP/1.1 response
+// or over a websocket. Interceptors, and with them access control and metrics, apply as usual.
+func NewGRPCWebServer(grpcServer *grpc.Server) *grpcweb.WrappedGrpcServer {
+	return grpcweb.WrapServer(
+		grpcServer,
+		grpcweb.WithOriginFunc(originAllowed),
+		grpcweb.WithWebsockets(true),
+		grpcweb.WithWebsocketOriginFunc(func(req *http.Request) bool {
+			return originAllowed(req.Header.Get("Origin"))
+		}),
+	)
+}
+
+// originAllowed follows cors_allowed_origins of the HTTP API
+func originAllowed(origin string) bool {
+	return slices.Contains(config.CORSAllowedOrigins, "*") ||
+		slices.Contains(config.CORSAllowedOrigins, origin)
+}
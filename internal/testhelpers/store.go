@@ -0,0 +1,38 @@
+// Package testhelpers holds the fixtures shared by the tests of several packages.
+package testhelpers
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+
+	"github.com/setavenger/blindbit-oracle/internal/database"
+	"github.com/setavenger/blindbit-oracle/internal/database/dbpebble"
+)
+
+// NewStore opens a store on an in-memory db with blocks indexed, it is closed when the test ends
+func NewStore(t testing.TB, blocks ...*database.DBBlock) *dbpebble.Store {
+	t.Helper()
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := dbpebble.NewStore(db)
+	t.Cleanup(func() { store.Close() })
+	ApplyBlocks(t, store, blocks...)
+	return store
+}
+
+// ApplyBlocks indexes blocks in order and flushes them to the db
+func ApplyBlocks(t testing.TB, store database.DB, blocks ...*database.DBBlock) {
+	t.Helper()
+	for _, block := range blocks {
+		if err := store.ApplyBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.FlushBatch(true); err != nil {
+		t.Fatal(err)
+	}
+}
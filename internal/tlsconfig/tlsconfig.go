@@ -0,0 +1,146 @@
+// Package tlsconfig serves the HTTP and gRPC listeners with a certificate
+// that can be replaced at runtime (SIGHUP) and optionally verifies client certificates (mTLS).
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/setavenger/blindbit-lib/logging"
+	"github.com/setavenger/blindbit-oracle/internal/config"
+)
+
+// Reloader holds the current certificate and client CAs.
+// Connections established before a reload keep their certificate.
+type Reloader struct {
+	certFile, keyFile, clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// New loads the files, clientCAFile is optional and enables mTLS
+func New(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls needs both a certificate and a key file")
+	}
+	r := &Reloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FromConfig returns nil if no certificate is configured
+func FromConfig() (*Reloader, error) {
+	if config.TLSCertFile == "" && config.TLSKeyFile == "" {
+		if config.TLSClientCAFile != "" {
+			return nil, errors.New("tls_client_ca_file needs tls_cert_file and tls_key_file")
+		}
+		return nil, nil
+	}
+	return New(config.TLSCertFile, config.TLSKeyFile, config.TLSClientCAFile)
+}
+
+// Reload reads the files again. On error the previous certificate stays in use.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client ca file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("no certificates found in client ca file")
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.mu.Unlock()
+	return nil
+}
+
+// MutualTLS reports whether clients have to present a certificate
+func (r *Reloader) MutualTLS() bool {
+	return r.clientCAFile != ""
+}
+
+// Config returns a tls.Config which always uses the latest certificate and client CAs.
+// Every listener gets its own config so NextProtos can be set per protocol.
+func (r *Reloader) Config() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+	}
+	if r.MutualTLS() {
+		// the chain is verified by hand, tls.Config.ClientCAs could not be swapped on reload
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyPeerCertificate = r.verifyClient
+	}
+	return cfg
+}
+
+func (r *Reloader) verifyClient(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no client certificate")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCerts[i])
+		if err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	r.mu.RLock()
+	roots := r.clientCAs
+	r.mu.RUnlock()
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+// ReloadOnSIGHUP reloads the files whenever the process receives SIGHUP
+func (r *Reloader) ReloadOnSIGHUP() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := r.Reload(); err != nil {
+				logging.L.Err(err).Msg("tls reload failed, keeping the previous certificate")
+				continue
+			}
+			logging.L.Info().Msg("reloaded tls certificate")
+		}
+	}()
+}
@@ -0,0 +1,217 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert is self-signed if parent is nil
+func newTestCert(t *testing.T, name string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// handshake returns the certificate the server presented
+func handshake(t *testing.T, serverCfg *tls.Config, clientCert *tls.Certificate) (*x509.Certificate, error) {
+	t.Helper()
+	// a real connection, net.Pipe is unbuffered and blocks on the alert of a rejected client
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- tls.Server(conn, serverCfg).Handshake()
+	}()
+
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+
+	clientCfg := &tls.Config{InsecureSkipVerify: true}
+	if clientCert != nil {
+		clientCfg.Certificates = []tls.Certificate{*clientCert}
+	}
+	client := tls.Client(clientConn, clientCfg)
+	clientErr := client.Handshake()
+	if err := <-serverErr; err != nil {
+		return nil, err
+	}
+	if clientErr != nil {
+		return nil, clientErr
+	}
+	return client.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestCert(t, "first", nil, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := first.write(t, dir, "server")
+
+	r, err := New(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := r.Config()
+
+	got, err := handshake(t, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject.CommonName != "first" {
+		t.Fatalf("served %s", got.Subject.CommonName)
+	}
+
+	second := newTestCert(t, "second", nil, x509.ExtKeyUsageServerAuth)
+	second.write(t, dir, "server")
+	if err = r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	got, err = handshake(t, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject.CommonName != "second" {
+		t.Fatalf("served %s after reload", got.Subject.CommonName)
+	}
+
+	// a broken file keeps the last good certificate
+	if err = os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Reload(); err == nil {
+		t.Fatal("expected reload to fail")
+	}
+	got, err = handshake(t, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject.CommonName != "second" {
+		t.Fatalf("served %s after failed reload", got.Subject.CommonName)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	server := newTestCert(t, "server", nil, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := server.write(t, dir, "server")
+
+	ca := newTestCert(t, "ca", nil, x509.ExtKeyUsageClientAuth)
+	caFile, _ := ca.write(t, dir, "ca")
+	client := newTestCert(t, "client", ca, x509.ExtKeyUsageClientAuth).tlsCert()
+
+	otherCA := newTestCert(t, "other-ca", nil, x509.ExtKeyUsageClientAuth)
+	stranger := newTestCert(t, "stranger", otherCA, x509.ExtKeyUsageClientAuth).tlsCert()
+
+	r, err := New(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := r.Config()
+
+	if _, err = handshake(t, cfg, &client); err != nil {
+		t.Errorf("client signed by the ca rejected: %v", err)
+	}
+	if _, err = handshake(t, cfg, nil); err == nil {
+		t.Error("client without certificate accepted")
+	}
+	if _, err = handshake(t, cfg, &stranger); err == nil {
+		t.Error("client signed by another ca accepted")
+	}
+
+	// rotating the ca takes effect for new connections
+	otherCA.write(t, dir, "ca")
+	if err = r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = handshake(t, cfg, &stranger); err != nil {
+		t.Errorf("client signed by the new ca rejected: %v", err)
+	}
+	if _, err = handshake(t, cfg, &client); err == nil {
+		t.Error("client signed by the old ca accepted")
+	}
+}
@@ -0,0 +1,1954 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: oracle.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type InfoResponse struct {
+	state                          protoimpl.MessageState `protogen:"open.v1"`
+	Network                        string                 `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	Height                         uint64                 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	TweaksOnly                     bool                   `protobuf:"varint,3,opt,name=tweaks_only,json=tweaksOnly,proto3" json:"tweaks_only,omitempty"`
+	TweaksFullBasic                bool                   `protobuf:"varint,4,opt,name=tweaks_full_basic,json=tweaksFullBasic,proto3" json:"tweaks_full_basic,omitempty"`
+	TweaksFullWithDustFilter       bool                   `protobuf:"varint,5,opt,name=tweaks_full_with_dust_filter,json=tweaksFullWithDustFilter,proto3" json:"tweaks_full_with_dust_filter,omitempty"`
+	TweaksCutThroughWithDustFilter bool                   `protobuf:"varint,6,opt,name=tweaks_cut_through_with_dust_filter,json=tweaksCutThroughWithDustFilter,proto3" json:"tweaks_cut_through_with_dust_filter,omitempty"`
+	unknownFields                  protoimpl.UnknownFields
+	sizeCache                      protoimpl.SizeCache
+}
+
+func (x *InfoResponse) Reset() {
+	*x = InfoResponse{}
+	mi := &file_oracle_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResponse) ProtoMessage() {}
+
+func (x *InfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
+func (*InfoResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *InfoResponse) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *InfoResponse) GetTweaksOnly() bool {
+	if x != nil {
+		return x.TweaksOnly
+	}
+	return false
+}
+
+func (x *InfoResponse) GetTweaksFullBasic() bool {
+	if x != nil {
+		return x.TweaksFullBasic
+	}
+	return false
+}
+
+func (x *InfoResponse) GetTweaksFullWithDustFilter() bool {
+	if x != nil {
+		return x.TweaksFullWithDustFilter
+	}
+	return false
+}
+
+func (x *InfoResponse) GetTweaksCutThroughWithDustFilter() bool {
+	if x != nil {
+		return x.TweaksCutThroughWithDustFilter
+	}
+	return false
+}
+
+type BlockIdentifier struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockHash     []byte                 `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockHeight   uint64                 `protobuf:"varint,2,opt,name=block_height,json=blockHeight,proto3" json:"block_height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockIdentifier) Reset() {
+	*x = BlockIdentifier{}
+	mi := &file_oracle_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockIdentifier) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockIdentifier) ProtoMessage() {}
+
+func (x *BlockIdentifier) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockIdentifier.ProtoReflect.Descriptor instead.
+func (*BlockIdentifier) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BlockIdentifier) GetBlockHash() []byte {
+	if x != nil {
+		return x.BlockHash
+	}
+	return nil
+}
+
+func (x *BlockIdentifier) GetBlockHeight() uint64 {
+	if x != nil {
+		return x.BlockHeight
+	}
+	return 0
+}
+
+type BlockHeightRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Block:
+	//
+	//	*BlockHeightRequest_BlockHeight
+	//	*BlockHeightRequest_BlockHash
+	Block         isBlockHeightRequest_Block `protobuf_oneof:"block"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockHeightRequest) Reset() {
+	*x = BlockHeightRequest{}
+	mi := &file_oracle_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockHeightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockHeightRequest) ProtoMessage() {}
+
+func (x *BlockHeightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockHeightRequest.ProtoReflect.Descriptor instead.
+func (*BlockHeightRequest) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BlockHeightRequest) GetBlock() isBlockHeightRequest_Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *BlockHeightRequest) GetBlockHeight() uint64 {
+	if x != nil {
+		if x, ok := x.Block.(*BlockHeightRequest_BlockHeight); ok {
+			return x.BlockHeight
+		}
+	}
+	return 0
+}
+
+func (x *BlockHeightRequest) GetBlockHash() []byte {
+	if x != nil {
+		if x, ok := x.Block.(*BlockHeightRequest_BlockHash); ok {
+			return x.BlockHash
+		}
+	}
+	return nil
+}
+
+type isBlockHeightRequest_Block interface {
+	isBlockHeightRequest_Block()
+}
+
+type BlockHeightRequest_BlockHeight struct {
+	BlockHeight uint64 `protobuf:"varint,1,opt,name=block_height,json=blockHeight,proto3,oneof"`
+}
+
+type BlockHeightRequest_BlockHash struct {
+	BlockHash []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3,oneof"`
+}
+
+func (*BlockHeightRequest_BlockHeight) isBlockHeightRequest_Block() {}
+
+func (*BlockHeightRequest_BlockHash) isBlockHeightRequest_Block() {}
+
+type BlockHeightResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockHeight   uint64                 `protobuf:"varint,1,opt,name=block_height,json=blockHeight,proto3" json:"block_height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockHeightResponse) Reset() {
+	*x = BlockHeightResponse{}
+	mi := &file_oracle_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockHeightResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockHeightResponse) ProtoMessage() {}
+
+func (x *BlockHeightResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockHeightResponse.ProtoReflect.Descriptor instead.
+func (*BlockHeightResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BlockHeightResponse) GetBlockHeight() uint64 {
+	if x != nil {
+		return x.BlockHeight
+	}
+	return 0
+}
+
+type BlockHashResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockHash     []byte                 `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockHashResponse) Reset() {
+	*x = BlockHashResponse{}
+	mi := &file_oracle_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockHashResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockHashResponse) ProtoMessage() {}
+
+func (x *BlockHashResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockHashResponse.ProtoReflect.Descriptor instead.
+func (*BlockHashResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BlockHashResponse) GetBlockHash() []byte {
+	if x != nil {
+		return x.BlockHash
+	}
+	return nil
+}
+
+type IndexResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BlockIdentifier *BlockIdentifier       `protobuf:"bytes,1,opt,name=block_identifier,json=blockIdentifier,proto3" json:"block_identifier,omitempty"`
+	Index           [][]byte               `protobuf:"bytes,2,rep,name=index,proto3" json:"index,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *IndexResponse) Reset() {
+	*x = IndexResponse{}
+	mi := &file_oracle_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexResponse) ProtoMessage() {}
+
+func (x *IndexResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexResponse.ProtoReflect.Descriptor instead.
+func (*IndexResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *IndexResponse) GetBlockIdentifier() *BlockIdentifier {
+	if x != nil {
+		return x.BlockIdentifier
+	}
+	return nil
+}
+
+func (x *IndexResponse) GetIndex() [][]byte {
+	if x != nil {
+		return x.Index
+	}
+	return nil
+}
+
+type UTXOItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          []byte                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Vout          uint32                 `protobuf:"varint,2,opt,name=vout,proto3" json:"vout,omitempty"`
+	Amount        uint64                 `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Pubkey        []byte                 `protobuf:"bytes,4,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UTXOItem) Reset() {
+	*x = UTXOItem{}
+	mi := &file_oracle_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UTXOItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UTXOItem) ProtoMessage() {}
+
+func (x *UTXOItem) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UTXOItem.ProtoReflect.Descriptor instead.
+func (*UTXOItem) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UTXOItem) GetTxid() []byte {
+	if x != nil {
+		return x.Txid
+	}
+	return nil
+}
+
+func (x *UTXOItem) GetVout() uint32 {
+	if x != nil {
+		return x.Vout
+	}
+	return 0
+}
+
+func (x *UTXOItem) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *UTXOItem) GetPubkey() []byte {
+	if x != nil {
+		return x.Pubkey
+	}
+	return nil
+}
+
+type UTXOResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BlockIdentifier *BlockIdentifier       `protobuf:"bytes,1,opt,name=block_identifier,json=blockIdentifier,proto3" json:"block_identifier,omitempty"`
+	Index           []*UTXOItem            `protobuf:"bytes,2,rep,name=index,proto3" json:"index,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UTXOResponse) Reset() {
+	*x = UTXOResponse{}
+	mi := &file_oracle_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UTXOResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UTXOResponse) ProtoMessage() {}
+
+func (x *UTXOResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UTXOResponse.ProtoReflect.Descriptor instead.
+func (*UTXOResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UTXOResponse) GetBlockIdentifier() *BlockIdentifier {
+	if x != nil {
+		return x.BlockIdentifier
+	}
+	return nil
+}
+
+func (x *UTXOResponse) GetIndex() []*UTXOItem {
+	if x != nil {
+		return x.Index
+	}
+	return nil
+}
+
+type UTXOItemLight struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vout          uint32                 `protobuf:"varint,1,opt,name=vout,proto3" json:"vout,omitempty"`
+	Amount        uint64                 `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Pubkey        []byte                 `protobuf:"bytes,3,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UTXOItemLight) Reset() {
+	*x = UTXOItemLight{}
+	mi := &file_oracle_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UTXOItemLight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UTXOItemLight) ProtoMessage() {}
+
+func (x *UTXOItemLight) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UTXOItemLight.ProtoReflect.Descriptor instead.
+func (*UTXOItemLight) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UTXOItemLight) GetVout() uint32 {
+	if x != nil {
+		return x.Vout
+	}
+	return 0
+}
+
+func (x *UTXOItemLight) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *UTXOItemLight) GetPubkey() []byte {
+	if x != nil {
+		return x.Pubkey
+	}
+	return nil
+}
+
+type ComputeIndexTxItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          []byte                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Tweak         []byte                 `protobuf:"bytes,2,opt,name=tweak,proto3" json:"tweak,omitempty"`
+	OutputsShort  []byte                 `protobuf:"bytes,3,opt,name=outputs_short,json=outputsShort,proto3" json:"outputs_short,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComputeIndexTxItem) Reset() {
+	*x = ComputeIndexTxItem{}
+	mi := &file_oracle_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComputeIndexTxItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComputeIndexTxItem) ProtoMessage() {}
+
+func (x *ComputeIndexTxItem) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComputeIndexTxItem.ProtoReflect.Descriptor instead.
+func (*ComputeIndexTxItem) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ComputeIndexTxItem) GetTxid() []byte {
+	if x != nil {
+		return x.Txid
+	}
+	return nil
+}
+
+func (x *ComputeIndexTxItem) GetTweak() []byte {
+	if x != nil {
+		return x.Tweak
+	}
+	return nil
+}
+
+func (x *ComputeIndexTxItem) GetOutputsShort() []byte {
+	if x != nil {
+		return x.OutputsShort
+	}
+	return nil
+}
+
+type ComputeIndexResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BlockIdentifier *BlockIdentifier       `protobuf:"bytes,1,opt,name=block_identifier,json=blockIdentifier,proto3" json:"block_identifier,omitempty"`
+	Index           []*ComputeIndexTxItem  `protobuf:"bytes,2,rep,name=index,proto3" json:"index,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ComputeIndexResponse) Reset() {
+	*x = ComputeIndexResponse{}
+	mi := &file_oracle_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComputeIndexResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComputeIndexResponse) ProtoMessage() {}
+
+func (x *ComputeIndexResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComputeIndexResponse.ProtoReflect.Descriptor instead.
+func (*ComputeIndexResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ComputeIndexResponse) GetBlockIdentifier() *BlockIdentifier {
+	if x != nil {
+		return x.BlockIdentifier
+	}
+	return nil
+}
+
+func (x *ComputeIndexResponse) GetIndex() []*ComputeIndexTxItem {
+	if x != nil {
+		return x.Index
+	}
+	return nil
+}
+
+type FullTxItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          []byte                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Tweak         []byte                 `protobuf:"bytes,2,opt,name=tweak,proto3" json:"tweak,omitempty"`
+	Inputs        []byte                 `protobuf:"bytes,3,opt,name=inputs,proto3" json:"inputs,omitempty"`
+	Utxos         []*UTXOItemLight       `protobuf:"bytes,4,rep,name=utxos,proto3" json:"utxos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FullTxItem) Reset() {
+	*x = FullTxItem{}
+	mi := &file_oracle_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FullTxItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FullTxItem) ProtoMessage() {}
+
+func (x *FullTxItem) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FullTxItem.ProtoReflect.Descriptor instead.
+func (*FullTxItem) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *FullTxItem) GetTxid() []byte {
+	if x != nil {
+		return x.Txid
+	}
+	return nil
+}
+
+func (x *FullTxItem) GetTweak() []byte {
+	if x != nil {
+		return x.Tweak
+	}
+	return nil
+}
+
+func (x *FullTxItem) GetInputs() []byte {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *FullTxItem) GetUtxos() []*UTXOItemLight {
+	if x != nil {
+		return x.Utxos
+	}
+	return nil
+}
+
+type FullBlockResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BlockIdentifier *BlockIdentifier       `protobuf:"bytes,1,opt,name=block_identifier,json=blockIdentifier,proto3" json:"block_identifier,omitempty"`
+	Index           []*FullTxItem          `protobuf:"bytes,2,rep,name=index,proto3" json:"index,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *FullBlockResponse) Reset() {
+	*x = FullBlockResponse{}
+	mi := &file_oracle_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FullBlockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FullBlockResponse) ProtoMessage() {}
+
+func (x *FullBlockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FullBlockResponse.ProtoReflect.Descriptor instead.
+func (*FullBlockResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FullBlockResponse) GetBlockIdentifier() *BlockIdentifier {
+	if x != nil {
+		return x.BlockIdentifier
+	}
+	return nil
+}
+
+func (x *FullBlockResponse) GetIndex() []*FullTxItem {
+	if x != nil {
+		return x.Index
+	}
+	return nil
+}
+
+type RangedBlockHeightRequestFiltered struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         uint64                 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           uint64                 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	Dustlimit     uint64                 `protobuf:"varint,3,opt,name=dustlimit,proto3" json:"dustlimit,omitempty"`
+	CutThrough    bool                   `protobuf:"varint,4,opt,name=cut_through,json=cutThrough,proto3" json:"cut_through,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangedBlockHeightRequestFiltered) Reset() {
+	*x = RangedBlockHeightRequestFiltered{}
+	mi := &file_oracle_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangedBlockHeightRequestFiltered) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangedBlockHeightRequestFiltered) ProtoMessage() {}
+
+func (x *RangedBlockHeightRequestFiltered) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangedBlockHeightRequestFiltered.ProtoReflect.Descriptor instead.
+func (*RangedBlockHeightRequestFiltered) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RangedBlockHeightRequestFiltered) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *RangedBlockHeightRequestFiltered) GetEnd() uint64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *RangedBlockHeightRequestFiltered) GetDustlimit() uint64 {
+	if x != nil {
+		return x.Dustlimit
+	}
+	return 0
+}
+
+func (x *RangedBlockHeightRequestFiltered) GetCutThrough() bool {
+	if x != nil {
+		return x.CutThrough
+	}
+	return false
+}
+
+type RangedBlockHeightRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         uint64                 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           uint64                 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangedBlockHeightRequest) Reset() {
+	*x = RangedBlockHeightRequest{}
+	mi := &file_oracle_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangedBlockHeightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangedBlockHeightRequest) ProtoMessage() {}
+
+func (x *RangedBlockHeightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangedBlockHeightRequest.ProtoReflect.Descriptor instead.
+func (*RangedBlockHeightRequest) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RangedBlockHeightRequest) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *RangedBlockHeightRequest) GetEnd() uint64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type BlockScanDataShortResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BlockIdentifier *BlockIdentifier       `protobuf:"bytes,1,opt,name=block_identifier,json=blockIdentifier,proto3" json:"block_identifier,omitempty"`
+	CompIndex       []*ComputeIndexTxItem  `protobuf:"bytes,2,rep,name=comp_index,json=compIndex,proto3" json:"comp_index,omitempty"`
+	SpentOutputs    []byte                 `protobuf:"bytes,3,opt,name=spent_outputs,json=spentOutputs,proto3" json:"spent_outputs,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BlockScanDataShortResponse) Reset() {
+	*x = BlockScanDataShortResponse{}
+	mi := &file_oracle_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockScanDataShortResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockScanDataShortResponse) ProtoMessage() {}
+
+func (x *BlockScanDataShortResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockScanDataShortResponse.ProtoReflect.Descriptor instead.
+func (*BlockScanDataShortResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BlockScanDataShortResponse) GetBlockIdentifier() *BlockIdentifier {
+	if x != nil {
+		return x.BlockIdentifier
+	}
+	return nil
+}
+
+func (x *BlockScanDataShortResponse) GetCompIndex() []*ComputeIndexTxItem {
+	if x != nil {
+		return x.CompIndex
+	}
+	return nil
+}
+
+func (x *BlockScanDataShortResponse) GetSpentOutputs() []byte {
+	if x != nil {
+		return x.SpentOutputs
+	}
+	return nil
+}
+
+type SubscribeBlocksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LastBlock     *BlockIdentifier       `protobuf:"bytes,1,opt,name=last_block,json=lastBlock,proto3" json:"last_block,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeBlocksRequest) Reset() {
+	*x = SubscribeBlocksRequest{}
+	mi := &file_oracle_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeBlocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeBlocksRequest) ProtoMessage() {}
+
+func (x *SubscribeBlocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeBlocksRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeBlocksRequest) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SubscribeBlocksRequest) GetLastBlock() *BlockIdentifier {
+	if x != nil {
+		return x.LastBlock
+	}
+	return nil
+}
+
+type BlockRewind struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Block         *BlockIdentifier       `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockRewind) Reset() {
+	*x = BlockRewind{}
+	mi := &file_oracle_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockRewind) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockRewind) ProtoMessage() {}
+
+func (x *BlockRewind) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockRewind.ProtoReflect.Descriptor instead.
+func (*BlockRewind) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BlockRewind) GetBlock() *BlockIdentifier {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+type SubscribeBlocksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*SubscribeBlocksResponse_Block
+	//	*SubscribeBlocksResponse_Rewind
+	Event         isSubscribeBlocksResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeBlocksResponse) Reset() {
+	*x = SubscribeBlocksResponse{}
+	mi := &file_oracle_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeBlocksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeBlocksResponse) ProtoMessage() {}
+
+func (x *SubscribeBlocksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeBlocksResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeBlocksResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SubscribeBlocksResponse) GetEvent() isSubscribeBlocksResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *SubscribeBlocksResponse) GetBlock() *BlockScanDataShortResponse {
+	if x != nil {
+		if x, ok := x.Event.(*SubscribeBlocksResponse_Block); ok {
+			return x.Block
+		}
+	}
+	return nil
+}
+
+func (x *SubscribeBlocksResponse) GetRewind() *BlockRewind {
+	if x != nil {
+		if x, ok := x.Event.(*SubscribeBlocksResponse_Rewind); ok {
+			return x.Rewind
+		}
+	}
+	return nil
+}
+
+type isSubscribeBlocksResponse_Event interface {
+	isSubscribeBlocksResponse_Event()
+}
+
+type SubscribeBlocksResponse_Block struct {
+	Block *BlockScanDataShortResponse `protobuf:"bytes,1,opt,name=block,proto3,oneof"`
+}
+
+type SubscribeBlocksResponse_Rewind struct {
+	Rewind *BlockRewind `protobuf:"bytes,2,opt,name=rewind,proto3,oneof"`
+}
+
+func (*SubscribeBlocksResponse_Block) isSubscribeBlocksResponse_Event() {}
+
+func (*SubscribeBlocksResponse_Rewind) isSubscribeBlocksResponse_Event() {}
+
+type ScanRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         uint64                 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           uint64                 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	ScanSecretKey []byte                 `protobuf:"bytes,3,opt,name=scan_secret_key,json=scanSecretKey,proto3" json:"scan_secret_key,omitempty"`
+	SpendPubkey   []byte                 `protobuf:"bytes,4,opt,name=spend_pubkey,json=spendPubkey,proto3" json:"spend_pubkey,omitempty"`
+	Labels        []uint32               `protobuf:"varint,5,rep,packed,name=labels,proto3" json:"labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRangeRequest) Reset() {
+	*x = ScanRangeRequest{}
+	mi := &file_oracle_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRangeRequest) ProtoMessage() {}
+
+func (x *ScanRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRangeRequest.ProtoReflect.Descriptor instead.
+func (*ScanRangeRequest) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ScanRangeRequest) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *ScanRangeRequest) GetEnd() uint64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *ScanRangeRequest) GetScanSecretKey() []byte {
+	if x != nil {
+		return x.ScanSecretKey
+	}
+	return nil
+}
+
+func (x *ScanRangeRequest) GetSpendPubkey() []byte {
+	if x != nil {
+		return x.SpendPubkey
+	}
+	return nil
+}
+
+func (x *ScanRangeRequest) GetLabels() []uint32 {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type ScanRangeMatch struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BlockIdentifier *BlockIdentifier       `protobuf:"bytes,1,opt,name=block_identifier,json=blockIdentifier,proto3" json:"block_identifier,omitempty"`
+	Utxo            *UTXOItem              `protobuf:"bytes,2,opt,name=utxo,proto3" json:"utxo,omitempty"`
+	Tweak           []byte                 `protobuf:"bytes,3,opt,name=tweak,proto3" json:"tweak,omitempty"`
+	PrivKeyTweak    []byte                 `protobuf:"bytes,4,opt,name=priv_key_tweak,json=privKeyTweak,proto3" json:"priv_key_tweak,omitempty"`
+	Label           *uint32                `protobuf:"varint,5,opt,name=label,proto3,oneof" json:"label,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ScanRangeMatch) Reset() {
+	*x = ScanRangeMatch{}
+	mi := &file_oracle_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRangeMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRangeMatch) ProtoMessage() {}
+
+func (x *ScanRangeMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRangeMatch.ProtoReflect.Descriptor instead.
+func (*ScanRangeMatch) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ScanRangeMatch) GetBlockIdentifier() *BlockIdentifier {
+	if x != nil {
+		return x.BlockIdentifier
+	}
+	return nil
+}
+
+func (x *ScanRangeMatch) GetUtxo() *UTXOItem {
+	if x != nil {
+		return x.Utxo
+	}
+	return nil
+}
+
+func (x *ScanRangeMatch) GetTweak() []byte {
+	if x != nil {
+		return x.Tweak
+	}
+	return nil
+}
+
+func (x *ScanRangeMatch) GetPrivKeyTweak() []byte {
+	if x != nil {
+		return x.PrivKeyTweak
+	}
+	return nil
+}
+
+func (x *ScanRangeMatch) GetLabel() uint32 {
+	if x != nil && x.Label != nil {
+		return *x.Label
+	}
+	return 0
+}
+
+type ScanRangeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matches       []*ScanRangeMatch      `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	ScannedHeight uint64                 `protobuf:"varint,2,opt,name=scanned_height,json=scannedHeight,proto3" json:"scanned_height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRangeResponse) Reset() {
+	*x = ScanRangeResponse{}
+	mi := &file_oracle_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRangeResponse) ProtoMessage() {}
+
+func (x *ScanRangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRangeResponse.ProtoReflect.Descriptor instead.
+func (*ScanRangeResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ScanRangeResponse) GetMatches() []*ScanRangeMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *ScanRangeResponse) GetScannedHeight() uint64 {
+	if x != nil {
+		return x.ScannedHeight
+	}
+	return 0
+}
+
+type RegisterWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScanSecretKey []byte                 `protobuf:"bytes,1,opt,name=scan_secret_key,json=scanSecretKey,proto3" json:"scan_secret_key,omitempty"`
+	SpendPubkey   []byte                 `protobuf:"bytes,2,opt,name=spend_pubkey,json=spendPubkey,proto3" json:"spend_pubkey,omitempty"`
+	Labels        []uint32               `protobuf:"varint,3,rep,packed,name=labels,proto3" json:"labels,omitempty"`
+	BirthHeight   uint64                 `protobuf:"varint,4,opt,name=birth_height,json=birthHeight,proto3" json:"birth_height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterWalletRequest) Reset() {
+	*x = RegisterWalletRequest{}
+	mi := &file_oracle_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterWalletRequest) ProtoMessage() {}
+
+func (x *RegisterWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterWalletRequest.ProtoReflect.Descriptor instead.
+func (*RegisterWalletRequest) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RegisterWalletRequest) GetScanSecretKey() []byte {
+	if x != nil {
+		return x.ScanSecretKey
+	}
+	return nil
+}
+
+func (x *RegisterWalletRequest) GetSpendPubkey() []byte {
+	if x != nil {
+		return x.SpendPubkey
+	}
+	return nil
+}
+
+func (x *RegisterWalletRequest) GetLabels() []uint32 {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *RegisterWalletRequest) GetBirthHeight() uint64 {
+	if x != nil {
+		return x.BirthHeight
+	}
+	return 0
+}
+
+type WalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WalletId      []byte                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletRequest) Reset() {
+	*x = WalletRequest{}
+	mi := &file_oracle_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletRequest) ProtoMessage() {}
+
+func (x *WalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletRequest.ProtoReflect.Descriptor instead.
+func (*WalletRequest) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *WalletRequest) GetWalletId() []byte {
+	if x != nil {
+		return x.WalletId
+	}
+	return nil
+}
+
+type WalletStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WalletId      []byte                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	BirthHeight   uint64                 `protobuf:"varint,2,opt,name=birth_height,json=birthHeight,proto3" json:"birth_height,omitempty"`
+	ScannedHeight uint64                 `protobuf:"varint,3,opt,name=scanned_height,json=scannedHeight,proto3" json:"scanned_height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletStatus) Reset() {
+	*x = WalletStatus{}
+	mi := &file_oracle_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletStatus) ProtoMessage() {}
+
+func (x *WalletStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletStatus.ProtoReflect.Descriptor instead.
+func (*WalletStatus) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *WalletStatus) GetWalletId() []byte {
+	if x != nil {
+		return x.WalletId
+	}
+	return nil
+}
+
+func (x *WalletStatus) GetBirthHeight() uint64 {
+	if x != nil {
+		return x.BirthHeight
+	}
+	return 0
+}
+
+func (x *WalletStatus) GetScannedHeight() uint64 {
+	if x != nil {
+		return x.ScannedHeight
+	}
+	return 0
+}
+
+type WalletOutputsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WalletId      []byte                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	IncludeSpent  bool                   `protobuf:"varint,2,opt,name=include_spent,json=includeSpent,proto3" json:"include_spent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletOutputsRequest) Reset() {
+	*x = WalletOutputsRequest{}
+	mi := &file_oracle_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletOutputsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletOutputsRequest) ProtoMessage() {}
+
+func (x *WalletOutputsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletOutputsRequest.ProtoReflect.Descriptor instead.
+func (*WalletOutputsRequest) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WalletOutputsRequest) GetWalletId() []byte {
+	if x != nil {
+		return x.WalletId
+	}
+	return nil
+}
+
+func (x *WalletOutputsRequest) GetIncludeSpent() bool {
+	if x != nil {
+		return x.IncludeSpent
+	}
+	return false
+}
+
+type WalletOutput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Match         *ScanRangeMatch        `protobuf:"bytes,1,opt,name=match,proto3" json:"match,omitempty"`
+	SpentIn       *BlockIdentifier       `protobuf:"bytes,2,opt,name=spent_in,json=spentIn,proto3" json:"spent_in,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletOutput) Reset() {
+	*x = WalletOutput{}
+	mi := &file_oracle_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletOutput) ProtoMessage() {}
+
+func (x *WalletOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletOutput.ProtoReflect.Descriptor instead.
+func (*WalletOutput) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *WalletOutput) GetMatch() *ScanRangeMatch {
+	if x != nil {
+		return x.Match
+	}
+	return nil
+}
+
+func (x *WalletOutput) GetSpentIn() *BlockIdentifier {
+	if x != nil {
+		return x.SpentIn
+	}
+	return nil
+}
+
+type WalletOutputsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        *WalletStatus          `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	Outputs       []*WalletOutput        `protobuf:"bytes,2,rep,name=outputs,proto3" json:"outputs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletOutputsResponse) Reset() {
+	*x = WalletOutputsResponse{}
+	mi := &file_oracle_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletOutputsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletOutputsResponse) ProtoMessage() {}
+
+func (x *WalletOutputsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oracle_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletOutputsResponse.ProtoReflect.Descriptor instead.
+func (*WalletOutputsResponse) Descriptor() ([]byte, []int) {
+	return file_oracle_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *WalletOutputsResponse) GetWallet() *WalletStatus {
+	if x != nil {
+		return x.Wallet
+	}
+	return nil
+}
+
+func (x *WalletOutputsResponse) GetOutputs() []*WalletOutput {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+var File_oracle_proto protoreflect.FileDescriptor
+
+const file_oracle_proto_rawDesc = "" +
+	"\n" +
+	"\foracle.proto\x12\x12blindbit.oracle.v1\x1a\x1bgoogle/protobuf/empty.proto\"\x9a\x02\n" +
+	"\fInfoResponse\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\x12\x1f\n" +
+	"\vtweaks_only\x18\x03 \x01(\bR\n" +
+	"tweaksOnly\x12*\n" +
+	"\x11tweaks_full_basic\x18\x04 \x01(\bR\x0ftweaksFullBasic\x12>\n" +
+	"\x1ctweaks_full_with_dust_filter\x18\x05 \x01(\bR\x18tweaksFullWithDustFilter\x12K\n" +
+	"#tweaks_cut_through_with_dust_filter\x18\x06 \x01(\bR\x1etweaksCutThroughWithDustFilter\"S\n" +
+	"\x0fBlockIdentifier\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x01 \x01(\fR\tblockHash\x12!\n" +
+	"\fblock_height\x18\x02 \x01(\x04R\vblockHeight\"c\n" +
+	"\x12BlockHeightRequest\x12#\n" +
+	"\fblock_height\x18\x01 \x01(\x04H\x00R\vblockHeight\x12\x1f\n" +
+	"\n" +
+	"block_hash\x18\x02 \x01(\fH\x00R\tblockHashB\a\n" +
+	"\x05block\"8\n" +
+	"\x13BlockHeightResponse\x12!\n" +
+	"\fblock_height\x18\x01 \x01(\x04R\vblockHeight\"2\n" +
+	"\x11BlockHashResponse\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x01 \x01(\fR\tblockHash\"u\n" +
+	"\rIndexResponse\x12N\n" +
+	"\x10block_identifier\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\x0fblockIdentifier\x12\x14\n" +
+	"\x05index\x18\x02 \x03(\fR\x05index\"b\n" +
+	"\bUTXOItem\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\fR\x04txid\x12\x12\n" +
+	"\x04vout\x18\x02 \x01(\rR\x04vout\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x04R\x06amount\x12\x16\n" +
+	"\x06pubkey\x18\x04 \x01(\fR\x06pubkey\"\x92\x01\n" +
+	"\fUTXOResponse\x12N\n" +
+	"\x10block_identifier\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\x0fblockIdentifier\x122\n" +
+	"\x05index\x18\x02 \x03(\v2\x1c.blindbit.oracle.v1.UTXOItemR\x05index\"S\n" +
+	"\rUTXOItemLight\x12\x12\n" +
+	"\x04vout\x18\x01 \x01(\rR\x04vout\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x04R\x06amount\x12\x16\n" +
+	"\x06pubkey\x18\x03 \x01(\fR\x06pubkey\"c\n" +
+	"\x12ComputeIndexTxItem\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\fR\x04txid\x12\x14\n" +
+	"\x05tweak\x18\x02 \x01(\fR\x05tweak\x12#\n" +
+	"\routputs_short\x18\x03 \x01(\fR\foutputsShort\"\xa4\x01\n" +
+	"\x14ComputeIndexResponse\x12N\n" +
+	"\x10block_identifier\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\x0fblockIdentifier\x12<\n" +
+	"\x05index\x18\x02 \x03(\v2&.blindbit.oracle.v1.ComputeIndexTxItemR\x05index\"\x87\x01\n" +
+	"\n" +
+	"FullTxItem\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\fR\x04txid\x12\x14\n" +
+	"\x05tweak\x18\x02 \x01(\fR\x05tweak\x12\x16\n" +
+	"\x06inputs\x18\x03 \x01(\fR\x06inputs\x127\n" +
+	"\x05utxos\x18\x04 \x03(\v2!.blindbit.oracle.v1.UTXOItemLightR\x05utxos\"\x99\x01\n" +
+	"\x11FullBlockResponse\x12N\n" +
+	"\x10block_identifier\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\x0fblockIdentifier\x124\n" +
+	"\x05index\x18\x02 \x03(\v2\x1e.blindbit.oracle.v1.FullTxItemR\x05index\"\x89\x01\n" +
+	" RangedBlockHeightRequestFiltered\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x04R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x04R\x03end\x12\x1c\n" +
+	"\tdustlimit\x18\x03 \x01(\x04R\tdustlimit\x12\x1f\n" +
+	"\vcut_through\x18\x04 \x01(\bR\n" +
+	"cutThrough\"B\n" +
+	"\x18RangedBlockHeightRequest\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x04R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x04R\x03end\"\xd8\x01\n" +
+	"\x1aBlockScanDataShortResponse\x12N\n" +
+	"\x10block_identifier\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\x0fblockIdentifier\x12E\n" +
+	"\n" +
+	"comp_index\x18\x02 \x03(\v2&.blindbit.oracle.v1.ComputeIndexTxItemR\tcompIndex\x12#\n" +
+	"\rspent_outputs\x18\x03 \x01(\fR\fspentOutputs\"\\\n" +
+	"\x16SubscribeBlocksRequest\x12B\n" +
+	"\n" +
+	"last_block\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\tlastBlock\"H\n" +
+	"\vBlockRewind\x129\n" +
+	"\x05block\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\x05block\"\xa5\x01\n" +
+	"\x17SubscribeBlocksResponse\x12F\n" +
+	"\x05block\x18\x01 \x01(\v2..blindbit.oracle.v1.BlockScanDataShortResponseH\x00R\x05block\x129\n" +
+	"\x06rewind\x18\x02 \x01(\v2\x1f.blindbit.oracle.v1.BlockRewindH\x00R\x06rewindB\a\n" +
+	"\x05event\"\x9d\x01\n" +
+	"\x10ScanRangeRequest\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x04R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x04R\x03end\x12&\n" +
+	"\x0fscan_secret_key\x18\x03 \x01(\fR\rscanSecretKey\x12!\n" +
+	"\fspend_pubkey\x18\x04 \x01(\fR\vspendPubkey\x12\x16\n" +
+	"\x06labels\x18\x05 \x03(\rR\x06labels\"\xf3\x01\n" +
+	"\x0eScanRangeMatch\x12N\n" +
+	"\x10block_identifier\x18\x01 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\x0fblockIdentifier\x120\n" +
+	"\x04utxo\x18\x02 \x01(\v2\x1c.blindbit.oracle.v1.UTXOItemR\x04utxo\x12\x14\n" +
+	"\x05tweak\x18\x03 \x01(\fR\x05tweak\x12$\n" +
+	"\x0epriv_key_tweak\x18\x04 \x01(\fR\fprivKeyTweak\x12\x19\n" +
+	"\x05label\x18\x05 \x01(\rH\x00R\x05label\x88\x01\x01B\b\n" +
+	"\x06_label\"x\n" +
+	"\x11ScanRangeResponse\x12<\n" +
+	"\amatches\x18\x01 \x03(\v2\".blindbit.oracle.v1.ScanRangeMatchR\amatches\x12%\n" +
+	"\x0escanned_height\x18\x02 \x01(\x04R\rscannedHeight\"\x9d\x01\n" +
+	"\x15RegisterWalletRequest\x12&\n" +
+	"\x0fscan_secret_key\x18\x01 \x01(\fR\rscanSecretKey\x12!\n" +
+	"\fspend_pubkey\x18\x02 \x01(\fR\vspendPubkey\x12\x16\n" +
+	"\x06labels\x18\x03 \x03(\rR\x06labels\x12!\n" +
+	"\fbirth_height\x18\x04 \x01(\x04R\vbirthHeight\",\n" +
+	"\rWalletRequest\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\fR\bwalletId\"u\n" +
+	"\fWalletStatus\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\fR\bwalletId\x12!\n" +
+	"\fbirth_height\x18\x02 \x01(\x04R\vbirthHeight\x12%\n" +
+	"\x0escanned_height\x18\x03 \x01(\x04R\rscannedHeight\"X\n" +
+	"\x14WalletOutputsRequest\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\fR\bwalletId\x12#\n" +
+	"\rinclude_spent\x18\x02 \x01(\bR\fincludeSpent\"\x88\x01\n" +
+	"\fWalletOutput\x128\n" +
+	"\x05match\x18\x01 \x01(\v2\".blindbit.oracle.v1.ScanRangeMatchR\x05match\x12>\n" +
+	"\bspent_in\x18\x02 \x01(\v2#.blindbit.oracle.v1.BlockIdentifierR\aspentIn\"\x8d\x01\n" +
+	"\x15WalletOutputsResponse\x128\n" +
+	"\x06wallet\x18\x01 \x01(\v2 .blindbit.oracle.v1.WalletStatusR\x06wallet\x12:\n" +
+	"\aoutputs\x18\x02 \x03(\v2 .blindbit.oracle.v1.WalletOutputR\aoutputs2\xe0\r\n" +
+	"\rOracleService\x12C\n" +
+	"\aGetInfo\x12\x16.google.protobuf.Empty\x1a .blindbit.oracle.v1.InfoResponse\x12U\n" +
+	"\x12GetBestBlockHeight\x12\x16.google.protobuf.Empty\x1a'.blindbit.oracle.v1.BlockHeightResponse\x12e\n" +
+	"\x14GetBlockHashByHeight\x12&.blindbit.oracle.v1.BlockHeightRequest\x1a%.blindbit.oracle.v1.BlockHashResponse\x12v\n" +
+	"\x12StreamComputeIndex\x124.blindbit.oracle.v1.RangedBlockHeightRequestFiltered\x1a(.blindbit.oracle.v1.ComputeIndexResponse0\x01\x12\x82\x01\n" +
+	"\x18StreamBlockScanDataShort\x124.blindbit.oracle.v1.RangedBlockHeightRequestFiltered\x1a..blindbit.oracle.v1.BlockScanDataShortResponse0\x01\x12]\n" +
+	"\fGetFullBlock\x12&.blindbit.oracle.v1.BlockHeightRequest\x1a%.blindbit.oracle.v1.FullBlockResponse\x12V\n" +
+	"\tGetTweaks\x12&.blindbit.oracle.v1.BlockHeightRequest\x1a!.blindbit.oracle.v1.IndexResponse\x12T\n" +
+	"\bGetUtxos\x12&.blindbit.oracle.v1.BlockHeightRequest\x1a .blindbit.oracle.v1.UTXOResponse\x12\\\n" +
+	"\x0fGetSpentOutputs\x12&.blindbit.oracle.v1.BlockHeightRequest\x1a!.blindbit.oracle.v1.IndexResponse\x12a\n" +
+	"\fStreamTweaks\x12,.blindbit.oracle.v1.RangedBlockHeightRequest\x1a!.blindbit.oracle.v1.IndexResponse0\x01\x12_\n" +
+	"\vStreamUtxos\x12,.blindbit.oracle.v1.RangedBlockHeightRequest\x1a .blindbit.oracle.v1.UTXOResponse0\x01\x12g\n" +
+	"\x12StreamSpentOutputs\x12,.blindbit.oracle.v1.RangedBlockHeightRequest\x1a!.blindbit.oracle.v1.IndexResponse0\x01\x12l\n" +
+	"\x0fSubscribeBlocks\x12*.blindbit.oracle.v1.SubscribeBlocksRequest\x1a+.blindbit.oracle.v1.SubscribeBlocksResponse0\x01\x12Z\n" +
+	"\tScanRange\x12$.blindbit.oracle.v1.ScanRangeRequest\x1a%.blindbit.oracle.v1.ScanRangeResponse0\x01\x12]\n" +
+	"\x0eRegisterWallet\x12).blindbit.oracle.v1.RegisterWalletRequest\x1a .blindbit.oracle.v1.WalletStatus\x12M\n" +
+	"\x10UnregisterWallet\x12!.blindbit.oracle.v1.WalletRequest\x1a\x16.google.protobuf.Empty\x12V\n" +
+	"\x0fGetWalletStatus\x12!.blindbit.oracle.v1.WalletRequest\x1a .blindbit.oracle.v1.WalletStatus\x12g\n" +
+	"\x10GetWalletOutputs\x12(.blindbit.oracle.v1.WalletOutputsRequest\x1a).blindbit.oracle.v1.WalletOutputsResponseB0Z.github.com/setavenger/blindbit-oracle/proto/pbb\x06proto3"
+
+var (
+	file_oracle_proto_rawDescOnce sync.Once
+	file_oracle_proto_rawDescData []byte
+)
+
+func file_oracle_proto_rawDescGZIP() []byte {
+	file_oracle_proto_rawDescOnce.Do(func() {
+		file_oracle_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_oracle_proto_rawDesc), len(file_oracle_proto_rawDesc)))
+	})
+	return file_oracle_proto_rawDescData
+}
+
+var file_oracle_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
+var file_oracle_proto_goTypes = []any{
+	(*InfoResponse)(nil),                     // 0: blindbit.oracle.v1.InfoResponse
+	(*BlockIdentifier)(nil),                  // 1: blindbit.oracle.v1.BlockIdentifier
+	(*BlockHeightRequest)(nil),               // 2: blindbit.oracle.v1.BlockHeightRequest
+	(*BlockHeightResponse)(nil),              // 3: blindbit.oracle.v1.BlockHeightResponse
+	(*BlockHashResponse)(nil),                // 4: blindbit.oracle.v1.BlockHashResponse
+	(*IndexResponse)(nil),                    // 5: blindbit.oracle.v1.IndexResponse
+	(*UTXOItem)(nil),                         // 6: blindbit.oracle.v1.UTXOItem
+	(*UTXOResponse)(nil),                     // 7: blindbit.oracle.v1.UTXOResponse
+	(*UTXOItemLight)(nil),                    // 8: blindbit.oracle.v1.UTXOItemLight
+	(*ComputeIndexTxItem)(nil),               // 9: blindbit.oracle.v1.ComputeIndexTxItem
+	(*ComputeIndexResponse)(nil),             // 10: blindbit.oracle.v1.ComputeIndexResponse
+	(*FullTxItem)(nil),                       // 11: blindbit.oracle.v1.FullTxItem
+	(*FullBlockResponse)(nil),                // 12: blindbit.oracle.v1.FullBlockResponse
+	(*RangedBlockHeightRequestFiltered)(nil), // 13: blindbit.oracle.v1.RangedBlockHeightRequestFiltered
+	(*RangedBlockHeightRequest)(nil),         // 14: blindbit.oracle.v1.RangedBlockHeightRequest
+	(*BlockScanDataShortResponse)(nil),       // 15: blindbit.oracle.v1.BlockScanDataShortResponse
+	(*SubscribeBlocksRequest)(nil),           // 16: blindbit.oracle.v1.SubscribeBlocksRequest
+	(*BlockRewind)(nil),                      // 17: blindbit.oracle.v1.BlockRewind
+	(*SubscribeBlocksResponse)(nil),          // 18: blindbit.oracle.v1.SubscribeBlocksResponse
+	(*ScanRangeRequest)(nil),                 // 19: blindbit.oracle.v1.ScanRangeRequest
+	(*ScanRangeMatch)(nil),                   // 20: blindbit.oracle.v1.ScanRangeMatch
+	(*ScanRangeResponse)(nil),                // 21: blindbit.oracle.v1.ScanRangeResponse
+	(*RegisterWalletRequest)(nil),            // 22: blindbit.oracle.v1.RegisterWalletRequest
+	(*WalletRequest)(nil),                    // 23: blindbit.oracle.v1.WalletRequest
+	(*WalletStatus)(nil),                     // 24: blindbit.oracle.v1.WalletStatus
+	(*WalletOutputsRequest)(nil),             // 25: blindbit.oracle.v1.WalletOutputsRequest
+	(*WalletOutput)(nil),                     // 26: blindbit.oracle.v1.WalletOutput
+	(*WalletOutputsResponse)(nil),            // 27: blindbit.oracle.v1.WalletOutputsResponse
+	(*emptypb.Empty)(nil),                    // 28: google.protobuf.Empty
+}
+var file_oracle_proto_depIdxs = []int32{
+	1,  // 0: blindbit.oracle.v1.IndexResponse.block_identifier:type_name -> blindbit.oracle.v1.BlockIdentifier
+	1,  // 1: blindbit.oracle.v1.UTXOResponse.block_identifier:type_name -> blindbit.oracle.v1.BlockIdentifier
+	6,  // 2: blindbit.oracle.v1.UTXOResponse.index:type_name -> blindbit.oracle.v1.UTXOItem
+	1,  // 3: blindbit.oracle.v1.ComputeIndexResponse.block_identifier:type_name -> blindbit.oracle.v1.BlockIdentifier
+	9,  // 4: blindbit.oracle.v1.ComputeIndexResponse.index:type_name -> blindbit.oracle.v1.ComputeIndexTxItem
+	8,  // 5: blindbit.oracle.v1.FullTxItem.utxos:type_name -> blindbit.oracle.v1.UTXOItemLight
+	1,  // 6: blindbit.oracle.v1.FullBlockResponse.block_identifier:type_name -> blindbit.oracle.v1.BlockIdentifier
+	11, // 7: blindbit.oracle.v1.FullBlockResponse.index:type_name -> blindbit.oracle.v1.FullTxItem
+	1,  // 8: blindbit.oracle.v1.BlockScanDataShortResponse.block_identifier:type_name -> blindbit.oracle.v1.BlockIdentifier
+	9,  // 9: blindbit.oracle.v1.BlockScanDataShortResponse.comp_index:type_name -> blindbit.oracle.v1.ComputeIndexTxItem
+	1,  // 10: blindbit.oracle.v1.SubscribeBlocksRequest.last_block:type_name -> blindbit.oracle.v1.BlockIdentifier
+	1,  // 11: blindbit.oracle.v1.BlockRewind.block:type_name -> blindbit.oracle.v1.BlockIdentifier
+	15, // 12: blindbit.oracle.v1.SubscribeBlocksResponse.block:type_name -> blindbit.oracle.v1.BlockScanDataShortResponse
+	17, // 13: blindbit.oracle.v1.SubscribeBlocksResponse.rewind:type_name -> blindbit.oracle.v1.BlockRewind
+	1,  // 14: blindbit.oracle.v1.ScanRangeMatch.block_identifier:type_name -> blindbit.oracle.v1.BlockIdentifier
+	6,  // 15: blindbit.oracle.v1.ScanRangeMatch.utxo:type_name -> blindbit.oracle.v1.UTXOItem
+	20, // 16: blindbit.oracle.v1.ScanRangeResponse.matches:type_name -> blindbit.oracle.v1.ScanRangeMatch
+	20, // 17: blindbit.oracle.v1.WalletOutput.match:type_name -> blindbit.oracle.v1.ScanRangeMatch
+	1,  // 18: blindbit.oracle.v1.WalletOutput.spent_in:type_name -> blindbit.oracle.v1.BlockIdentifier
+	24, // 19: blindbit.oracle.v1.WalletOutputsResponse.wallet:type_name -> blindbit.oracle.v1.WalletStatus
+	26, // 20: blindbit.oracle.v1.WalletOutputsResponse.outputs:type_name -> blindbit.oracle.v1.WalletOutput
+	28, // 21: blindbit.oracle.v1.OracleService.GetInfo:input_type -> google.protobuf.Empty
+	28, // 22: blindbit.oracle.v1.OracleService.GetBestBlockHeight:input_type -> google.protobuf.Empty
+	2,  // 23: blindbit.oracle.v1.OracleService.GetBlockHashByHeight:input_type -> blindbit.oracle.v1.BlockHeightRequest
+	13, // 24: blindbit.oracle.v1.OracleService.StreamComputeIndex:input_type -> blindbit.oracle.v1.RangedBlockHeightRequestFiltered
+	13, // 25: blindbit.oracle.v1.OracleService.StreamBlockScanDataShort:input_type -> blindbit.oracle.v1.RangedBlockHeightRequestFiltered
+	2,  // 26: blindbit.oracle.v1.OracleService.GetFullBlock:input_type -> blindbit.oracle.v1.BlockHeightRequest
+	2,  // 27: blindbit.oracle.v1.OracleService.GetTweaks:input_type -> blindbit.oracle.v1.BlockHeightRequest
+	2,  // 28: blindbit.oracle.v1.OracleService.GetUtxos:input_type -> blindbit.oracle.v1.BlockHeightRequest
+	2,  // 29: blindbit.oracle.v1.OracleService.GetSpentOutputs:input_type -> blindbit.oracle.v1.BlockHeightRequest
+	14, // 30: blindbit.oracle.v1.OracleService.StreamTweaks:input_type -> blindbit.oracle.v1.RangedBlockHeightRequest
+	14, // 31: blindbit.oracle.v1.OracleService.StreamUtxos:input_type -> blindbit.oracle.v1.RangedBlockHeightRequest
+	14, // 32: blindbit.oracle.v1.OracleService.StreamSpentOutputs:input_type -> blindbit.oracle.v1.RangedBlockHeightRequest
+	16, // 33: blindbit.oracle.v1.OracleService.SubscribeBlocks:input_type -> blindbit.oracle.v1.SubscribeBlocksRequest
+	19, // 34: blindbit.oracle.v1.OracleService.ScanRange:input_type -> blindbit.oracle.v1.ScanRangeRequest
+	22, // 35: blindbit.oracle.v1.OracleService.RegisterWallet:input_type -> blindbit.oracle.v1.RegisterWalletRequest
+	23, // 36: blindbit.oracle.v1.OracleService.UnregisterWallet:input_type -> blindbit.oracle.v1.WalletRequest
+	23, // 37: blindbit.oracle.v1.OracleService.GetWalletStatus:input_type -> blindbit.oracle.v1.WalletRequest
+	25, // 38: blindbit.oracle.v1.OracleService.GetWalletOutputs:input_type -> blindbit.oracle.v1.WalletOutputsRequest
+	0,  // 39: blindbit.oracle.v1.OracleService.GetInfo:output_type -> blindbit.oracle.v1.InfoResponse
+	3,  // 40: blindbit.oracle.v1.OracleService.GetBestBlockHeight:output_type -> blindbit.oracle.v1.BlockHeightResponse
+	4,  // 41: blindbit.oracle.v1.OracleService.GetBlockHashByHeight:output_type -> blindbit.oracle.v1.BlockHashResponse
+	10, // 42: blindbit.oracle.v1.OracleService.StreamComputeIndex:output_type -> blindbit.oracle.v1.ComputeIndexResponse
+	15, // 43: blindbit.oracle.v1.OracleService.StreamBlockScanDataShort:output_type -> blindbit.oracle.v1.BlockScanDataShortResponse
+	12, // 44: blindbit.oracle.v1.OracleService.GetFullBlock:output_type -> blindbit.oracle.v1.FullBlockResponse
+	5,  // 45: blindbit.oracle.v1.OracleService.GetTweaks:output_type -> blindbit.oracle.v1.IndexResponse
+	7,  // 46: blindbit.oracle.v1.OracleService.GetUtxos:output_type -> blindbit.oracle.v1.UTXOResponse
+	5,  // 47: blindbit.oracle.v1.OracleService.GetSpentOutputs:output_type -> blindbit.oracle.v1.IndexResponse
+	5,  // 48: blindbit.oracle.v1.OracleService.StreamTweaks:output_type -> blindbit.oracle.v1.IndexResponse
+	7,  // 49: blindbit.oracle.v1.OracleService.StreamUtxos:output_type -> blindbit.oracle.v1.UTXOResponse
+	5,  // 50: blindbit.oracle.v1.OracleService.StreamSpentOutputs:output_type -> blindbit.oracle.v1.IndexResponse
+	18, // 51: blindbit.oracle.v1.OracleService.SubscribeBlocks:output_type -> blindbit.oracle.v1.SubscribeBlocksResponse
+	21, // 52: blindbit.oracle.v1.OracleService.ScanRange:output_type -> blindbit.oracle.v1.ScanRangeResponse
+	24, // 53: blindbit.oracle.v1.OracleService.RegisterWallet:output_type -> blindbit.oracle.v1.WalletStatus
+	28, // 54: blindbit.oracle.v1.OracleService.UnregisterWallet:output_type -> google.protobuf.Empty
+	24, // 55: blindbit.oracle.v1.OracleService.GetWalletStatus:output_type -> blindbit.oracle.v1.WalletStatus
+	27, // 56: blindbit.oracle.v1.OracleService.GetWalletOutputs:output_type -> blindbit.oracle.v1.WalletOutputsResponse
+	39, // [39:57] is the sub-list for method output_type
+	21, // [21:39] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_oracle_proto_init() }
+func file_oracle_proto_init() {
+	if File_oracle_proto != nil {
+		return
+	}
+	file_oracle_proto_msgTypes[2].OneofWrappers = []any{
+		(*BlockHeightRequest_BlockHeight)(nil),
+		(*BlockHeightRequest_BlockHash)(nil),
+	}
+	file_oracle_proto_msgTypes[18].OneofWrappers = []any{
+		(*SubscribeBlocksResponse_Block)(nil),
+		(*SubscribeBlocksResponse_Rewind)(nil),
+	}
+	file_oracle_proto_msgTypes[20].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_oracle_proto_rawDesc), len(file_oracle_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   28,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_oracle_proto_goTypes,
+		DependencyIndexes: file_oracle_proto_depIdxs,
+		MessageInfos:      file_oracle_proto_msgTypes,
+	}.Build()
+	File_oracle_proto = out.File
+	file_oracle_proto_goTypes = nil
+	file_oracle_proto_depIdxs = nil
+}
@@ -0,0 +1,790 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: oracle.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OracleService_GetInfo_FullMethodName                  = "/blindbit.oracle.v1.OracleService/GetInfo"
+	OracleService_GetBestBlockHeight_FullMethodName       = "/blindbit.oracle.v1.OracleService/GetBestBlockHeight"
+	OracleService_GetBlockHashByHeight_FullMethodName     = "/blindbit.oracle.v1.OracleService/GetBlockHashByHeight"
+	OracleService_StreamComputeIndex_FullMethodName       = "/blindbit.oracle.v1.OracleService/StreamComputeIndex"
+	OracleService_StreamBlockScanDataShort_FullMethodName = "/blindbit.oracle.v1.OracleService/StreamBlockScanDataShort"
+	OracleService_GetFullBlock_FullMethodName             = "/blindbit.oracle.v1.OracleService/GetFullBlock"
+	OracleService_GetTweaks_FullMethodName                = "/blindbit.oracle.v1.OracleService/GetTweaks"
+	OracleService_GetUtxos_FullMethodName                 = "/blindbit.oracle.v1.OracleService/GetUtxos"
+	OracleService_GetSpentOutputs_FullMethodName          = "/blindbit.oracle.v1.OracleService/GetSpentOutputs"
+	OracleService_StreamTweaks_FullMethodName             = "/blindbit.oracle.v1.OracleService/StreamTweaks"
+	OracleService_StreamUtxos_FullMethodName              = "/blindbit.oracle.v1.OracleService/StreamUtxos"
+	OracleService_StreamSpentOutputs_FullMethodName       = "/blindbit.oracle.v1.OracleService/StreamSpentOutputs"
+	OracleService_SubscribeBlocks_FullMethodName          = "/blindbit.oracle.v1.OracleService/SubscribeBlocks"
+	OracleService_ScanRange_FullMethodName                = "/blindbit.oracle.v1.OracleService/ScanRange"
+	OracleService_RegisterWallet_FullMethodName           = "/blindbit.oracle.v1.OracleService/RegisterWallet"
+	OracleService_UnregisterWallet_FullMethodName         = "/blindbit.oracle.v1.OracleService/UnregisterWallet"
+	OracleService_GetWalletStatus_FullMethodName          = "/blindbit.oracle.v1.OracleService/GetWalletStatus"
+	OracleService_GetWalletOutputs_FullMethodName         = "/blindbit.oracle.v1.OracleService/GetWalletOutputs"
+)
+
+// OracleServiceClient is the client API for OracleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OracleServiceClient interface {
+	GetInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InfoResponse, error)
+	GetBestBlockHeight(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockHeightResponse, error)
+	GetBlockHashByHeight(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*BlockHashResponse, error)
+	StreamComputeIndex(ctx context.Context, in *RangedBlockHeightRequestFiltered, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ComputeIndexResponse], error)
+	StreamBlockScanDataShort(ctx context.Context, in *RangedBlockHeightRequestFiltered, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BlockScanDataShortResponse], error)
+	GetFullBlock(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*FullBlockResponse, error)
+	GetTweaks(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*IndexResponse, error)
+	GetUtxos(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*UTXOResponse, error)
+	GetSpentOutputs(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*IndexResponse, error)
+	StreamTweaks(ctx context.Context, in *RangedBlockHeightRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexResponse], error)
+	StreamUtxos(ctx context.Context, in *RangedBlockHeightRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UTXOResponse], error)
+	StreamSpentOutputs(ctx context.Context, in *RangedBlockHeightRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexResponse], error)
+	SubscribeBlocks(ctx context.Context, in *SubscribeBlocksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeBlocksResponse], error)
+	ScanRange(ctx context.Context, in *ScanRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanRangeResponse], error)
+	RegisterWallet(ctx context.Context, in *RegisterWalletRequest, opts ...grpc.CallOption) (*WalletStatus, error)
+	UnregisterWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetWalletStatus(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletStatus, error)
+	GetWalletOutputs(ctx context.Context, in *WalletOutputsRequest, opts ...grpc.CallOption) (*WalletOutputsResponse, error)
+}
+
+type oracleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOracleServiceClient(cc grpc.ClientConnInterface) OracleServiceClient {
+	return &oracleServiceClient{cc}
+}
+
+func (c *oracleServiceClient) GetInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) GetBestBlockHeight(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockHeightResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockHeightResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetBestBlockHeight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) GetBlockHashByHeight(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*BlockHashResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockHashResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetBlockHashByHeight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) StreamComputeIndex(ctx context.Context, in *RangedBlockHeightRequestFiltered, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ComputeIndexResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OracleService_ServiceDesc.Streams[0], OracleService_StreamComputeIndex_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RangedBlockHeightRequestFiltered, ComputeIndexResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamComputeIndexClient = grpc.ServerStreamingClient[ComputeIndexResponse]
+
+func (c *oracleServiceClient) StreamBlockScanDataShort(ctx context.Context, in *RangedBlockHeightRequestFiltered, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BlockScanDataShortResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OracleService_ServiceDesc.Streams[1], OracleService_StreamBlockScanDataShort_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RangedBlockHeightRequestFiltered, BlockScanDataShortResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamBlockScanDataShortClient = grpc.ServerStreamingClient[BlockScanDataShortResponse]
+
+func (c *oracleServiceClient) GetFullBlock(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*FullBlockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FullBlockResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetFullBlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) GetTweaks(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*IndexResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IndexResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetTweaks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) GetUtxos(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*UTXOResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UTXOResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetUtxos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) GetSpentOutputs(ctx context.Context, in *BlockHeightRequest, opts ...grpc.CallOption) (*IndexResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IndexResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetSpentOutputs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) StreamTweaks(ctx context.Context, in *RangedBlockHeightRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OracleService_ServiceDesc.Streams[2], OracleService_StreamTweaks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RangedBlockHeightRequest, IndexResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamTweaksClient = grpc.ServerStreamingClient[IndexResponse]
+
+func (c *oracleServiceClient) StreamUtxos(ctx context.Context, in *RangedBlockHeightRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UTXOResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OracleService_ServiceDesc.Streams[3], OracleService_StreamUtxos_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RangedBlockHeightRequest, UTXOResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamUtxosClient = grpc.ServerStreamingClient[UTXOResponse]
+
+func (c *oracleServiceClient) StreamSpentOutputs(ctx context.Context, in *RangedBlockHeightRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OracleService_ServiceDesc.Streams[4], OracleService_StreamSpentOutputs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RangedBlockHeightRequest, IndexResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamSpentOutputsClient = grpc.ServerStreamingClient[IndexResponse]
+
+func (c *oracleServiceClient) SubscribeBlocks(ctx context.Context, in *SubscribeBlocksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeBlocksResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OracleService_ServiceDesc.Streams[5], OracleService_SubscribeBlocks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeBlocksRequest, SubscribeBlocksResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_SubscribeBlocksClient = grpc.ServerStreamingClient[SubscribeBlocksResponse]
+
+func (c *oracleServiceClient) ScanRange(ctx context.Context, in *ScanRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanRangeResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OracleService_ServiceDesc.Streams[6], OracleService_ScanRange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanRangeRequest, ScanRangeResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_ScanRangeClient = grpc.ServerStreamingClient[ScanRangeResponse]
+
+func (c *oracleServiceClient) RegisterWallet(ctx context.Context, in *RegisterWalletRequest, opts ...grpc.CallOption) (*WalletStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletStatus)
+	err := c.cc.Invoke(ctx, OracleService_RegisterWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) UnregisterWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, OracleService_UnregisterWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) GetWalletStatus(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletStatus)
+	err := c.cc.Invoke(ctx, OracleService_GetWalletStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oracleServiceClient) GetWalletOutputs(ctx context.Context, in *WalletOutputsRequest, opts ...grpc.CallOption) (*WalletOutputsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletOutputsResponse)
+	err := c.cc.Invoke(ctx, OracleService_GetWalletOutputs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OracleServiceServer is the server API for OracleService service.
+// All implementations must embed UnimplementedOracleServiceServer
+// for forward compatibility.
+type OracleServiceServer interface {
+	GetInfo(context.Context, *emptypb.Empty) (*InfoResponse, error)
+	GetBestBlockHeight(context.Context, *emptypb.Empty) (*BlockHeightResponse, error)
+	GetBlockHashByHeight(context.Context, *BlockHeightRequest) (*BlockHashResponse, error)
+	StreamComputeIndex(*RangedBlockHeightRequestFiltered, grpc.ServerStreamingServer[ComputeIndexResponse]) error
+	StreamBlockScanDataShort(*RangedBlockHeightRequestFiltered, grpc.ServerStreamingServer[BlockScanDataShortResponse]) error
+	GetFullBlock(context.Context, *BlockHeightRequest) (*FullBlockResponse, error)
+	GetTweaks(context.Context, *BlockHeightRequest) (*IndexResponse, error)
+	GetUtxos(context.Context, *BlockHeightRequest) (*UTXOResponse, error)
+	GetSpentOutputs(context.Context, *BlockHeightRequest) (*IndexResponse, error)
+	StreamTweaks(*RangedBlockHeightRequest, grpc.ServerStreamingServer[IndexResponse]) error
+	StreamUtxos(*RangedBlockHeightRequest, grpc.ServerStreamingServer[UTXOResponse]) error
+	StreamSpentOutputs(*RangedBlockHeightRequest, grpc.ServerStreamingServer[IndexResponse]) error
+	SubscribeBlocks(*SubscribeBlocksRequest, grpc.ServerStreamingServer[SubscribeBlocksResponse]) error
+	ScanRange(*ScanRangeRequest, grpc.ServerStreamingServer[ScanRangeResponse]) error
+	RegisterWallet(context.Context, *RegisterWalletRequest) (*WalletStatus, error)
+	UnregisterWallet(context.Context, *WalletRequest) (*emptypb.Empty, error)
+	GetWalletStatus(context.Context, *WalletRequest) (*WalletStatus, error)
+	GetWalletOutputs(context.Context, *WalletOutputsRequest) (*WalletOutputsResponse, error)
+	mustEmbedUnimplementedOracleServiceServer()
+}
+
+// UnimplementedOracleServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOracleServiceServer struct{}
+
+func (UnimplementedOracleServiceServer) GetInfo(context.Context, *emptypb.Empty) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedOracleServiceServer) GetBestBlockHeight(context.Context, *emptypb.Empty) (*BlockHeightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBestBlockHeight not implemented")
+}
+func (UnimplementedOracleServiceServer) GetBlockHashByHeight(context.Context, *BlockHeightRequest) (*BlockHashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockHashByHeight not implemented")
+}
+func (UnimplementedOracleServiceServer) StreamComputeIndex(*RangedBlockHeightRequestFiltered, grpc.ServerStreamingServer[ComputeIndexResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamComputeIndex not implemented")
+}
+func (UnimplementedOracleServiceServer) StreamBlockScanDataShort(*RangedBlockHeightRequestFiltered, grpc.ServerStreamingServer[BlockScanDataShortResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBlockScanDataShort not implemented")
+}
+func (UnimplementedOracleServiceServer) GetFullBlock(context.Context, *BlockHeightRequest) (*FullBlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFullBlock not implemented")
+}
+func (UnimplementedOracleServiceServer) GetTweaks(context.Context, *BlockHeightRequest) (*IndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTweaks not implemented")
+}
+func (UnimplementedOracleServiceServer) GetUtxos(context.Context, *BlockHeightRequest) (*UTXOResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUtxos not implemented")
+}
+func (UnimplementedOracleServiceServer) GetSpentOutputs(context.Context, *BlockHeightRequest) (*IndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpentOutputs not implemented")
+}
+func (UnimplementedOracleServiceServer) StreamTweaks(*RangedBlockHeightRequest, grpc.ServerStreamingServer[IndexResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTweaks not implemented")
+}
+func (UnimplementedOracleServiceServer) StreamUtxos(*RangedBlockHeightRequest, grpc.ServerStreamingServer[UTXOResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamUtxos not implemented")
+}
+func (UnimplementedOracleServiceServer) StreamSpentOutputs(*RangedBlockHeightRequest, grpc.ServerStreamingServer[IndexResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSpentOutputs not implemented")
+}
+func (UnimplementedOracleServiceServer) SubscribeBlocks(*SubscribeBlocksRequest, grpc.ServerStreamingServer[SubscribeBlocksResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeBlocks not implemented")
+}
+func (UnimplementedOracleServiceServer) ScanRange(*ScanRangeRequest, grpc.ServerStreamingServer[ScanRangeResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ScanRange not implemented")
+}
+func (UnimplementedOracleServiceServer) RegisterWallet(context.Context, *RegisterWalletRequest) (*WalletStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterWallet not implemented")
+}
+func (UnimplementedOracleServiceServer) UnregisterWallet(context.Context, *WalletRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnregisterWallet not implemented")
+}
+func (UnimplementedOracleServiceServer) GetWalletStatus(context.Context, *WalletRequest) (*WalletStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWalletStatus not implemented")
+}
+func (UnimplementedOracleServiceServer) GetWalletOutputs(context.Context, *WalletOutputsRequest) (*WalletOutputsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWalletOutputs not implemented")
+}
+func (UnimplementedOracleServiceServer) mustEmbedUnimplementedOracleServiceServer() {}
+func (UnimplementedOracleServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeOracleServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OracleServiceServer will
+// result in compilation errors.
+type UnsafeOracleServiceServer interface {
+	mustEmbedUnimplementedOracleServiceServer()
+}
+
+func RegisterOracleServiceServer(s grpc.ServiceRegistrar, srv OracleServiceServer) {
+	// If the following call pancis, it indicates UnimplementedOracleServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OracleService_ServiceDesc, srv)
+}
+
+func _OracleService_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetInfo(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_GetBestBlockHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetBestBlockHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetBestBlockHeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetBestBlockHeight(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_GetBlockHashByHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetBlockHashByHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetBlockHashByHeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetBlockHashByHeight(ctx, req.(*BlockHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_StreamComputeIndex_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangedBlockHeightRequestFiltered)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OracleServiceServer).StreamComputeIndex(m, &grpc.GenericServerStream[RangedBlockHeightRequestFiltered, ComputeIndexResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamComputeIndexServer = grpc.ServerStreamingServer[ComputeIndexResponse]
+
+func _OracleService_StreamBlockScanDataShort_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangedBlockHeightRequestFiltered)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OracleServiceServer).StreamBlockScanDataShort(m, &grpc.GenericServerStream[RangedBlockHeightRequestFiltered, BlockScanDataShortResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamBlockScanDataShortServer = grpc.ServerStreamingServer[BlockScanDataShortResponse]
+
+func _OracleService_GetFullBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetFullBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetFullBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetFullBlock(ctx, req.(*BlockHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_GetTweaks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetTweaks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetTweaks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetTweaks(ctx, req.(*BlockHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_GetUtxos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetUtxos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetUtxos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetUtxos(ctx, req.(*BlockHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_GetSpentOutputs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetSpentOutputs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetSpentOutputs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetSpentOutputs(ctx, req.(*BlockHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_StreamTweaks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangedBlockHeightRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OracleServiceServer).StreamTweaks(m, &grpc.GenericServerStream[RangedBlockHeightRequest, IndexResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamTweaksServer = grpc.ServerStreamingServer[IndexResponse]
+
+func _OracleService_StreamUtxos_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangedBlockHeightRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OracleServiceServer).StreamUtxos(m, &grpc.GenericServerStream[RangedBlockHeightRequest, UTXOResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamUtxosServer = grpc.ServerStreamingServer[UTXOResponse]
+
+func _OracleService_StreamSpentOutputs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RangedBlockHeightRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OracleServiceServer).StreamSpentOutputs(m, &grpc.GenericServerStream[RangedBlockHeightRequest, IndexResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_StreamSpentOutputsServer = grpc.ServerStreamingServer[IndexResponse]
+
+func _OracleService_SubscribeBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeBlocksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OracleServiceServer).SubscribeBlocks(m, &grpc.GenericServerStream[SubscribeBlocksRequest, SubscribeBlocksResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_SubscribeBlocksServer = grpc.ServerStreamingServer[SubscribeBlocksResponse]
+
+func _OracleService_ScanRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OracleServiceServer).ScanRange(m, &grpc.GenericServerStream[ScanRangeRequest, ScanRangeResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OracleService_ScanRangeServer = grpc.ServerStreamingServer[ScanRangeResponse]
+
+func _OracleService_RegisterWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).RegisterWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_RegisterWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).RegisterWallet(ctx, req.(*RegisterWalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_UnregisterWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).UnregisterWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_UnregisterWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).UnregisterWallet(ctx, req.(*WalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_GetWalletStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetWalletStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetWalletStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetWalletStatus(ctx, req.(*WalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OracleService_GetWalletOutputs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WalletOutputsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OracleServiceServer).GetWalletOutputs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OracleService_GetWalletOutputs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OracleServiceServer).GetWalletOutputs(ctx, req.(*WalletOutputsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OracleService_ServiceDesc is the grpc.ServiceDesc for OracleService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OracleService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "blindbit.oracle.v1.OracleService",
+	HandlerType: (*OracleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetInfo",
+			Handler:    _OracleService_GetInfo_Handler,
+		},
+		{
+			MethodName: "GetBestBlockHeight",
+			Handler:    _OracleService_GetBestBlockHeight_Handler,
+		},
+		{
+			MethodName: "GetBlockHashByHeight",
+			Handler:    _OracleService_GetBlockHashByHeight_Handler,
+		},
+		{
+			MethodName: "GetFullBlock",
+			Handler:    _OracleService_GetFullBlock_Handler,
+		},
+		{
+			MethodName: "GetTweaks",
+			Handler:    _OracleService_GetTweaks_Handler,
+		},
+		{
+			MethodName: "GetUtxos",
+			Handler:    _OracleService_GetUtxos_Handler,
+		},
+		{
+			MethodName: "GetSpentOutputs",
+			Handler:    _OracleService_GetSpentOutputs_Handler,
+		},
+		{
+			MethodName: "RegisterWallet",
+			Handler:    _OracleService_RegisterWallet_Handler,
+		},
+		{
+			MethodName: "UnregisterWallet",
+			Handler:    _OracleService_UnregisterWallet_Handler,
+		},
+		{
+			MethodName: "GetWalletStatus",
+			Handler:    _OracleService_GetWalletStatus_Handler,
+		},
+		{
+			MethodName: "GetWalletOutputs",
+			Handler:    _OracleService_GetWalletOutputs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamComputeIndex",
+			Handler:       _OracleService_StreamComputeIndex_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamBlockScanDataShort",
+			Handler:       _OracleService_StreamBlockScanDataShort_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamTweaks",
+			Handler:       _OracleService_StreamTweaks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamUtxos",
+			Handler:       _OracleService_StreamUtxos_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamSpentOutputs",
+			Handler:       _OracleService_StreamSpentOutputs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeBlocks",
+			Handler:       _OracleService_SubscribeBlocks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ScanRange",
+			Handler:       _OracleService_ScanRange_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "oracle.proto",
+}